@@ -6,6 +6,7 @@ import (
 	"golang-clean-web-api/api/middleware"
 	"golang-clean-web-api/api/router"
 	"golang-clean-web-api/config"
+	"golang-clean-web-api/constant"
 	"golang-clean-web-api/pkg/logging"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,8 @@ func InitServer(cfg *config.Config) {
 
 	r.Use(gin.Logger())
 	r.Use(gin.Recovery())
+	r.Use(middleware.RequestId())
+	r.Use(middleware.DbSession())
 	r.Use(middleware.Cors(cfg))
 	r.Use(middleware.RateLimiter(cfg))
 
@@ -56,5 +59,17 @@ func RegisterRoutes(r *gin.Engine, cfg *config.Config) {
 		router.Country(countries, cfg)
 		router.City(cities, cfg)
 		router.Color(colors, cfg)
+
+		// Search across entities
+		search := v1.Group("/search", middleware.Authentication(cfg))
+		router.Search(search, cfg)
+
+		// Audit log - admin only
+		audit := v1.Group("/audit", middleware.Authentication(cfg), middleware.Authorization(constant.AdminRoleName))
+		router.Audit(audit, cfg)
+
+		// Webhook subscriptions - admin only
+		webhooks := v1.Group("/webhooks", middleware.Authentication(cfg), middleware.Authorization(constant.AdminRoleName))
+		router.Webhook(webhooks, cfg)
 	}
 }
@@ -0,0 +1,17 @@
+package dto
+
+import "golang-clean-web-api/usecase/dto"
+
+// AggregateRowResponse is a group: the values of its group-by fields and of its aggregates
+type AggregateRowResponse struct {
+	Group  map[string]interface{} `json:"group,omitempty"`
+	Values map[string]interface{} `json:"values"`
+}
+
+func ToAggregateRowResponse(from dto.AggregateRow) AggregateRowResponse {
+	response := AggregateRowResponse{Values: from.Values}
+	if len(from.Group) > 0 {
+		response.Group = from.Group
+	}
+	return response
+}
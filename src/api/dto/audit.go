@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang-clean-web-api/usecase/dto"
+)
+
+type AuditLogResponse struct {
+	Id         int             `json:"id"`
+	EntityType string          `json:"entityType,omitempty"`
+	EntityId   int             `json:"entityId,omitempty"`
+	Action     string          `json:"action,omitempty"`
+	UserId     int             `json:"userId,omitempty"`
+	Username   string          `json:"username,omitempty"`
+	RequestId  string          `json:"requestId,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	Changes    json.RawMessage `json:"changes,omitempty"`
+}
+
+func ToAuditLogResponse(from dto.AuditLog) AuditLogResponse {
+	response := AuditLogResponse{
+		Id:         from.Id,
+		EntityType: from.EntityType,
+		EntityId:   from.EntityId,
+		Action:     from.Action,
+		UserId:     from.UserId,
+		Username:   from.Username,
+		RequestId:  from.RequestId,
+		CreatedAt:  from.CreatedAt,
+	}
+	if from.Changes != "" {
+		response.Changes = json.RawMessage(from.Changes)
+	}
+	return response
+}
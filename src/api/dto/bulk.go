@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"golang-clean-web-api/api/validation"
+)
+
+const (
+	BulkAtomic     = "atomic"
+	BulkBestEffort = "bestEffort"
+)
+
+// BulkRequest is a list of writes. In atomic mode, the default, either all of
+// them are applied or none, in bestEffort mode each one stands on its own.
+type BulkRequest struct {
+	Mode       string                 `json:"mode,omitempty" binding:"omitempty,oneof=atomic bestEffort"`
+	Operations []BulkOperationRequest `json:"operations" binding:"required,min=1"`
+}
+
+// BulkOperationRequest is validated on its own, Data is the body the single
+// create or update endpoint takes and Version the ETag it would be sent with
+type BulkOperationRequest struct {
+	Op      string          `json:"op" binding:"required,oneof=create update delete"`
+	Id      int             `json:"id,omitempty" binding:"required_unless=Op create"`
+	Version int             `json:"version,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+}
+
+type BulkResponse[TResponse any] struct {
+	Succeeded int                             `json:"succeeded"`
+	Failed    int                             `json:"failed"`
+	Results   []BulkResultResponse[TResponse] `json:"results"`
+}
+
+// BulkResultResponse is the outcome of the operation at Index, Status is the
+// status code the single endpoint would have answered with
+type BulkResultResponse[TResponse any] struct {
+	Index            int                           `json:"index"`
+	Op               string                        `json:"op"`
+	Id               int                           `json:"id,omitempty"`
+	Status           int                           `json:"status"`
+	Result           *TResponse                    `json:"result,omitempty"`
+	Error            string                        `json:"error,omitempty"`
+	ValidationErrors *[]validation.ValidationError `json:"validationErrors,omitempty"`
+}
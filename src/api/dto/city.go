@@ -12,17 +12,21 @@ type UpdateCityRequest struct {
 	CountryId int    `json:"countryId,omitempty"`
 }
 type CityResponse struct {
-	Id      int             `json:"id"`
-	Name    string          `json:"name"`
-	Country CountryResponse `json:"country,omitempty"`
+	Id      int              `json:"id"`
+	Name    string           `json:"name,omitempty"`
+	Country *CountryResponse `json:"country,omitempty"`
 }
 
 func ToCityResponse(from dto.City) CityResponse {
-	return CityResponse{
-		Id:      from.Id,
-		Name:    from.Name,
-		Country: ToCountryResponse(from.Country),
+	response := CityResponse{
+		Id:   from.Id,
+		Name: from.Name,
 	}
+	if from.Country.Id != 0 {
+		country := ToCountryResponse(from.Country)
+		response.Country = &country
+	}
+	return response
 }
 
 func ToCreateCity(from CreateCityRequest) dto.CreateCity {
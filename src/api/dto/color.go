@@ -39,3 +39,9 @@ func ToUpdateColor(from UpdateColorRequest) dto.UpdateColor {
 		HexCode: from.HexCode,
 	}
 }
+
+// WithColorKey names the color of an upsert after the key in the path
+func WithColorKey(key string, from CreateColorRequest) CreateColorRequest {
+	from.Name = key
+	return from
+}
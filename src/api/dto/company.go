@@ -12,17 +12,21 @@ type UpdateCompanyRequest struct {
 	CountryId int    `json:"countryId,omitempty"`
 }
 type CompanyResponse struct {
-	Id      int             `json:"id"`
-	Name    string          `json:"name"`
-	Country CountryResponse `json:"country,omitempty"`
+	Id      int              `json:"id"`
+	Name    string           `json:"name,omitempty"`
+	Country *CountryResponse `json:"country,omitempty"`
 }
 
 func ToCompanyResponse(from dto.Company) CompanyResponse {
-	return CompanyResponse{
-		Id:      from.Id,
-		Name:    from.Name,
-		Country: ToCountryResponse(from.Country),
+	response := CompanyResponse{
+		Id:   from.Id,
+		Name: from.Name,
 	}
+	if from.Country.Id != 0 {
+		country := ToCountryResponse(from.Country)
+		response.Country = &country
+	}
+	return response
 }
 
 func ToCreateCompany(from CreateCompanyRequest) dto.CreateCompany {
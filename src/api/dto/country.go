@@ -2,22 +2,42 @@ package dto
 
 import "golang-clean-web-api/usecase/dto"
 
+type CreateCountryRequest struct {
+	Name   string   `json:"name" binding:"required,alpha,min=3,max=20"`
+	Cities []string `json:"cities,omitempty" binding:"max=50,dive,alpha,min=3,max=20"`
+}
+
 type CreateUpdateCountryRequest struct {
 	Name string `json:"name" binding:"required,alpha,min=3,max=20"`
 }
 
 type CountryResponse struct {
 	Id        int               `json:"id"`
-	Name      string            `json:"name"`
+	Name      string            `json:"name,omitempty"`
 	Cities    []CityResponse    `json:"cities,omitempty"`
 	Companies []CompanyResponse `json:"companies,omitempty"`
 }
 
+// ToCountryResponse maps the relations that were loaded, unrequested ones stay empty
 func ToCountryResponse(from dto.Country) CountryResponse {
-	return CountryResponse{
+	response := CountryResponse{
 		Id:   from.Id,
 		Name: from.Name,
 	}
+	for _, city := range from.Cities {
+		response.Cities = append(response.Cities, ToCityResponse(city))
+	}
+	for _, company := range from.Companies {
+		response.Companies = append(response.Companies, ToCompanyResponse(company))
+	}
+	return response
+}
+
+func ToCreateCountry(from CreateCountryRequest) dto.CreateCountry {
+	return dto.CreateCountry{
+		Name:   from.Name,
+		Cities: from.Cities,
+	}
 }
 
 func ToCreateUpdateCountry(from CreateUpdateCountryRequest) dto.Name {
@@ -25,3 +45,9 @@ func ToCreateUpdateCountry(from CreateUpdateCountryRequest) dto.Name {
 		Name: from.Name,
 	}
 }
+
+// WithCountryKey names the country of an upsert after the key in the path
+func WithCountryKey(key string, from CreateUpdateCountryRequest) CreateUpdateCountryRequest {
+	from.Name = key
+	return from
+}
@@ -0,0 +1,187 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang-clean-web-api/domain/filter"
+)
+
+const defaultExportLanguage = "en"
+
+// exportHeaders label the columns in the languages other than english, the
+// english labels are made from the column names
+var exportHeaders = map[string]map[string]string{
+	"fa": {
+		"id":                "شناسه",
+		"name":              "نام",
+		"hexCode":           "کد رنگ",
+		"cities":            "شهرها",
+		"companies":         "شرکت‌ها",
+		"country.id":        "شناسه کشور",
+		"country.name":      "نام کشور",
+		"country.cities":    "شهرهای کشور",
+		"country.companies": "شرکت‌های کشور",
+	},
+}
+
+// ExportColumns are the columns of an export of TResponse: the json names of its
+// fields, country.name for a field of a nested object. Lists are one column.
+func ExportColumns[TResponse any]() []string {
+	return exportColumns(reflect.TypeOf((*TResponse)(nil)).Elem(), "")
+}
+
+func exportColumns(t reflect.Type, prefix string) []string {
+	columns := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && fieldType.NumField() > 0 {
+			columns = append(columns, exportColumns(fieldType, prefix+name+".")...)
+			continue
+		}
+		columns = append(columns, prefix+name)
+	}
+	return columns
+}
+
+// SelectExportColumns reads the comma separated columns of the query string, an
+// empty selection exports all the columns of TResponse
+func SelectExportColumns[TResponse any](raw string) ([]string, error) {
+	all := ExportColumns[TResponse]()
+	if raw == "" {
+		return all, nil
+	}
+	selected := []string{}
+	for _, column := range strings.Split(raw, ",") {
+		column = strings.TrimSpace(column)
+		if !slices.Contains(all, column) {
+			return nil, &filter.QueryError{Parameter: "columns", Value: raw,
+				Message: "unknown column " + strconv.Quote(column) + ", expected some of " + strings.Join(all, ",")}
+		}
+		selected = append(selected, column)
+	}
+	return selected, nil
+}
+
+// ToExportRow returns the values of the columns in the json of the response, so
+// exports match what the other endpoints return. Numbers are json.Number.
+func ToExportRow(response any, columns []string) ([]any, error) {
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	object := map[string]any{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		var value any = object
+		for _, name := range strings.Split(column, ".") {
+			parent, ok := value.(map[string]any)
+			if !ok {
+				value = nil
+				break
+			}
+			value = parent[name]
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// ExportLanguage picks the language of the headers: the lang parameter when it
+// is supported, otherwise the first supported one of the Accept-Language header
+func ExportLanguage(lang string, acceptLanguage string) string {
+	if supportedExportLanguage(lang) {
+		return primaryLanguage(lang)
+	}
+
+	type preference struct {
+		language string
+		quality  float64
+	}
+	preferences := []preference{}
+	for _, item := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		preferences = append(preferences, preference{language: tag, quality: quality})
+	}
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].quality > preferences[j].quality })
+	for _, p := range preferences {
+		if p.quality > 0 && supportedExportLanguage(p.language) {
+			return primaryLanguage(p.language)
+		}
+	}
+	return defaultExportLanguage
+}
+
+func supportedExportLanguage(tag string) bool {
+	language := primaryLanguage(tag)
+	_, ok := exportHeaders[language]
+	return ok || language == defaultExportLanguage
+}
+
+// primaryLanguage is fa for fa-IR
+func primaryLanguage(tag string) string {
+	language, _, _ := strings.Cut(tag, "-")
+	return strings.ToLower(strings.TrimSpace(language))
+}
+
+// ExportHeaders labels the columns in the language, in english when there is no label
+func ExportHeaders(columns []string, language string) []string {
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		if header, ok := exportHeaders[language][column]; ok {
+			headers[i] = header
+			continue
+		}
+		headers[i] = humanize(column)
+	}
+	return headers
+}
+
+// humanize turns country.hexCode into Country Hex Code
+func humanize(column string) string {
+	words := []string{}
+	for _, name := range strings.Split(column, ".") {
+		word := []rune{}
+		for i, r := range name {
+			if i > 0 && unicode.IsUpper(r) {
+				words = append(words, string(word))
+				word = word[:0]
+			}
+			if len(word) == 0 {
+				r = unicode.ToUpper(r)
+			}
+			word = append(word, r)
+		}
+		words = append(words, string(word))
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,193 @@
+package dto
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang-clean-web-api/api/validation"
+)
+
+const (
+	ImportCSV  = "csv"
+	ImportJSON = "json"
+)
+
+// importListSeparator separates the items of a list in a csv cell, e.g. Tehran|Shiraz
+const importListSeparator = "|"
+
+// ImportRecord is a row of an upload by column, Row is its position counted from 1
+// after the csv header
+type ImportRecord struct {
+	Row    int
+	Values map[string]any
+}
+
+// ImportResponse reports every row of an import. In a dry run nothing was kept,
+// the counts tell what the same upload would do.
+type ImportResponse[TResponse any] struct {
+	DryRun  bool                           `json:"dryRun"`
+	Created int                            `json:"created"`
+	Updated int                            `json:"updated"`
+	Failed  int                            `json:"failed"`
+	Rows    []ImportRowResponse[TResponse] `json:"rows"`
+}
+
+// ImportRowResponse is the outcome of a row: created, updated or failed. Status is
+// the status code the create endpoint would have answered a failed row with.
+type ImportRowResponse[TResponse any] struct {
+	Row              int                           `json:"row"`
+	Outcome          string                        `json:"outcome"`
+	Status           int                           `json:"status,omitempty"`
+	Result           *TResponse                    `json:"result,omitempty"`
+	Error            string                        `json:"error,omitempty"`
+	ValidationErrors *[]validation.ValidationError `json:"validationErrors,omitempty"`
+}
+
+// ImportColumnError rejects a column of a record that cannot be bound to the request
+type ImportColumnError struct {
+	Column  string
+	Value   string
+	Message string
+}
+
+func (e *ImportColumnError) Error() string {
+	return fmt.Sprintf("column %s: %s", e.Column, e.Message)
+}
+
+// ParseImport reads a csv upload with a header row of json names, or a json array
+// of objects. Empty csv cells are left out, like missing json fields.
+func ParseImport(format string, r io.Reader) ([]ImportRecord, error) {
+	switch format {
+	case ImportCSV:
+		return parseImportCSV(r)
+	case ImportJSON:
+		return parseImportJSON(r)
+	}
+	return nil, fmt.Errorf("unsupported import format %q, expected csv or json", format)
+}
+
+func parseImportCSV(r io.Reader) ([]ImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the csv has no header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	// a BOM is what spreadsheets put in front of utf-8 files
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	records := []ImportRecord{}
+	for {
+		line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		record := ImportRecord{Row: len(records) + 1, Values: map[string]any{}}
+		for i, value := range line {
+			if value = strings.TrimSpace(value); value != "" {
+				record.Values[header[i]] = value
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+func parseImportJSON(r io.Reader) ([]ImportRecord, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	objects := []map[string]any{}
+	if err := decoder.Decode(&objects); err != nil {
+		return nil, fmt.Errorf("expected a json array of objects: %w", err)
+	}
+	records := make([]ImportRecord, 0, len(objects))
+	for i, object := range objects {
+		records = append(records, ImportRecord{Row: i + 1, Values: object})
+	}
+	return records, nil
+}
+
+// ToImportRequest binds the values of a record to a request like the json of the
+// create endpoint. Csv text is read as the type of the field it goes to, lists
+// separated by |. An unknown column fails the record.
+func ToImportRequest[TRequest any](values map[string]any) (TRequest, error) {
+	var request TRequest
+	fields := map[string]reflect.Type{}
+	t := reflect.TypeOf(request)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+
+	converted := map[string]any{}
+	for column, value := range values {
+		fieldType, ok := fields[column]
+		if !ok {
+			return request, &ImportColumnError{Column: column, Message: "unknown column"}
+		}
+		text, ok := value.(string)
+		if !ok {
+			converted[column] = value
+			continue
+		}
+		parsed, err := parseImportValue(text, fieldType)
+		if err != nil {
+			return request, &ImportColumnError{Column: column, Value: text, Message: "expected a value of type " + fieldType.String()}
+		}
+		converted[column] = parsed
+	}
+
+	encoded, err := json.Marshal(converted)
+	if err != nil {
+		return request, err
+	}
+	var typeError *json.UnmarshalTypeError
+	if err := json.Unmarshal(encoded, &request); errors.As(err, &typeError) {
+		return request, &ImportColumnError{Column: typeError.Field, Message: "expected a value of type " + typeError.Type.String()}
+	} else if err != nil {
+		return request, err
+	}
+	return request, nil
+}
+
+func parseImportValue(text string, t reflect.Type) (any, error) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return parseImportValue(text, t.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(text, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(text, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(text, 64)
+	case reflect.Bool:
+		return strconv.ParseBool(text)
+	case reflect.Slice:
+		items := []any{}
+		for _, item := range strings.Split(text, importListSeparator) {
+			value, err := parseImportValue(strings.TrimSpace(item), t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	}
+	return text, nil
+}
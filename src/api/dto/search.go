@@ -0,0 +1,30 @@
+package dto
+
+import "golang-clean-web-api/usecase/dto"
+
+type SearchHitResponse struct {
+	Type      string  `json:"type"`
+	Id        int     `json:"id"`
+	Name      string  `json:"name"`
+	Rank      float64 `json:"rank"`
+	Highlight string  `json:"highlight,omitempty"`
+}
+
+type SuggestionResponse struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func ToSearchHitResponse(from dto.SearchHit) SearchHitResponse {
+	return SearchHitResponse{
+		Type:      from.Type,
+		Id:        from.Id,
+		Name:      from.Name,
+		Rank:      from.Rank,
+		Highlight: from.Highlight,
+	}
+}
+
+func ToSuggestionResponse(from dto.IdName) SuggestionResponse {
+	return SuggestionResponse{Id: from.Id, Name: from.Name}
+}
@@ -0,0 +1,124 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/usecase/dto"
+)
+
+// CreateWebhookSubscriptionRequest subscribes a url to the events of some
+// entities. Empty lists subscribe to all of them, an empty secret is generated
+// and returned once.
+type CreateWebhookSubscriptionRequest struct {
+	Url         string   `json:"url" binding:"required,url,max=500"`
+	EntityTypes []string `json:"entityTypes" binding:"max=4,unique,dive,oneof=countries cities companies colors"`
+	EventTypes  []string `json:"eventTypes" binding:"max=3,unique,dive,oneof=EntityCreated EntityUpdated EntityDeleted"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=100"`
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+// UpdateWebhookSubscriptionRequest replaces the url and filters, the secret stays
+type UpdateWebhookSubscriptionRequest struct {
+	Url         string   `json:"url" binding:"required,url,max=500"`
+	EntityTypes []string `json:"entityTypes" binding:"max=4,unique,dive,oneof=countries cities companies colors"`
+	EventTypes  []string `json:"eventTypes" binding:"max=3,unique,dive,oneof=EntityCreated EntityUpdated EntityDeleted"`
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+type WebhookSubscriptionResponse struct {
+	Id          int      `json:"id"`
+	Url         string   `json:"url,omitempty"`
+	EntityTypes []string `json:"entityTypes"`
+	EventTypes  []string `json:"eventTypes"`
+	Active      bool     `json:"active"`
+	// Secret is only returned when the subscription is created
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookDeliveryResponse struct {
+	Id             int             `json:"id"`
+	SubscriptionId int             `json:"subscriptionId"`
+	EventId        int             `json:"eventId,omitempty"`
+	EventType      string          `json:"eventType"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseCode   int             `json:"responseCode,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+}
+
+func ToWebhookSubscriptionResponse(from dto.WebhookSubscription) WebhookSubscriptionResponse {
+	return WebhookSubscriptionResponse{
+		Id:          from.Id,
+		Url:         from.Url,
+		EntityTypes: splitList(from.EntityTypes),
+		EventTypes:  splitList(from.EventTypes),
+		Active:      from.Active,
+	}
+}
+
+// ToCreatedWebhookSubscriptionResponse shows the secret the receiver verifies signatures with
+func ToCreatedWebhookSubscriptionResponse(from dto.WebhookSubscription) WebhookSubscriptionResponse {
+	response := ToWebhookSubscriptionResponse(from)
+	response.Secret = from.Secret
+	return response
+}
+
+func ToCreateWebhookSubscription(from CreateWebhookSubscriptionRequest) dto.CreateWebhookSubscription {
+	return dto.CreateWebhookSubscription{
+		Url:         from.Url,
+		EntityTypes: strings.Join(from.EntityTypes, ","),
+		EventTypes:  strings.Join(from.EventTypes, ","),
+		Secret:      from.Secret,
+		Active:      from.Active == nil || *from.Active,
+	}
+}
+
+func ToUpdateWebhookSubscription(from UpdateWebhookSubscriptionRequest) dto.UpdateWebhookSubscription {
+	return dto.UpdateWebhookSubscription{
+		Url:         from.Url,
+		EntityTypes: strings.Join(from.EntityTypes, ","),
+		EventTypes:  strings.Join(from.EventTypes, ","),
+		Active:      from.Active == nil || *from.Active,
+	}
+}
+
+func ToWebhookDeliveryResponse(from dto.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		Id:             from.Id,
+		SubscriptionId: from.SubscriptionId,
+		EventId:        int(from.EventId.Int64),
+		EventType:      from.EventType,
+		Status:         from.Status,
+		Attempts:       from.Attempts,
+		ResponseCode:   from.ResponseCode,
+		LastError:      from.LastError,
+		CreatedAt:      from.CreatedAt,
+	}
+	if from.Status == model.WebhookPending {
+		response.NextAttemptAt = &from.NextAttemptAt
+	}
+	if from.DeliveredAt.Valid {
+		response.DeliveredAt = &from.DeliveredAt.Time
+	}
+	if from.Payload != "" {
+		response.Payload = json.RawMessage(from.Payload)
+	}
+	return response
+}
+
+// splitList reads a comma separated list, empty for none
+func splitList(list string) []string {
+	if list == "" {
+		return []string{}
+	}
+	return strings.Split(list, ",")
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"golang-clean-web-api/api/dto"
+	_ "golang-clean-web-api/api/helper"
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/dependency"
+	_ "golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	usecase *usecase.AuditUsecase
+}
+
+func NewAuditHandler(cfg *config.Config) *AuditHandler {
+	return &AuditHandler{
+		usecase: usecase.NewAuditUsecase(cfg, dependency.GetAuditRepository(cfg)),
+	}
+}
+
+// ListAuditLogs godoc
+// @Summary List audit records
+// @Description List the audit records of all entities, newest first, admin only
+// @Tags Audit
+// @Accept json
+// @produces json
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. entityType:equals:cities, userId:equals:3 or createdAt:inRange:2024-01-01|2024-02-01" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -createdAt"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} helper.BaseHttpResponse{result=filter.PagedList[dto.AuditLogResponse]} "Audit response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/audit [get]
+// @Security AuthBearer
+func (h *AuditHandler) GetByQuery(c *gin.Context) {
+	GetByQuery(c, dto.ToAuditLogResponse, h.usecase.GetByFilter)
+}
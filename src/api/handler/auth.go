@@ -6,8 +6,9 @@ import (
 	"golang-clean-web-api/api/dto"
 	"golang-clean-web-api/api/helper"
 	"golang-clean-web-api/config"
+	"golang-clean-web-api/dependency"
 	"golang-clean-web-api/domain/model"
-	"golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/domain/repository"
 	"golang-clean-web-api/pkg/jwt"
 
 	"github.com/gin-gonic/gin"
@@ -16,13 +17,15 @@ import (
 
 type AuthHandler struct {
 	config       *config.Config
+	users        repository.UserRepository
 	tokenService *jwt.TokenService
 }
 
 func NewAuthHandler(cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
 		config:       cfg,
-		tokenService: jwt.NewTokenService(cfg),
+		users:        dependency.GetUserRepository(cfg),
+		tokenService: dependency.GetTokenService(cfg),
 	}
 }
 
@@ -46,11 +49,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	db := database.GetDb()
-
 	// Check if user exists
-	var existingUser model.User
-	if err := db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
+	if _, err := h.users.GetByUsername(c.Request.Context(), req.Username); err == nil {
 		c.JSON(http.StatusConflict,
 			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
 		return
@@ -72,7 +72,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		IsActive: true,
 	}
 
-	if err := db.Create(&user).Error; err != nil {
+	user, err = h.users.Create(c.Request.Context(), user)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError,
 			helper.GenerateBaseResponse(nil, false, helper.InternalError))
 		return
@@ -102,18 +103,16 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	db := database.GetDb()
-
 	// Find user
-	var user model.User
-	if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
+	user, err := h.users.GetByUsername(c.Request.Context(), req.Username)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized,
 			helper.GenerateBaseResponse(nil, false, helper.AuthError))
 		return
 	}
 
 	// Check password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
 		c.JSON(http.StatusUnauthorized,
 			helper.GenerateBaseResponse(nil, false, helper.AuthError))
 		return
@@ -127,14 +126,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Generate tokens
-	accessToken, err := h.tokenService.GenerateAccessToken(uint(user.Id), user.Username)
+	accessToken, err := h.tokenService.GenerateAccessToken(uint(user.Id), user.Username, user.RoleList()...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError,
 			helper.GenerateBaseResponse(nil, false, helper.InternalError))
 		return
 	}
 
-	refreshToken, err := h.tokenService.GenerateRefreshToken(uint(user.Id), user.Username)
+	refreshToken, err := h.tokenService.GenerateRefreshToken(uint(user.Id), user.Username, user.RoleList()...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError,
 			helper.GenerateBaseResponse(nil, false, helper.InternalError))
@@ -178,15 +177,23 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	// Reload the user, so a removed role or a deactivation takes effect on refresh
+	user, err := h.users.GetById(c.Request.Context(), int(claims.UserID))
+	if err != nil || !user.IsActive {
+		c.JSON(http.StatusUnauthorized,
+			helper.GenerateBaseResponse(nil, false, helper.AuthError))
+		return
+	}
+
 	// Generate new tokens
-	accessToken, err := h.tokenService.GenerateAccessToken(claims.UserID, claims.Username)
+	accessToken, err := h.tokenService.GenerateAccessToken(uint(user.Id), user.Username, user.RoleList()...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError,
 			helper.GenerateBaseResponse(nil, false, helper.InternalError))
 		return
 	}
 
-	refreshToken, err := h.tokenService.GenerateRefreshToken(claims.UserID, claims.Username)
+	refreshToken, err := h.tokenService.GenerateRefreshToken(uint(user.Id), user.Username, user.RoleList()...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError,
 			helper.GenerateBaseResponse(nil, false, helper.InternalError))
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"golang-clean-web-api/api/dto"
+	"golang-clean-web-api/api/helper"
+	"golang-clean-web-api/api/validation"
+	"golang-clean-web-api/pkg/service_errors"
+	usecaseDto "golang-clean-web-api/usecase/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Bulk applies a list of creates, updates and deletes
+// TCreateRequest, TUpdateRequest: Http bodies of a single create and update, validated per operation
+// TUCreate, TUUpdate: Usecase inputs mapped from them with createMapper and updateMapper
+// The response has a result per operation. It is 200 when all of them succeeded, otherwise
+// 207 in bestEffort mode and the status of the first failure in atomic mode.
+func Bulk[TCreateRequest any, TUpdateRequest any, TUCreate any, TUUpdate any, TUOutput any, TResponse any](c *gin.Context,
+	createMapper func(req TCreateRequest) (res TUCreate),
+	updateMapper func(req TUpdateRequest) (res TUUpdate),
+	responseMapper func(req TUOutput) (res TResponse),
+	usecaseBulk func(ctx context.Context, atomic bool,
+		operations []usecaseDto.BulkOperation[TUCreate, TUUpdate]) ([]usecaseDto.BulkResult[TUOutput], error)) {
+
+	// bind http request
+	request := new(dto.BulkRequest)
+	err := c.ShouldBindJSON(request)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+	atomic := request.Mode != dto.BulkBestEffort
+
+	// validate and map every operation on its own
+	operations := make([]usecaseDto.BulkOperation[TUCreate, TUUpdate], 0, len(request.Operations))
+	for _, item := range request.Operations {
+		operations = append(operations, bulkOperation(item, createMapper, updateMapper))
+	}
+
+	// call use case method
+	results, err := usecaseBulk(c.Request.Context(), atomic, operations)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
+		return
+	}
+
+	// map usecase response to http response
+	response := dto.BulkResponse[TResponse]{Results: make([]dto.BulkResultResponse[TResponse], 0, len(results))}
+	status := http.StatusOK
+	for i, result := range results {
+		item := dto.BulkResultResponse[TResponse]{Index: i, Op: result.Op, Id: result.Id, Status: http.StatusOK}
+		if result.Op == usecaseDto.BulkCreate {
+			item.Status = http.StatusCreated
+		}
+		if result.Err != nil {
+			response.Failed++
+			item.Status = helper.TranslateErrorToStatusCode(result.Err)
+			item.Error = result.Err.Error()
+			item.ValidationErrors = bulkValidationErrors(result.Err)
+			if status == http.StatusOK && result.Err.Error() != service_errors.BulkRolledBack {
+				status = item.Status
+			}
+		} else {
+			response.Succeeded++
+			if result.Result != nil {
+				mapped := responseMapper(*result.Result)
+				item.Result = &mapped
+			}
+		}
+		response.Results = append(response.Results, item)
+	}
+
+	if response.Failed == 0 {
+		c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
+		return
+	}
+	if !atomic {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, helper.GenerateBaseResponse(response, false, helper.ValidationError))
+}
+
+func bulkOperation[TCreateRequest any, TUpdateRequest any, TUCreate any, TUUpdate any](item dto.BulkOperationRequest,
+	createMapper func(req TCreateRequest) (res TUCreate),
+	updateMapper func(req TUpdateRequest) (res TUUpdate)) usecaseDto.BulkOperation[TUCreate, TUUpdate] {
+
+	operation := usecaseDto.BulkOperation[TUCreate, TUUpdate]{Op: item.Op, Id: item.Id, Version: item.Version}
+	if err := binding.Validator.ValidateStruct(&item); err != nil {
+		operation.Err = invalidBulkOperation(err)
+		return operation
+	}
+	switch item.Op {
+	case usecaseDto.BulkCreate:
+		request := new(TCreateRequest)
+		if err := bindBulkData(item.Data, request); err != nil {
+			operation.Err = invalidBulkOperation(err)
+			return operation
+		}
+		operation.Create = createMapper(*request)
+	case usecaseDto.BulkUpdate:
+		request := new(TUpdateRequest)
+		if err := bindBulkData(item.Data, request); err != nil {
+			operation.Err = invalidBulkOperation(err)
+			return operation
+		}
+		operation.Update = updateMapper(*request)
+	}
+	return operation
+}
+
+func bindBulkData(data json.RawMessage, request any) error {
+	if err := json.Unmarshal(data, request); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(request)
+}
+
+func invalidBulkOperation(err error) error {
+	return &service_errors.ServiceError{EndUserMessage: service_errors.InvalidBulkOperation, TechnicalMessage: err.Error(), Err: err}
+}
+
+// bulkValidationErrors lists why an operation was rejected, nil when it was valid
+func bulkValidationErrors(err error) *[]validation.ValidationError {
+	var serviceError *service_errors.ServiceError
+	if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidBulkOperation {
+		return helper.FieldErrors(err)
+	}
+	if validationErrors := validation.GetValidationErrors(serviceError.Err); validationErrors != nil {
+		return validationErrors
+	}
+	return &[]validation.ValidationError{{Property: "data", Tag: "json", Message: serviceError.TechnicalMessage}}
+}
@@ -6,15 +6,19 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"golang-clean-web-api/api/helper"
 	"golang-clean-web-api/config"
+	"golang-clean-web-api/constant"
 	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/pkg/concurrency"
 	"golang-clean-web-api/pkg/logging"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 var logger = logging.NewLogger(config.GetConfig())
@@ -45,10 +49,10 @@ func Create[TRequest any, TUInput any, TUOutput any, TResponse any](c *gin.Conte
 	usecaseInput := requestMapper(*request)
 
 	// call use case method
-	usecaseResult, err := usecaseCreate(c, usecaseInput)
+	usecaseResult, err := usecaseCreate(c.Request.Context(), usecaseInput)
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
 		return
 	}
 
@@ -85,21 +89,90 @@ func Update[TRequest any, TUInput any, TUOutput any, TResponse any](c *gin.Conte
 	// map http request body to usecase input
 	usecaseInput := requestMapper(*request)
 
+	ctx, ok := withIfMatch(c)
+	if !ok {
+		return
+	}
+
 	// call use case method
-	usecaseResult, err := usecaseUpdate(c, id, usecaseInput)
+	usecaseResult, err := usecaseUpdate(ctx, id, usecaseInput)
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
 		return
 	}
 
 	// map usecase response to http response
 	response := responseMapper(usecaseResult)
+	setETag(c, usecaseResult)
 
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
 }
 
+// Upsert creates or overwrites the entity identified by the key in the path.
+// The body is a create request, withKey puts the key into it before it is validated.
+func Upsert[TRequest any, TUInput any, TUOutput any, TResponse any](c *gin.Context,
+	withKey func(key string, req TRequest) (res TRequest),
+	requestMapper func(req TRequest) (res TUInput),
+	responseMapper func(req TUOutput) (res TResponse),
+	usecaseUpsert func(ctx context.Context, req TUInput) (TUOutput, bool, error)) {
+
+	// bind http request
+	request := new(TRequest)
+	err := json.NewDecoder(c.Request.Body).Decode(request)
+	if err == nil {
+		*request = withKey(c.Params.ByName("key"), *request)
+		err = binding.Validator.ValidateStruct(request)
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	// map http request body to usecase input
+	usecaseInput := requestMapper(*request)
+
+	// call use case method
+	usecaseResult, created, err := usecaseUpsert(c.Request.Context(), usecaseInput)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
+		return
+	}
+
+	// map usecase response to http response
+	response := responseMapper(usecaseResult)
+	setETag(c, usecaseResult)
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, helper.GenerateBaseResponse(response, true, 0))
+}
+
 func Delete(c *gin.Context, usecaseDelete func(ctx context.Context, id int) error) {
+	ctx, ok := withIfMatch(c)
+	if !ok {
+		return
+	}
+	c.Request = c.Request.WithContext(ctx)
+	execute(c, usecaseDelete)
+}
+
+// Restore a soft deleted entity
+func Restore(c *gin.Context, usecaseRestore func(ctx context.Context, id int) error) {
+	execute(c, usecaseRestore)
+}
+
+// Purge a soft deleted entity for good
+func Purge(c *gin.Context, usecasePurge func(ctx context.Context, id int) error) {
+	execute(c, usecasePurge)
+}
+
+// execute runs a usecase action on the entity of the id path parameter
+func execute(c *gin.Context, usecaseAction func(ctx context.Context, id int) error) {
 	id, _ := strconv.Atoi(c.Params.ByName("id"))
 	if id == 0 {
 		c.AbortWithStatusJSON(http.StatusNotFound,
@@ -107,10 +180,10 @@ func Delete(c *gin.Context, usecaseDelete func(ctx context.Context, id int) erro
 		return
 	}
 
-	err := usecaseDelete(c, id)
+	err := usecaseAction(c.Request.Context(), id)
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
 		return
 	}
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(nil, true, 0))
@@ -130,17 +203,31 @@ func GetById[TUOutput any, TResponse any](c *gin.Context,
 			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
 		return
 	}
+	projection, err := filter.ParseProjection(c.Request.URL.Query())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithQueryError(nil, false, helper.ValidationError, err))
+		return
+	}
+	asOf, err := filter.ParseAsOf(c.Request.URL.Query())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithQueryError(nil, false, helper.ValidationError, err))
+		return
+	}
+	ctx := filter.NewAsOfContext(filter.NewProjectionContext(c.Request.Context(), projection), asOf)
 
 	// call use case method
-	usecaseResult, err := usecaseGet(c, id)
+	usecaseResult, err := usecaseGet(ctx, id)
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
 		return
 	}
 
 	// map usecase response to http response
 	response := responseMapper(usecaseResult)
+	setETag(c, usecaseResult)
 
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
 }
@@ -162,11 +249,91 @@ func GetByFilter[TUOutput any, TResponse any](c *gin.Context,
 		return
 	}
 
+	listResponse(c, *req, responseMapper, usecaseList)
+}
+
+// Get entities by query string, e.g. ?filter=name:startsWith:Ir&sort=-name,id&page=2&pageSize=20
+// TUOutput: Usecase function output
+// TResponse: Http response body that mapped from TUOutput with TResponse := mapper(TUOutput)
+// responseMapper: this function map usecase output to endpoint output
+// usecaseList: usecase GetByFilter method
+func GetByQuery[TUOutput any, TResponse any](c *gin.Context,
+	responseMapper func(req TUOutput) (res TResponse),
+	usecaseList func(c context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[TUOutput], error)) {
+
+	req, err := filter.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithQueryError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	listResponse(c, *req, responseMapper, usecaseList)
+}
+
+// GetHistory lists the records kept about the entity in the path, like its audit
+// records, with the query string filter
+func GetHistory[TUOutput any, TResponse any](c *gin.Context,
+	responseMapper func(req TUOutput) (res TResponse),
+	usecaseHistory func(c context.Context, id int, req filter.PaginationInputWithFilter) (*filter.PagedList[TUOutput], error)) {
+	id, _ := strconv.Atoi(c.Params.ByName("id"))
+	if id == 0 {
+		c.AbortWithStatusJSON(http.StatusNotFound,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+	req, err := filter.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithQueryError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	listResponse(c, *req, responseMapper, func(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[TUOutput], error) {
+		return usecaseHistory(ctx, id, req)
+	})
+}
+
+// Aggregate entities grouped by some of their fields, with the dynamic filter of GetByFilter
+// responseMapper: this function map usecase output to endpoint output
+// usecaseAggregate: usecase Aggregate method
+func Aggregate[TUOutput any, TResponse any](c *gin.Context,
+	responseMapper func(req TUOutput) (res TResponse),
+	usecaseAggregate func(c context.Context, input filter.AggregateInput) ([]TUOutput, error)) {
+
+	input := new(filter.AggregateInput)
+	err := c.ShouldBindJSON(&input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithValidationError(nil, false, helper.ValidationError, err))
+		return
+	}
+
 	// call use case method
-	usecaseResult, err := usecaseList(c, *req)
+	rows, err := usecaseAggregate(c.Request.Context(), *input)
 	if err != nil {
 		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
-			helper.GenerateBaseResponseWithError(nil, false, helper.InternalError, err))
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
+		return
+	}
+
+	// map usecase response to http response
+	response := make([]TResponse, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, responseMapper(row))
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
+}
+
+func listResponse[TUOutput any, TResponse any](c *gin.Context, req filter.PaginationInputWithFilter,
+	responseMapper func(req TUOutput) (res TResponse),
+	usecaseList func(c context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[TUOutput], error)) {
+
+	// call use case method
+	usecaseResult, err := usecaseList(c.Request.Context(), req)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
 		return
 	}
 	response := filter.PagedList[TResponse]{
@@ -176,6 +343,8 @@ func GetByFilter[TUOutput any, TResponse any](c *gin.Context,
 		TotalPages:      usecaseResult.TotalPages,
 		HasPreviousPage: usecaseResult.HasPreviousPage,
 		HasNextPage:     usecaseResult.HasNextPage,
+		NextCursor:      usecaseResult.NextCursor,
+		PreviousCursor:  usecaseResult.PreviousCursor,
 	}
 
 	// map usecase response to http response
@@ -188,3 +357,34 @@ func GetByFilter[TUOutput any, TResponse any](c *gin.Context,
 
 	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
 }
+
+// versioned is implemented by usecase outputs that carry a row version
+type versioned interface {
+	GetVersion() int
+}
+
+func setETag(c *gin.Context, result any) {
+	if v, ok := result.(versioned); ok && v.GetVersion() > 0 {
+		c.Header(constant.ETagHeaderKey, helper.ETag(v.GetVersion()))
+	}
+}
+
+// withIfMatch returns the request context carrying the version of the If-Match
+// header. A malformed header can never match and is answered with 412.
+func withIfMatch(c *gin.Context) (context.Context, bool) {
+	ctx := c.Request.Context()
+	header := c.GetHeader(constant.IfMatchHeaderKey)
+	if header == "" {
+		return ctx, true
+	}
+	version, anyVersion, ok := helper.ParseETag(header)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed,
+			helper.GenerateBaseResponse(nil, false, helper.PreconditionError))
+		return nil, false
+	}
+	if anyVersion {
+		return ctx, true
+	}
+	return concurrency.NewContext(ctx, version), true
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang-clean-web-api/api/dto"
+	"golang-clean-web-api/api/helper"
+	"golang-clean-web-api/constant"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/pkg/concurrency"
+	usecaseDto "golang-clean-web-api/usecase/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetByQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received filter.PaginationInputWithFilter
+	usecaseList := func(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[string], error) {
+		received = req
+		items := []string{"Iran"}
+		return filter.NewPagedList(&items, 1, req.GetPageNumber(), int64(req.GetPageSize())), nil
+	}
+
+	router := gin.New()
+	router.GET("/countries", func(c *gin.Context) {
+		GetByQuery(c, func(item string) string { return item }, usecaseList)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/countries?filter=name:startsWith:Ir&filter=id:in:1|2&sort=-name&page=2&pageSize=5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if received.PageNumber != 2 || received.PageSize != 5 {
+		t.Errorf("Expected page 2 of size 5, got %d/%d", received.PageNumber, received.PageSize)
+	}
+	if received.Where == nil || len(received.Where.And) != 2 {
+		t.Fatalf("Expected two AND-joined conditions, got %+v", received.Where)
+	}
+	if received.Sort == nil || (*received.Sort)[0].ColId != "name" || (*received.Sort)[0].Sort != "desc" {
+		t.Errorf("Expected sort by name desc, got %+v", received.Sort)
+	}
+}
+
+func TestGetByQuery_InvalidParameter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	called := false
+	usecaseList := func(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[string], error) {
+		called = true
+		return &filter.PagedList[string]{}, nil
+	}
+
+	router := gin.New()
+	router.GET("/countries", func(c *gin.Context) {
+		GetByQuery(c, func(item string) string { return item }, usecaseList)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/countries?filter=name", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	if called {
+		t.Error("Expected usecase not to be called")
+	}
+
+	var response helper.BaseHttpResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.ValidationErrors == nil || (*response.ValidationErrors)[0].Property != "filter" {
+		t.Errorf("Expected a validation error on filter, got %s", w.Body.String())
+	}
+}
+
+type versionedOutput struct {
+	Name    string
+	Version int
+}
+
+func (o versionedOutput) GetVersion() int {
+	return o.Version
+}
+
+func TestUpdate_IfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var expected int
+	var expectedSet bool
+	usecaseUpdate := func(ctx context.Context, id int, req versionedOutput) (versionedOutput, error) {
+		expected, expectedSet = concurrency.FromContext(ctx)
+		return versionedOutput{Name: req.Name, Version: 4}, nil
+	}
+	router := gin.New()
+	router.PUT("/colors/:id", func(c *gin.Context) {
+		Update(c, func(req versionedOutput) versionedOutput { return req },
+			func(res versionedOutput) versionedOutput { return res }, usecaseUpdate)
+	})
+
+	cases := []struct {
+		ifMatch     string
+		status      int
+		expected    int
+		expectedSet bool
+	}{
+		{"", http.StatusOK, 0, false},
+		{`"3"`, http.StatusOK, 3, true},
+		{`W/"3"`, http.StatusOK, 3, true},
+		{"*", http.StatusOK, 0, false},
+		{"three", http.StatusPreconditionFailed, 0, false},
+	}
+	for _, c := range cases {
+		expected, expectedSet = 0, false
+		req := httptest.NewRequest(http.MethodPut, "/colors/1", strings.NewReader(`{"Name": "Ink"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if c.ifMatch != "" {
+			req.Header.Set(constant.IfMatchHeaderKey, c.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != c.status {
+			t.Errorf("If-Match %q: expected status %d, got %d", c.ifMatch, c.status, w.Code)
+			continue
+		}
+		if expected != c.expected || expectedSet != c.expectedSet {
+			t.Errorf("If-Match %q: expected version %d (%v), got %d (%v)", c.ifMatch, c.expected, c.expectedSet, expected, expectedSet)
+		}
+		if c.status == http.StatusOK && w.Header().Get(constant.ETagHeaderKey) != `"4"` {
+			t.Errorf("If-Match %q: expected ETag \"4\", got %q", c.ifMatch, w.Header().Get(constant.ETagHeaderKey))
+		}
+	}
+}
+
+type bulkItem struct {
+	Name string `json:"name" binding:"required,alpha"`
+}
+
+func TestBulk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received []usecaseDto.BulkOperation[bulkItem, bulkItem]
+	var receivedAtomic bool
+	usecaseBulk := func(ctx context.Context, atomic bool, operations []usecaseDto.BulkOperation[bulkItem, bulkItem]) ([]usecaseDto.BulkResult[bulkItem], error) {
+		received, receivedAtomic = operations, atomic
+		results := []usecaseDto.BulkResult[bulkItem]{}
+		for _, operation := range operations {
+			result := usecaseDto.BulkResult[bulkItem]{Op: operation.Op, Id: operation.Id, Err: operation.Err}
+			if result.Err == nil {
+				result.Result = &operation.Create
+			}
+			results = append(results, result)
+		}
+		return results, nil
+	}
+	same := func(item bulkItem) bulkItem { return item }
+	router := gin.New()
+	router.POST("/colors/bulk", func(c *gin.Context) {
+		Bulk(c, same, same, same, usecaseBulk)
+	})
+
+	body := `{"mode": "bestEffort", "operations": [
+		{"op": "create", "data": {"name": "Ink"}},
+		{"op": "create", "data": {"name": "Ink 2"}},
+		{"op": "update", "data": {"name": "Ink"}},
+		{"op": "delete", "id": 4, "version": 2}]}`
+	req := httptest.NewRequest(http.MethodPost, "/colors/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected status 207, got %d: %s", w.Code, w.Body.String())
+	}
+	if receivedAtomic || len(received) != 4 {
+		t.Fatalf("Expected 4 best effort operations, got %d (atomic %v)", len(received), receivedAtomic)
+	}
+	if received[0].Err != nil || received[0].Create.Name != "Ink" {
+		t.Errorf("Expected the first create to be mapped, got %+v", received[0])
+	}
+	if received[1].Err == nil || received[2].Err == nil {
+		t.Errorf("Expected the invalid name and the update without id to be rejected, got %+v", received[1:3])
+	}
+	if received[3].Err != nil || received[3].Id != 4 || received[3].Version != 2 {
+		t.Errorf("Expected the delete to carry id and version, got %+v", received[3])
+	}
+
+	var response struct {
+		Result dto.BulkResponse[bulkItem] `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Result.Succeeded != 2 || response.Result.Failed != 2 {
+		t.Errorf("Expected 2 succeeded and 2 failed, got %+v", response.Result)
+	}
+	statuses := []int{}
+	for _, result := range response.Result.Results {
+		statuses = append(statuses, result.Status)
+	}
+	if fmt.Sprint(statuses) != "[201 400 400 200]" {
+		t.Errorf("Expected statuses [201 400 400 200], got %v", statuses)
+	}
+	if validationErrors := response.Result.Results[1].ValidationErrors; validationErrors == nil || (*validationErrors)[0].Property != "Name" {
+		t.Errorf("Expected a validation error on Name, got %+v", response.Result.Results[1])
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	existing := map[string]bool{"Ink": true}
+	usecaseUpsert := func(ctx context.Context, req bulkItem) (bulkItem, bool, error) {
+		created := !existing[req.Name]
+		existing[req.Name] = true
+		return req, created, nil
+	}
+	router := gin.New()
+	router.PUT("/colors/by-key/:key", func(c *gin.Context) {
+		Upsert(c, func(key string, req bulkItem) bulkItem { req.Name = key; return req },
+			func(req bulkItem) bulkItem { return req }, func(res bulkItem) bulkItem { return res }, usecaseUpsert)
+	})
+
+	cases := []struct {
+		key    string
+		body   string
+		status int
+	}{
+		{"Ink", `{"name": "Other"}`, http.StatusOK},
+		{"Coal", `{}`, http.StatusCreated},
+		{"Coal", `{}`, http.StatusOK},
+		{"Coal2", `{}`, http.StatusBadRequest},
+		{"Coal", `{`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPut, "/colors/by-key/"+c.key, strings.NewReader(c.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != c.status {
+			t.Errorf("PUT %s %s: expected status %d, got %d: %s", c.key, c.body, c.status, w.Code, w.Body.String())
+		}
+	}
+	if existing["Other"] {
+		t.Error("Expected the key in the path to win over the body")
+	}
+}
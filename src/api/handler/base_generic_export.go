@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"golang-clean-web-api/api/dto"
+	"golang-clean-web-api/api/helper"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/pkg/export"
+	"golang-clean-web-api/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Export streams the entities of the query string filter and sort as a csv, ndjson
+// or xlsx download named after the entity
+// TResponse: Http response body that mapped from TUOutput, a row of the export
+// columns: comma separated json paths of TResponse, e.g. id,country.name, all by default
+// lang or Accept-Language: language of the csv and xlsx headers
+// Rows go out a batch at a time as usecaseExport reads them. An error after the
+// first batch can no longer change the status, the download is cut short.
+func Export[TUOutput any, TResponse any](c *gin.Context, name string,
+	responseMapper func(req TUOutput) (res TResponse),
+	usecaseExport func(ctx context.Context, req filter.PaginationInputWithFilter, write func(items []TUOutput) error) error) {
+
+	format := c.DefaultQuery("format", export.CSV)
+	if !export.Supported(format) {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithQueryError(nil, false, helper.ValidationError,
+				&filter.QueryError{Parameter: "format", Value: format, Message: "expected csv, ndjson or xlsx"}))
+		return
+	}
+	req, err := filter.ParseQuery(c.Request.URL.Query())
+	if err == nil {
+		var columns []string
+		columns, err = dto.SelectExportColumns[TResponse](c.Query("columns"))
+		if err == nil {
+			headers := dto.ExportHeaders(columns, dto.ExportLanguage(c.Query("lang"), c.GetHeader("Accept-Language")))
+			streamExport(c, name, format, columns, headers, *req, responseMapper, usecaseExport)
+			return
+		}
+	}
+	c.AbortWithStatusJSON(http.StatusBadRequest,
+		helper.GenerateBaseResponseWithQueryError(nil, false, helper.ValidationError, err))
+}
+
+func streamExport[TUOutput any, TResponse any](c *gin.Context, name string, format string, columns []string, headers []string,
+	req filter.PaginationInputWithFilter,
+	responseMapper func(req TUOutput) (res TResponse),
+	usecaseExport func(ctx context.Context, req filter.PaginationInputWithFilter, write func(items []TUOutput) error) error) {
+
+	// the response starts with the first batch, errors before it are answered as usual
+	var writer export.Writer
+	err := usecaseExport(c.Request.Context(), req, func(items []TUOutput) error {
+		if writer == nil {
+			c.Header("Content-Type", export.ContentType(format))
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+			c.Status(http.StatusOK)
+			var err error
+			if writer, err = export.NewWriter(format, c.Writer, columns, headers); err != nil {
+				return err
+			}
+		}
+		for _, item := range items {
+			row, err := dto.ToExportRow(responseMapper(item), columns)
+			if err != nil {
+				return err
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil && writer != nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		return
+	}
+	if !c.Writer.Written() {
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
+		return
+	}
+	logger.Error(logging.RequestResponse, logging.Api, "export cut short: "+err.Error(), nil)
+	c.Abort()
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang-clean-web-api/api/dto"
+	"golang-clean-web-api/api/helper"
+	"golang-clean-web-api/api/validation"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/pkg/service_errors"
+	usecaseDto "golang-clean-web-api/usecase/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// importReference is a column that names a related entity by its natural key,
+// like the country of a city, and the field of the request that takes its id
+type importReference struct {
+	Column string
+	Field  string
+	// Ids returns the ids of the keys that exist
+	Ids func(ctx context.Context, keys []string) (map[string]int, error)
+}
+
+// Import creates or updates entities from a csv or json upload
+// TRequest: the create request a row is bound to, validated like the body of Create
+// TUInput: Usecase input mapped from TRequest with requestMapper
+// references: columns resolved to the id of a related entity before validation
+// The upload is the file field of a multipart form or the request body, read as csv
+// or json by the format parameter, the content type or the file name. dryRun=true
+// reports what would happen and keeps nothing. The response has an outcome per row,
+// it is 200 when no row failed and 207 otherwise.
+func Import[TRequest any, TUInput any, TUOutput any, TResponse any](c *gin.Context, references []importReference,
+	requestMapper func(req TRequest) (res TUInput),
+	responseMapper func(req TUOutput) (res TResponse),
+	usecaseImport func(ctx context.Context, dryRun bool, rows []usecaseDto.ImportRow[TUInput]) ([]usecaseDto.ImportResult[TUOutput], error)) {
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithQueryError(nil, false, helper.ValidationError,
+				&filter.QueryError{Parameter: "dryRun", Value: c.Query("dryRun"), Message: "expected true or false"}))
+		return
+	}
+	records, err := readImport(c)
+	if err != nil {
+		err = &service_errors.ServiceError{EndUserMessage: service_errors.InvalidImportFile, TechnicalMessage: err.Error(), Err: err}
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithError(nil, false, helper.ValidationError, err))
+		return
+	}
+
+	// resolve references, then validate and map every row on its own
+	ctx := c.Request.Context()
+	failures, err := resolveReferences(ctx, references, records)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
+		return
+	}
+	rows := make([]usecaseDto.ImportRow[TUInput], 0, len(records))
+	for i, record := range records {
+		row := usecaseDto.ImportRow[TUInput]{Row: record.Row, Err: failures[i]}
+		if row.Err == nil {
+			row.Create, row.Err = importRequest(record, requestMapper)
+		}
+		rows = append(rows, row)
+	}
+
+	// call use case method
+	results, err := usecaseImport(ctx, dryRun, rows)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
+		return
+	}
+
+	// map usecase response to http response
+	response := dto.ImportResponse[TResponse]{DryRun: dryRun, Rows: make([]dto.ImportRowResponse[TResponse], 0, len(results))}
+	for _, result := range results {
+		item := dto.ImportRowResponse[TResponse]{Row: result.Row, Outcome: result.Outcome}
+		switch result.Outcome {
+		case usecaseDto.ImportCreated:
+			response.Created++
+		case usecaseDto.ImportUpdated:
+			response.Updated++
+		default:
+			response.Failed++
+			item.Status = helper.TranslateErrorToStatusCode(result.Err)
+			item.Error = result.Err.Error()
+			item.ValidationErrors = importValidationErrors(result.Err)
+		}
+		if result.Result != nil {
+			mapped := responseMapper(*result.Result)
+			item.Result = &mapped
+		}
+		response.Rows = append(response.Rows, item)
+	}
+
+	if response.Failed == 0 {
+		c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
+		return
+	}
+	c.JSON(http.StatusMultiStatus, helper.GenerateBaseResponse(response, false, helper.ValidationError))
+}
+
+// readImport parses the uploaded file, or the body when the request is no form
+func readImport(c *gin.Context) ([]dto.ImportRecord, error) {
+	var body io.Reader = c.Request.Body
+	contentType, name := c.ContentType(), ""
+	if strings.HasPrefix(contentType, "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		body, contentType, name = file, header.Header.Get("Content-Type"), strings.ToLower(header.Filename)
+	}
+
+	format := c.Query("format")
+	switch {
+	case format != "":
+	case strings.Contains(contentType, "csv") || strings.HasSuffix(name, ".csv"):
+		format = dto.ImportCSV
+	case strings.Contains(contentType, "json") || strings.HasSuffix(name, ".json"):
+		format = dto.ImportJSON
+	}
+	return dto.ParseImport(format, body)
+}
+
+// resolveReferences puts the id of the key in the column of every reference into
+// its field, with a lookup per reference. Records whose key does not exist fail.
+func resolveReferences(ctx context.Context, references []importReference, records []dto.ImportRecord) ([]error, error) {
+	failures := make([]error, len(records))
+	for _, reference := range references {
+		keys := []string{}
+		for _, record := range records {
+			if key, ok := record.Values[reference.Column]; ok {
+				keys = append(keys, fmt.Sprint(key))
+			}
+		}
+		ids, err := reference.Ids(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		for i, record := range records {
+			key, ok := record.Values[reference.Column]
+			if !ok {
+				continue
+			}
+			delete(record.Values, reference.Column)
+			id, ok := ids[fmt.Sprint(key)]
+			if !ok && failures[i] == nil {
+				failures[i] = &service_errors.ServiceError{EndUserMessage: service_errors.InvalidReference, Field: reference.Column,
+					TechnicalMessage: fmt.Sprintf("%s %q does not exist", reference.Column, fmt.Sprint(key))}
+			}
+			record.Values[reference.Field] = id
+		}
+	}
+	return failures, nil
+}
+
+func importRequest[TRequest any, TUInput any](record dto.ImportRecord, requestMapper func(req TRequest) (res TUInput)) (TUInput, error) {
+	var input TUInput
+	request, err := dto.ToImportRequest[TRequest](record.Values)
+	if err == nil {
+		err = binding.Validator.ValidateStruct(&request)
+	}
+	if err != nil {
+		return input, &service_errors.ServiceError{EndUserMessage: service_errors.InvalidImportRow, TechnicalMessage: err.Error(), Err: err}
+	}
+	return requestMapper(request), nil
+}
+
+// importValidationErrors lists why a row failed
+func importValidationErrors(err error) *[]validation.ValidationError {
+	var serviceError *service_errors.ServiceError
+	if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidImportRow {
+		return helper.FieldErrors(err)
+	}
+	var columnError *dto.ImportColumnError
+	if errors.As(serviceError.Err, &columnError) {
+		return &[]validation.ValidationError{{Property: columnError.Column, Tag: "column", Value: columnError.Value, Message: columnError.Message}}
+	}
+	if validationErrors := validation.GetValidationErrors(serviceError.Err); validationErrors != nil {
+		return validationErrors
+	}
+	return &[]validation.ValidationError{{Property: "row", Tag: "json", Message: serviceError.TechnicalMessage}}
+}
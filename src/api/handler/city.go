@@ -13,11 +13,16 @@ import (
 
 type CityHandler struct {
 	usecase *usecase.CityUsecase
+	// references of the import, the country of a city is its name
+	references []importReference
 }
 
 func NewCityHandler(cfg *config.Config) *CityHandler {
+	countries := usecase.NewCountryUsecase(cfg, dependency.GetCountryRepository(cfg),
+		dependency.GetCityRepository(cfg), dependency.GetTransactionManager())
 	return &CityHandler{
-		usecase: usecase.NewCityUsecase(cfg, dependency.GetCityRepository(cfg)),
+		usecase:    usecase.NewCityUsecase(cfg, dependency.GetCityRepository(cfg), dependency.GetTransactionManager()),
+		references: []importReference{{Column: "country", Field: "countryId", Ids: countries.IdsByName}},
 	}
 }
 
@@ -43,10 +48,13 @@ func (h *CityHandler) Create(c *gin.Context) {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being changed"
 // @Param Request body dto.UpdateCityRequest true "Update a City"
 // @Success 200 {object} helper.BaseHttpResponse{result=dto.CityResponse} "City response"
+// @Header 200 {string} ETag "Row version"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Failure 412 {object} helper.BaseHttpResponse "Modified by someone else"
 // @Router /v1/cities/{id} [put]
 // @Security AuthBearer
 func (h *CityHandler) Update(c *gin.Context) {
@@ -60,9 +68,11 @@ func (h *CityHandler) Update(c *gin.Context) {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being changed"
 // @Success 200 {object} helper.BaseHttpResponse "response"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Failure 412 {object} helper.BaseHttpResponse "Modified by someone else"
 // @Router /v1/cities/{id} [delete]
 // @Security AuthBearer
 func (h *CityHandler) Delete(c *gin.Context) {
@@ -76,7 +86,11 @@ func (h *CityHandler) Delete(c *gin.Context) {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name"
+// @Param include query string false "Comma separated relations to load, e.g. country"
+// @Param asOf query string false "RFC 3339 time to read the city as of, e.g. 2024-03-01T12:00:00Z"
 // @Success 200 {object} helper.BaseHttpResponse{result=dto.CityResponse} "City response"
+// @Header 200 {string} ETag "Row version"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Failure 404 {object} helper.BaseHttpResponse "Not found"
 // @Router /v1/cities/{id} [get]
@@ -99,3 +113,170 @@ func (h *CityHandler) GetById(c *gin.Context) {
 func (h *CityHandler) GetByFilter(c *gin.Context) {
 	GetByFilter(c, dto.ToCityResponse, h.usecase.GetByFilter)
 }
+
+// ListCities godoc
+// @Summary List Cities
+// @Description List Cities filtered, sorted and paged by query string
+// @Tags Cities
+// @Accept json
+// @produces json
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. name:startsWith:Ir" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -name,id"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Param cursorMode query bool false "Page by sort keys instead of page number"
+// @Param cursor query string false "nextCursor or previousCursor of a previous page"
+// @Param withTotal query bool false "Count the total in cursor mode"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name"
+// @Param include query string false "Comma separated relations to load, e.g. country"
+// @Param asOf query string false "RFC 3339 time to read the cities as of, e.g. 2024-03-01T12:00:00Z"
+// @Success 200 {object} helper.BaseHttpResponse "City response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/cities [get]
+// @Security AuthBearer
+func (h *CityHandler) GetByQuery(c *gin.Context) {
+	GetByQuery(c, dto.ToCityResponse, h.usecase.GetByFilter)
+}
+
+// GetDeletedCities godoc
+// @Summary List deleted Cities
+// @Description List soft deleted Cities with the query string filter, admin only
+// @Tags Cities
+// @Accept json
+// @produces json
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. name:startsWith:Ir" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -deletedAt"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} helper.BaseHttpResponse "City response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/cities/trash [get]
+// @Security AuthBearer
+func (h *CityHandler) GetDeleted(c *gin.Context) {
+	GetByQuery(c, dto.ToCityResponse, h.usecase.GetDeleted)
+}
+
+// RestoreCity godoc
+// @Summary Restore a City
+// @Description Restore a soft deleted City, admin only
+// @Tags Cities
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse "response"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/cities/trash/{id}/restore [post]
+// @Security AuthBearer
+func (h *CityHandler) Restore(c *gin.Context) {
+	Restore(c, h.usecase.Restore)
+}
+
+// PurgeCity godoc
+// @Summary Purge a City
+// @Description Permanently delete a soft deleted City, admin only
+// @Tags Cities
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse "response"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/cities/trash/{id} [delete]
+// @Security AuthBearer
+func (h *CityHandler) Purge(c *gin.Context) {
+	Purge(c, h.usecase.Purge)
+}
+
+// GetCityHistory godoc
+// @Summary History of a City
+// @Description List the audit records of a City, oldest first, admin only
+// @Tags Cities
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. action:equals:update" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -createdAt"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} helper.BaseHttpResponse{result=filter.PagedList[dto.AuditLogResponse]} "Audit response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/cities/{id}/history [get]
+// @Security AuthBearer
+func (h *CityHandler) GetHistory(c *gin.Context) {
+	GetHistory(c, dto.ToAuditLogResponse, h.usecase.GetHistory)
+}
+
+// BulkCities godoc
+// @Summary Create, update and delete Cities in bulk
+// @Description Apply a list of operations in atomic (default) or bestEffort mode, with a result per operation
+// @Tags Cities
+// @Accept json
+// @produces json
+// @Param Request body dto.BulkRequest true "Operations, data is a dto.CreateCityRequest or dto.UpdateCityRequest"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.BulkResponse[dto.CityResponse]} "All operations applied"
+// @Success 207 {object} helper.BaseHttpResponse{result=dto.BulkResponse[dto.CityResponse]} "Some operations failed in bestEffort mode"
+// @Failure 400 {object} helper.BaseHttpResponse{result=dto.BulkResponse[dto.CityResponse]} "Bad request"
+// @Failure 413 {object} helper.BaseHttpResponse "Too many operations"
+// @Router /v1/cities/bulk [post]
+// @Security AuthBearer
+func (h *CityHandler) Bulk(c *gin.Context) {
+	Bulk(c, dto.ToCreateCity, dto.ToUpdateCity, dto.ToCityResponse, h.usecase.Bulk)
+}
+
+// AggregateCities godoc
+// @Summary Aggregate Cities
+// @Description Count Cities and compute min, max, sum and avg of numeric fields per group, with the dynamic filter of get-by-filter
+// @Tags Cities
+// @Accept json
+// @produces json
+// @Param Request body filter.AggregateInput true "Filter, groupBy fields such as Country.Name, aggregates and sort on either"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.AggregateRowResponse} "Groups"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/cities/aggregate [post]
+// @Security AuthBearer
+func (h *CityHandler) Aggregate(c *gin.Context) {
+	Aggregate(c, dto.ToAggregateRowResponse, h.usecase.Aggregate)
+}
+
+// ExportCities godoc
+// @Summary Export Cities
+// @Description Download the Cities of the query string filter and sort, streamed in batches
+// @Tags Cities
+// @produces text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Param columns query string false "Comma separated json paths of the response, e.g. id,name,country.name"
+// @Param lang query string false "Language of the headers, en or fa, Accept-Language by default"
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. name:startsWith:Ir" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -name,id"
+// @Param search query string false "Words to match in the search fields"
+// @Param include query string false "Comma separated relations to load, e.g. country"
+// @Param asOf query string false "RFC 3339 time to read the cities as of, e.g. 2024-03-01T12:00:00Z"
+// @Success 200 {file} file "The export"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/cities/export [get]
+// @Security AuthBearer
+func (h *CityHandler) Export(c *gin.Context) {
+	Export(c, "cities", dto.ToCityResponse, h.usecase.Export)
+}
+
+// ImportCities godoc
+// @Summary Import Cities
+// @Description Create Cities from a csv with the header name,country or a json array of dto.CreateCityRequest. Rows are always created, country is the name of an existing country. Every row is validated like a create request and reported on its own.
+// @Tags Cities
+// @Accept text/csv,application/json,multipart/form-data
+// @produces json
+// @Param file formData file false "The upload, the request body is read when there is no form"
+// @Param format query string false "csv or json, by the content type or file name by default"
+// @Param dryRun query bool false "Report what the import would do and keep nothing"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ImportResponse[dto.CityResponse]} "All rows imported"
+// @Success 207 {object} helper.BaseHttpResponse{result=dto.ImportResponse[dto.CityResponse]} "Some rows failed"
+// @Failure 400 {object} helper.BaseHttpResponse "Malformed upload"
+// @Failure 413 {object} helper.BaseHttpResponse "Too many rows"
+// @Router /v1/cities/import [post]
+// @Security AuthBearer
+func (h *CityHandler) Import(c *gin.Context) {
+	Import(c, h.references, dto.ToCreateCity, dto.ToCityResponse, h.usecase.Import)
+}
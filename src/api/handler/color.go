@@ -17,7 +17,7 @@ type ColorHandler struct {
 
 func NewColorHandler(cfg *config.Config) *ColorHandler {
 	return &ColorHandler{
-		usecase: usecase.NewColorUsecase(cfg, dependency.GetColorRepository(cfg)),
+		usecase: usecase.NewColorUsecase(cfg, dependency.GetColorRepository(cfg), dependency.GetTransactionManager()),
 	}
 }
 
@@ -43,10 +43,13 @@ func (h *ColorHandler) Create(c *gin.Context) {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being changed"
 // @Param Request body dto.UpdateColorRequest true "Update a Color"
 // @Success 200 {object} helper.BaseHttpResponse{result=dto.ColorResponse} "Color response"
+// @Header 200 {string} ETag "Row version"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Failure 412 {object} helper.BaseHttpResponse "Modified by someone else"
 // @Router /v1/colors/{id} [put]
 // @Security AuthBearer
 func (h *ColorHandler) Update(c *gin.Context) {
@@ -60,9 +63,11 @@ func (h *ColorHandler) Update(c *gin.Context) {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being changed"
 // @Success 200 {object} helper.BaseHttpResponse "response"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Failure 412 {object} helper.BaseHttpResponse "Modified by someone else"
 // @Router /v1/colors/{id} [delete]
 // @Security AuthBearer
 func (h *ColorHandler) Delete(c *gin.Context) {
@@ -76,7 +81,10 @@ func (h *ColorHandler) Delete(c *gin.Context) {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name"
+// @Param asOf query string false "RFC 3339 time to read the color as of, e.g. 2024-03-01T12:00:00Z"
 // @Success 200 {object} helper.BaseHttpResponse{result=dto.ColorResponse} "Color response"
+// @Header 200 {string} ETag "Row version"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Failure 404 {object} helper.BaseHttpResponse "Not found"
 // @Router /v1/colors/{id} [get]
@@ -99,3 +107,186 @@ func (h *ColorHandler) GetById(c *gin.Context) {
 func (h *ColorHandler) GetByFilter(c *gin.Context) {
 	GetByFilter(c, dto.ToColorResponse, h.usecase.GetByFilter)
 }
+
+// ListColors godoc
+// @Summary List Colors
+// @Description List Colors filtered, sorted and paged by query string
+// @Tags Colors
+// @Accept json
+// @produces json
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. name:startsWith:Ir" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -name,id"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Param cursorMode query bool false "Page by sort keys instead of page number"
+// @Param cursor query string false "nextCursor or previousCursor of a previous page"
+// @Param withTotal query bool false "Count the total in cursor mode"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name"
+// @Param asOf query string false "RFC 3339 time to read the colors as of, e.g. 2024-03-01T12:00:00Z"
+// @Success 200 {object} helper.BaseHttpResponse "Color response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/colors [get]
+// @Security AuthBearer
+func (h *ColorHandler) GetByQuery(c *gin.Context) {
+	GetByQuery(c, dto.ToColorResponse, h.usecase.GetByFilter)
+}
+
+// GetDeletedColors godoc
+// @Summary List deleted Colors
+// @Description List soft deleted Colors with the query string filter, admin only
+// @Tags Colors
+// @Accept json
+// @produces json
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. name:startsWith:Ir" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -deletedAt"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} helper.BaseHttpResponse "Color response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/colors/trash [get]
+// @Security AuthBearer
+func (h *ColorHandler) GetDeleted(c *gin.Context) {
+	GetByQuery(c, dto.ToColorResponse, h.usecase.GetDeleted)
+}
+
+// RestoreColor godoc
+// @Summary Restore a Color
+// @Description Restore a soft deleted Color, admin only
+// @Tags Colors
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse "response"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/colors/trash/{id}/restore [post]
+// @Security AuthBearer
+func (h *ColorHandler) Restore(c *gin.Context) {
+	Restore(c, h.usecase.Restore)
+}
+
+// PurgeColor godoc
+// @Summary Purge a Color
+// @Description Permanently delete a soft deleted Color, admin only
+// @Tags Colors
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse "response"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/colors/trash/{id} [delete]
+// @Security AuthBearer
+func (h *ColorHandler) Purge(c *gin.Context) {
+	Purge(c, h.usecase.Purge)
+}
+
+// GetColorHistory godoc
+// @Summary History of a Color
+// @Description List the audit records of a Color, oldest first, admin only
+// @Tags Colors
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. action:equals:update" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -createdAt"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} helper.BaseHttpResponse{result=filter.PagedList[dto.AuditLogResponse]} "Audit response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/colors/{id}/history [get]
+// @Security AuthBearer
+func (h *ColorHandler) GetHistory(c *gin.Context) {
+	GetHistory(c, dto.ToAuditLogResponse, h.usecase.GetHistory)
+}
+
+// BulkColors godoc
+// @Summary Create, update and delete Colors in bulk
+// @Description Apply a list of operations in atomic (default) or bestEffort mode, with a result per operation
+// @Tags Colors
+// @Accept json
+// @produces json
+// @Param Request body dto.BulkRequest true "Operations, data is a dto.CreateColorRequest or dto.UpdateColorRequest"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.BulkResponse[dto.ColorResponse]} "All operations applied"
+// @Success 207 {object} helper.BaseHttpResponse{result=dto.BulkResponse[dto.ColorResponse]} "Some operations failed in bestEffort mode"
+// @Failure 400 {object} helper.BaseHttpResponse{result=dto.BulkResponse[dto.ColorResponse]} "Bad request"
+// @Failure 413 {object} helper.BaseHttpResponse "Too many operations"
+// @Router /v1/colors/bulk [post]
+// @Security AuthBearer
+func (h *ColorHandler) Bulk(c *gin.Context) {
+	Bulk(c, dto.ToCreateColor, dto.ToUpdateColor, dto.ToColorResponse, h.usecase.Bulk)
+}
+
+// UpsertColor godoc
+// @Summary Create or update a Color by name
+// @Description Create the Color with the name in the path, or overwrite the existing one
+// @Tags Colors
+// @Accept json
+// @produces json
+// @Param key path string true "Name"
+// @Param Request body dto.CreateColorRequest true "The Color, the name is taken from the path"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ColorResponse} "Updated"
+// @Success 201 {object} helper.BaseHttpResponse{result=dto.ColorResponse} "Created"
+// @Header 200 {string} ETag "Row version"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/colors/by-key/{key} [put]
+// @Security AuthBearer
+func (h *ColorHandler) Upsert(c *gin.Context) {
+	Upsert(c, dto.WithColorKey, dto.ToCreateColor, dto.ToColorResponse, h.usecase.Upsert)
+}
+
+// AggregateColors godoc
+// @Summary Aggregate Colors
+// @Description Count Colors and compute min, max, sum and avg of numeric fields per group, with the dynamic filter of get-by-filter
+// @Tags Colors
+// @Accept json
+// @produces json
+// @Param Request body filter.AggregateInput true "Filter, groupBy fields such as Name, aggregates and sort on either"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.AggregateRowResponse} "Groups"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/colors/aggregate [post]
+// @Security AuthBearer
+func (h *ColorHandler) Aggregate(c *gin.Context) {
+	Aggregate(c, dto.ToAggregateRowResponse, h.usecase.Aggregate)
+}
+
+// ExportColors godoc
+// @Summary Export Colors
+// @Description Download the Colors of the query string filter and sort, streamed in batches
+// @Tags Colors
+// @produces text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Param columns query string false "Comma separated json paths of the response, e.g. id,name,hexCode"
+// @Param lang query string false "Language of the headers, en or fa, Accept-Language by default"
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. name:startsWith:Ir" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -name,id"
+// @Param search query string false "Words to match in the search fields"
+// @Param asOf query string false "RFC 3339 time to read the colors as of, e.g. 2024-03-01T12:00:00Z"
+// @Success 200 {file} file "The export"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/colors/export [get]
+// @Security AuthBearer
+func (h *ColorHandler) Export(c *gin.Context) {
+	Export(c, "colors", dto.ToColorResponse, h.usecase.Export)
+}
+
+// ImportColors godoc
+// @Summary Import Colors
+// @Description Create Colors from a csv with the header name,hexCode or a json array of dto.CreateColorRequest. Rows are created or updated by name. Every row is validated like a create request and reported on its own.
+// @Tags Colors
+// @Accept text/csv,application/json,multipart/form-data
+// @produces json
+// @Param file formData file false "The upload, the request body is read when there is no form"
+// @Param format query string false "csv or json, by the content type or file name by default"
+// @Param dryRun query bool false "Report what the import would do and keep nothing"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ImportResponse[dto.ColorResponse]} "All rows imported"
+// @Success 207 {object} helper.BaseHttpResponse{result=dto.ImportResponse[dto.ColorResponse]} "Some rows failed"
+// @Failure 400 {object} helper.BaseHttpResponse "Malformed upload"
+// @Failure 413 {object} helper.BaseHttpResponse "Too many rows"
+// @Router /v1/colors/import [post]
+// @Security AuthBearer
+func (h *ColorHandler) Import(c *gin.Context) {
+	Import(c, nil, dto.ToCreateColor, dto.ToColorResponse, h.usecase.Import)
+}
@@ -17,7 +17,8 @@ type CountryHandler struct {
 
 func NewCountryHandler(cfg *config.Config) *CountryHandler {
 	return &CountryHandler{
-		usecase: usecase.NewCountryUsecase(cfg, dependency.GetCountryRepository(cfg))}
+		usecase: usecase.NewCountryUsecase(cfg, dependency.GetCountryRepository(cfg),
+			dependency.GetCityRepository(cfg), dependency.GetTransactionManager())}
 }
 
 // CreateCountry godoc
@@ -26,13 +27,13 @@ func NewCountryHandler(cfg *config.Config) *CountryHandler {
 // @Tags Countries
 // @Accept json
 // @produces json
-// @Param Request body dto.CreateUpdateCountryRequest true "Create a country"
+// @Param Request body dto.CreateCountryRequest true "Create a country with its cities"
 // @Success 201 {object} helper.BaseHttpResponse{result=dto.CountryResponse} "Country response"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Router /v1/countries/ [post]
 // @Security AuthBearer
 func (h *CountryHandler) Create(c *gin.Context) {
-	Create(c, dto.ToCreateUpdateCountry, dto.ToCountryResponse, h.usecase.Create)
+	Create(c, dto.ToCreateCountry, dto.ToCountryResponse, h.usecase.Create)
 }
 
 // UpdateCountry godoc
@@ -42,9 +43,12 @@ func (h *CountryHandler) Create(c *gin.Context) {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being changed"
 // @Param Request body dto.CreateUpdateCountryRequest true "Update a country"
 // @Success 200 {object} helper.BaseHttpResponse{result=dto.CountryResponse} "Country response"
+// @Header 200 {string} ETag "Row version"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 412 {object} helper.BaseHttpResponse "Modified by someone else"
 // @Router /v1/countries/{id} [put]
 // @Security AuthBearer
 func (h *CountryHandler) Update(c *gin.Context) {
@@ -58,8 +62,10 @@ func (h *CountryHandler) Update(c *gin.Context) {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being changed"
 // @Success 200 {object} helper.BaseHttpResponse "response"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 412 {object} helper.BaseHttpResponse "Modified by someone else"
 // @Router /v1/countries/{id} [delete]
 // @Security AuthBearer
 func (h *CountryHandler) Delete(c *gin.Context) {
@@ -73,7 +79,11 @@ func (h *CountryHandler) Delete(c *gin.Context) {
 // @Accept json
 // @produces json
 // @Param id path int true "Id"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name"
+// @Param include query string false "Comma separated relations to load, e.g. cities,companies"
+// @Param asOf query string false "RFC 3339 time to read the country as of, e.g. 2024-03-01T12:00:00Z"
 // @Success 200 {object} helper.BaseHttpResponse{result=dto.CountryResponse} "Country response"
+// @Header 200 {string} ETag "Row version"
 // @Failure 400 {object} helper.BaseHttpResponse "Bad request"
 // @Router /v1/countries/{id} [get]
 // @Security AuthBearer
@@ -95,3 +105,188 @@ func (h *CountryHandler) GetById(c *gin.Context) {
 func (h *CountryHandler) GetByFilter(c *gin.Context) {
 	GetByFilter(c, dto.ToCountryResponse, h.usecase.GetByFilter)
 }
+
+// ListCountries godoc
+// @Summary List Countries
+// @Description List Countries filtered, sorted and paged by query string
+// @Tags Countries
+// @Accept json
+// @produces json
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. name:startsWith:Ir" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -name,id"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Param cursorMode query bool false "Page by sort keys instead of page number"
+// @Param cursor query string false "nextCursor or previousCursor of a previous page"
+// @Param withTotal query bool false "Count the total in cursor mode"
+// @Param fields query string false "Comma separated fields to return, e.g. id,name"
+// @Param include query string false "Comma separated relations to load, e.g. cities,companies"
+// @Param asOf query string false "RFC 3339 time to read the countries as of, e.g. 2024-03-01T12:00:00Z"
+// @Success 200 {object} helper.BaseHttpResponse "Country response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/countries [get]
+// @Security AuthBearer
+func (h *CountryHandler) GetByQuery(c *gin.Context) {
+	GetByQuery(c, dto.ToCountryResponse, h.usecase.GetByFilter)
+}
+
+// GetDeletedCountries godoc
+// @Summary List deleted Countries
+// @Description List soft deleted Countries with the query string filter, admin only
+// @Tags Countries
+// @Accept json
+// @produces json
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. name:startsWith:Ir" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -deletedAt"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} helper.BaseHttpResponse "Country response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/countries/trash [get]
+// @Security AuthBearer
+func (h *CountryHandler) GetDeleted(c *gin.Context) {
+	GetByQuery(c, dto.ToCountryResponse, h.usecase.GetDeleted)
+}
+
+// RestoreCountry godoc
+// @Summary Restore a Country
+// @Description Restore a soft deleted Country, admin only
+// @Tags Countries
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse "response"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/countries/trash/{id}/restore [post]
+// @Security AuthBearer
+func (h *CountryHandler) Restore(c *gin.Context) {
+	Restore(c, h.usecase.Restore)
+}
+
+// PurgeCountry godoc
+// @Summary Purge a Country
+// @Description Permanently delete a soft deleted Country, admin only
+// @Tags Countries
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse "response"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/countries/trash/{id} [delete]
+// @Security AuthBearer
+func (h *CountryHandler) Purge(c *gin.Context) {
+	Purge(c, h.usecase.Purge)
+}
+
+// GetCountryHistory godoc
+// @Summary History of a Country
+// @Description List the audit records of a Country, oldest first, admin only
+// @Tags Countries
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. action:equals:update" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -createdAt"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} helper.BaseHttpResponse{result=filter.PagedList[dto.AuditLogResponse]} "Audit response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/countries/{id}/history [get]
+// @Security AuthBearer
+func (h *CountryHandler) GetHistory(c *gin.Context) {
+	GetHistory(c, dto.ToAuditLogResponse, h.usecase.GetHistory)
+}
+
+// BulkCountries godoc
+// @Summary Create, update and delete Countries in bulk
+// @Description Apply a list of operations in atomic (default) or bestEffort mode, with a result per operation
+// @Tags Countries
+// @Accept json
+// @produces json
+// @Param Request body dto.BulkRequest true "Operations, data is a dto.CreateUpdateCountryRequest or dto.CreateUpdateCountryRequest"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.BulkResponse[dto.CountryResponse]} "All operations applied"
+// @Success 207 {object} helper.BaseHttpResponse{result=dto.BulkResponse[dto.CountryResponse]} "Some operations failed in bestEffort mode"
+// @Failure 400 {object} helper.BaseHttpResponse{result=dto.BulkResponse[dto.CountryResponse]} "Bad request"
+// @Failure 413 {object} helper.BaseHttpResponse "Too many operations"
+// @Router /v1/countries/bulk [post]
+// @Security AuthBearer
+func (h *CountryHandler) Bulk(c *gin.Context) {
+	Bulk(c, dto.ToCreateUpdateCountry, dto.ToCreateUpdateCountry, dto.ToCountryResponse, h.usecase.Bulk)
+}
+
+// UpsertCountry godoc
+// @Summary Create or update a Country by name
+// @Description Create the Country with the name in the path, or overwrite the existing one
+// @Tags Countries
+// @Accept json
+// @produces json
+// @Param key path string true "Name"
+// @Param Request body dto.CreateUpdateCountryRequest true "The Country, the name is taken from the path"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.CountryResponse} "Updated"
+// @Success 201 {object} helper.BaseHttpResponse{result=dto.CountryResponse} "Created"
+// @Header 200 {string} ETag "Row version"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/countries/by-key/{key} [put]
+// @Security AuthBearer
+func (h *CountryHandler) Upsert(c *gin.Context) {
+	Upsert(c, dto.WithCountryKey, dto.ToCreateUpdateCountry, dto.ToCountryResponse, h.usecase.Upsert)
+}
+
+// AggregateCountries godoc
+// @Summary Aggregate Countries
+// @Description Count Countries and compute min, max, sum and avg of numeric fields per group, with the dynamic filter of get-by-filter
+// @Tags Countries
+// @Accept json
+// @produces json
+// @Param Request body filter.AggregateInput true "Filter, groupBy fields such as Name, aggregates and sort on either"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.AggregateRowResponse} "Groups"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/countries/aggregate [post]
+// @Security AuthBearer
+func (h *CountryHandler) Aggregate(c *gin.Context) {
+	Aggregate(c, dto.ToAggregateRowResponse, h.usecase.Aggregate)
+}
+
+// ExportCountries godoc
+// @Summary Export Countries
+// @Description Download the Countries of the query string filter and sort, streamed in batches
+// @Tags Countries
+// @produces text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Param columns query string false "Comma separated json paths of the response, e.g. id,name"
+// @Param lang query string false "Language of the headers, en or fa, Accept-Language by default"
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. name:startsWith:Ir" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -name,id"
+// @Param search query string false "Words to match in the search fields"
+// @Param include query string false "Comma separated relations to load, e.g. cities,companies"
+// @Param asOf query string false "RFC 3339 time to read the countries as of, e.g. 2024-03-01T12:00:00Z"
+// @Success 200 {file} file "The export"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/countries/export [get]
+// @Security AuthBearer
+func (h *CountryHandler) Export(c *gin.Context) {
+	Export(c, "countries", dto.ToCountryResponse, h.usecase.Export)
+}
+
+// ImportCountries godoc
+// @Summary Import Countries
+// @Description Create Countries from a csv with the header name or a json array of dto.CreateUpdateCountryRequest. Rows are created or updated by name. Every row is validated like a create request and reported on its own.
+// @Tags Countries
+// @Accept text/csv,application/json,multipart/form-data
+// @produces json
+// @Param file formData file false "The upload, the request body is read when there is no form"
+// @Param format query string false "csv or json, by the content type or file name by default"
+// @Param dryRun query bool false "Report what the import would do and keep nothing"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.ImportResponse[dto.CountryResponse]} "All rows imported"
+// @Success 207 {object} helper.BaseHttpResponse{result=dto.ImportResponse[dto.CountryResponse]} "Some rows failed"
+// @Failure 400 {object} helper.BaseHttpResponse "Malformed upload"
+// @Failure 413 {object} helper.BaseHttpResponse "Too many rows"
+// @Router /v1/countries/import [post]
+// @Security AuthBearer
+func (h *CountryHandler) Import(c *gin.Context) {
+	Import(c, nil, dto.ToCreateUpdateCountry, dto.ToCountryResponse, h.usecase.Import)
+}
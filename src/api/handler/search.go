@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+
+	"golang-clean-web-api/api/dto"
+	"golang-clean-web-api/api/helper"
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/dependency"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	usecase *usecase.SearchUsecase
+}
+
+func NewSearchHandler(cfg *config.Config) *SearchHandler {
+	return &SearchHandler{
+		usecase: usecase.NewSearchUsecase(cfg, dependency.GetSearchRepository(cfg)),
+	}
+}
+
+// Search godoc
+// @Summary Search entities
+// @Description Full-text and fuzzy search across countries, cities, companies and colors, most relevant first
+// @Tags Search
+// @Accept json
+// @produces json
+// @Param q query string true "Text to search, misspelled words match close names"
+// @Param types query string false "Comma separated entity types: country, city, company, color"
+// @Param limit query int false "At most this many hits, 20 by default and 100 at most"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.SearchHitResponse} "Search response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/search [get]
+// @Security AuthBearer
+func (h *SearchHandler) Search(c *gin.Context) {
+	input, err := filter.ParseSearch(c.Request.URL.Query())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithQueryError(nil, false, helper.ValidationError, err))
+		return
+	}
+	hits, err := h.usecase.Search(c.Request.Context(), *input)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
+		return
+	}
+	response := make([]dto.SearchHitResponse, 0, len(hits))
+	for _, hit := range hits {
+		response = append(response, dto.ToSearchHitResponse(hit))
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
+}
+
+// Suggest godoc
+// @Summary Suggest names
+// @Description Typeahead: entities of one type whose name, or a word of it, starts with the text
+// @Tags Search
+// @Accept json
+// @produces json
+// @Param q query string true "Start of the name"
+// @Param type query string true "Entity type: country, city, company or color"
+// @Param limit query int false "At most this many suggestions, 20 by default and 100 at most"
+// @Success 200 {object} helper.BaseHttpResponse{result=[]dto.SuggestionResponse} "Suggestions"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/search/suggest [get]
+// @Security AuthBearer
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	input, err := filter.ParseSuggest(c.Request.URL.Query())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest,
+			helper.GenerateBaseResponseWithQueryError(nil, false, helper.ValidationError, err))
+		return
+	}
+	suggestions, err := h.usecase.Suggest(c.Request.Context(), *input)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
+		return
+	}
+	response := make([]dto.SuggestionResponse, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		response = append(response, dto.ToSuggestionResponse(suggestion))
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(response, true, 0))
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"golang-clean-web-api/api/dto"
+	"golang-clean-web-api/api/helper"
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/dependency"
+	_ "golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/usecase"
+	usecaseDto "golang-clean-web-api/usecase/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	usecase *usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(cfg *config.Config) *WebhookHandler {
+	return &WebhookHandler{
+		usecase: usecase.NewWebhookUsecase(cfg, dependency.GetWebhookSubscriptionRepository(cfg),
+			dependency.GetWebhookDeliveryRepository(cfg), dependency.GetTransactionManager()),
+	}
+}
+
+// CreateWebhookSubscription godoc
+// @Summary Create a webhook subscription
+// @Description Subscribe a url to domain events, admin only. The secret signing the deliveries is only returned here.
+// @Tags Webhooks
+// @Accept json
+// @produces json
+// @Param Request body dto.CreateWebhookSubscriptionRequest true "Create a webhook subscription"
+// @Success 201 {object} helper.BaseHttpResponse{result=dto.WebhookSubscriptionResponse} "Subscription response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 403 {object} helper.BaseHttpResponse "Forbidden"
+// @Router /v1/webhooks/ [post]
+// @Security AuthBearer
+func (h *WebhookHandler) Create(c *gin.Context) {
+	Create(c, dto.ToCreateWebhookSubscription, dto.ToCreatedWebhookSubscriptionResponse, h.usecase.Create)
+}
+
+// UpdateWebhookSubscription godoc
+// @Summary Update a webhook subscription
+// @Description Replace the url, filters and state of a webhook subscription, admin only
+// @Tags Webhooks
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Param Request body dto.UpdateWebhookSubscriptionRequest true "Update a webhook subscription"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.WebhookSubscriptionResponse} "Subscription response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Failure 412 {object} helper.BaseHttpResponse "Modified by someone else"
+// @Router /v1/webhooks/{id} [put]
+// @Security AuthBearer
+func (h *WebhookHandler) Update(c *gin.Context) {
+	Update(c, dto.ToUpdateWebhookSubscription, dto.ToWebhookSubscriptionResponse, h.usecase.Update)
+}
+
+// DeleteWebhookSubscription godoc
+// @Summary Delete a webhook subscription
+// @Description Delete a webhook subscription, its pending deliveries are not sent, admin only
+// @Tags Webhooks
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Success 200 {object} helper.BaseHttpResponse "response"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Failure 412 {object} helper.BaseHttpResponse "Modified by someone else"
+// @Router /v1/webhooks/{id} [delete]
+// @Security AuthBearer
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	Delete(c, h.usecase.Delete)
+}
+
+// GetWebhookSubscription godoc
+// @Summary Get a webhook subscription
+// @Description Get a webhook subscription, admin only
+// @Tags Webhooks
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.WebhookSubscriptionResponse} "Subscription response"
+// @Header 200 {string} ETag "Row version"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/webhooks/{id} [get]
+// @Security AuthBearer
+func (h *WebhookHandler) GetById(c *gin.Context) {
+	GetById(c, dto.ToWebhookSubscriptionResponse, h.usecase.GetById)
+}
+
+// GetWebhookSubscriptions godoc
+// @Summary List webhook subscriptions
+// @Description List the webhook subscriptions, admin only
+// @Tags Webhooks
+// @Accept json
+// @produces json
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. url:contains:partner" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -id"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} helper.BaseHttpResponse{result=filter.PagedList[dto.WebhookSubscriptionResponse]} "Subscription response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Router /v1/webhooks [get]
+// @Security AuthBearer
+func (h *WebhookHandler) GetByQuery(c *gin.Context) {
+	GetByQuery(c, dto.ToWebhookSubscriptionResponse, h.usecase.GetByFilter)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Delivery log of a webhook subscription
+// @Description List the deliveries of a webhook subscription with the response code of their last attempt, newest first, admin only
+// @Tags Webhooks
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Param filter query []string false "Condition field:type:value, repeatable, e.g. status:equals:dead" collectionFormat(multi)
+// @Param sort query string false "Comma separated fields, prefix with - for descending, e.g. -createdAt"
+// @Param page query int false "Page number"
+// @Param pageSize query int false "Page size"
+// @Success 200 {object} helper.BaseHttpResponse{result=filter.PagedList[dto.WebhookDeliveryResponse]} "Delivery response"
+// @Failure 400 {object} helper.BaseHttpResponse "Bad request"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/webhooks/{id}/deliveries [get]
+// @Security AuthBearer
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	GetHistory(c, dto.ToWebhookDeliveryResponse, h.usecase.GetDeliveries)
+}
+
+// PingWebhookSubscription godoc
+// @Summary Ping a webhook subscription
+// @Description Send a signed Ping event to the subscription right away, once, and return the logged delivery, admin only
+// @Tags Webhooks
+// @Accept json
+// @produces json
+// @Param id path int true "Id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.WebhookDeliveryResponse} "Delivery response"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Router /v1/webhooks/{id}/ping [post]
+// @Security AuthBearer
+func (h *WebhookHandler) Ping(c *gin.Context) {
+	deliver(c, h.usecase.Ping)
+}
+
+// ReplayWebhookDelivery godoc
+// @Summary Replay a webhook delivery
+// @Description Send a delivery that has not succeeded again right away, a dead one gets a fresh set of retries, admin only
+// @Tags Webhooks
+// @Accept json
+// @produces json
+// @Param id path int true "Delivery id"
+// @Success 200 {object} helper.BaseHttpResponse{result=dto.WebhookDeliveryResponse} "Delivery response"
+// @Failure 404 {object} helper.BaseHttpResponse "Not found"
+// @Failure 409 {object} helper.BaseHttpResponse "Already delivered"
+// @Router /v1/webhooks/deliveries/{id}/replay [post]
+// @Security AuthBearer
+func (h *WebhookHandler) Replay(c *gin.Context) {
+	deliver(c, h.usecase.Replay)
+}
+
+// deliver runs a usecase action that sends a delivery of the id in the path and
+// answers with the delivery, whether the receiver took it or not
+func deliver(c *gin.Context, usecaseDeliver func(ctx context.Context, id int) (usecaseDto.WebhookDelivery, error)) {
+	id, _ := strconv.Atoi(c.Params.ByName("id"))
+	if id == 0 {
+		c.AbortWithStatusJSON(http.StatusNotFound,
+			helper.GenerateBaseResponse(nil, false, helper.ValidationError))
+		return
+	}
+
+	delivery, err := usecaseDeliver(c.Request.Context(), id)
+	if err != nil {
+		c.AbortWithStatusJSON(helper.TranslateErrorToStatusCode(err),
+			helper.GenerateBaseResponseWithError(nil, false, helper.TranslateErrorToResultCode(err), err))
+		return
+	}
+	c.JSON(http.StatusOK, helper.GenerateBaseResponse(dto.ToWebhookDeliveryResponse(delivery), true, 0))
+}
@@ -1,6 +1,12 @@
 package helper
 
-import validation "golang-clean-web-api/api/validation"
+import (
+	"errors"
+
+	validation "golang-clean-web-api/api/validation"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/pkg/service_errors"
+)
 
 type BaseHttpResponse struct {
 	Result           any                           `json:"result"`
@@ -19,13 +25,35 @@ func GenerateBaseResponse(result any, success bool, resultCode ResultCode) *Base
 
 func GenerateBaseResponseWithError(result any, success bool, resultCode ResultCode, err error) *BaseHttpResponse {
 	return &BaseHttpResponse{Result: result,
-		Success:    success,
-		ResultCode: resultCode,
-		Error:      err.Error(),
+		Success:          success,
+		ResultCode:       resultCode,
+		ValidationErrors: FieldErrors(err),
+		Error:            err.Error(),
 	}
 
 }
 
+// FieldErrors lists the field or query parameter a service error is about, nil
+// when there is none
+func FieldErrors(err error) *[]validation.ValidationError {
+	var serviceError *service_errors.ServiceError
+	if !errors.As(err, &serviceError) {
+		return nil
+	}
+	var queryError *filter.QueryError
+	if errors.As(serviceError.Err, &queryError) {
+		return queryErrors(queryError)
+	}
+	if serviceError.Field == "" {
+		return nil
+	}
+	return &[]validation.ValidationError{{
+		Property: serviceError.Field,
+		Tag:      "db",
+		Message:  serviceError.EndUserMessage,
+	}}
+}
+
 func GenerateBaseResponseWithAnyError(result any, success bool, resultCode ResultCode, err any) *BaseHttpResponse {
 	return &BaseHttpResponse{Result: result,
 		Success:    success,
@@ -41,3 +69,24 @@ func GenerateBaseResponseWithValidationError(result any, success bool, resultCod
 		ValidationErrors: validation.GetValidationErrors(err),
 	}
 }
+
+func GenerateBaseResponseWithQueryError(result any, success bool, resultCode ResultCode, err error) *BaseHttpResponse {
+	var queryError *filter.QueryError
+	if !errors.As(err, &queryError) {
+		return GenerateBaseResponseWithError(result, success, resultCode, err)
+	}
+	return &BaseHttpResponse{Result: result,
+		Success:          success,
+		ResultCode:       resultCode,
+		ValidationErrors: queryErrors(queryError),
+	}
+}
+
+func queryErrors(queryError *filter.QueryError) *[]validation.ValidationError {
+	return &[]validation.ValidationError{{
+		Property: queryError.Parameter,
+		Tag:      "query",
+		Value:    queryError.Value,
+		Message:  queryError.Message,
+	}}
+}
@@ -0,0 +1,30 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag formats a row version as a strong entity tag, e.g. "3"
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ParseETag reads the version of an If-Match value. Weak tags are accepted since
+// the version identifies the row state, "*" matches any version.
+func ParseETag(value string) (version int, any bool, ok bool) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return 0, true, true
+	}
+	value = strings.TrimPrefix(value, "W/")
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, false, false
+	}
+	version, err = strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, false, false
+	}
+	return version, false, true
+}
@@ -18,6 +18,78 @@ var StatusCodeMapping = map[string]int{
 	service_errors.UsernameExists:   409,
 	service_errors.RecordNotFound:   404,
 	service_errors.PermissionDenied: 403,
+
+	// DB
+	service_errors.ConcurrencyConflict: 412,
+	service_errors.DuplicateRecord:     409,
+	service_errors.RecordInUse:         409,
+	service_errors.InvalidReference:    422,
+	service_errors.RequiredValue:       422,
+	service_errors.InvalidValue:        422,
+	service_errors.ValueTooLong:        422,
+
+	// Bulk
+	service_errors.BulkTooLarge:         413,
+	service_errors.InvalidBulkOperation: 400,
+	service_errors.BulkRolledBack:       424,
+
+	// Import
+	service_errors.ImportTooLarge:    413,
+	service_errors.InvalidImportFile: 400,
+	service_errors.InvalidImportRow:  400,
+
+	// Filter
+	service_errors.InvalidFilter: 400,
+
+	// Webhook
+	service_errors.AlreadyDelivered: 409,
+}
+
+// ResultCodeMapping gives the result code of the errors that are not internal
+var ResultCodeMapping = map[string]ResultCode{
+	// OTP
+	service_errors.OptExists:   ConflictError,
+	service_errors.OtpUsed:     ConflictError,
+	service_errors.OtpNotValid: ValidationError,
+
+	// User
+	service_errors.EmailExists:      ConflictError,
+	service_errors.UsernameExists:   ConflictError,
+	service_errors.RecordNotFound:   NotFoundError,
+	service_errors.PermissionDenied: ForbiddenError,
+
+	// DB
+	service_errors.ConcurrencyConflict: PreconditionError,
+	service_errors.DuplicateRecord:     ConflictError,
+	service_errors.RecordInUse:         ConflictError,
+	service_errors.InvalidReference:    UnprocessableError,
+	service_errors.RequiredValue:       UnprocessableError,
+	service_errors.InvalidValue:        UnprocessableError,
+	service_errors.ValueTooLong:        UnprocessableError,
+
+	// Filter
+	service_errors.InvalidFilter: ValidationError,
+
+	// Bulk
+	service_errors.BulkTooLarge:         ValidationError,
+	service_errors.InvalidBulkOperation: ValidationError,
+	service_errors.BulkRolledBack:       ValidationError,
+
+	// Import
+	service_errors.ImportTooLarge:    ValidationError,
+	service_errors.InvalidImportFile: ValidationError,
+	service_errors.InvalidImportRow:  ValidationError,
+
+	// Webhook
+	service_errors.AlreadyDelivered: ConflictError,
+}
+
+func TranslateErrorToResultCode(err error) ResultCode {
+	value, ok := ResultCodeMapping[err.Error()]
+	if !ok {
+		return InternalError
+	}
+	return value
 }
 
 func TranslateErrorToStatusCode(err error) int {
@@ -6,17 +6,19 @@ import (
 
 	"golang-clean-web-api/api/helper"
 	"golang-clean-web-api/config"
-	"golang-clean-web-api/pkg/jwt"
+	"golang-clean-web-api/constant"
+	"golang-clean-web-api/dependency"
+	"golang-clean-web-api/pkg/identity"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Authentication middleware
 func Authentication(cfg *config.Config) gin.HandlerFunc {
-	tokenService := jwt.NewTokenService(cfg)
+	tokenService := dependency.GetTokenService(cfg)
 
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
+		authHeader := c.GetHeader(constant.AuthorizationHeaderKey)
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized,
 				helper.GenerateBaseResponse(nil, false, helper.AuthError))
@@ -38,10 +40,38 @@ func Authentication(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		// Store user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		// Attach the identity to the request context so usecases and repositories can see it
+		user := identity.Identity{}
+		if current, ok := identity.FromContext(c.Request.Context()); ok {
+			user = *current
+		}
+		user.UserId = int(claims.UserID)
+		user.Username = claims.Username
+		user.Roles = claims.Roles
+		user.Tenant = claims.Tenant
+		c.Request = c.Request.WithContext(identity.NewContext(c.Request.Context(), &user))
 
 		c.Next()
 	}
 }
+
+// Authorization lets the request through when its identity has one of the roles.
+// It runs after Authentication.
+func Authorization(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := identity.FromContext(c.Request.Context())
+		if !ok || !user.IsAuthenticated() {
+			c.AbortWithStatusJSON(http.StatusUnauthorized,
+				helper.GenerateBaseResponse(nil, false, helper.AuthError))
+			return
+		}
+		for _, role := range roles {
+			if user.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden,
+			helper.GenerateBaseResponse(nil, false, helper.ForbiddenError))
+	}
+}
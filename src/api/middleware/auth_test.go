@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/constant"
+	"golang-clean-web-api/pkg/identity"
+	"golang-clean-web-api/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+func TestAuthentication_AttachesIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		Jwt: config.JwtConfig{
+			Secret:            "test-secret-key-for-jwt-testing-purposes",
+			AccessExpireTime:  60,
+			RefreshExpireTime: 10080,
+		},
+	}
+	token, err := jwt.NewTokenService(cfg).GenerateAccessToken(42, "tester", constant.AdminRoleName)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	var got *identity.Identity
+	r := gin.New()
+	r.Use(RequestId())
+	r.GET("/me", Authentication(cfg), func(c *gin.Context) {
+		got, _ = identity.FromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set(constant.AuthorizationHeaderKey, "Bearer "+token)
+	req.Header.Set(constant.RequestIdHeaderKey, "req-42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if got == nil {
+		t.Fatal("Expected identity in request context")
+	}
+	if got.UserId != 42 || got.Username != "tester" {
+		t.Errorf("Expected user 42/tester, got %d/%s", got.UserId, got.Username)
+	}
+	if !got.HasRole(constant.AdminRoleName) {
+		t.Errorf("Expected role %s, got %v", constant.AdminRoleName, got.Roles)
+	}
+	if got.Tenant != "" {
+		t.Errorf("Expected no tenant for a token without one, got %s", got.Tenant)
+	}
+	if got.RequestId != "req-42" {
+		t.Errorf("Expected request id req-42, got %s", got.RequestId)
+	}
+	if w.Header().Get(constant.RequestIdHeaderKey) != "req-42" {
+		t.Errorf("Expected request id header req-42, got %s", w.Header().Get(constant.RequestIdHeaderKey))
+	}
+}
+
+func TestAuthentication_AttachesTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Jwt: config.JwtConfig{Secret: "test-secret"}}
+	claims := &jwt.Claims{UserID: 42, Username: "tester", Tenant: "acme", RegisteredClaims: gojwt.RegisteredClaims{
+		ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+	token, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims).SignedString([]byte(cfg.Jwt.Secret))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	var got *identity.Identity
+	r := gin.New()
+	r.GET("/me", Authentication(cfg), func(c *gin.Context) {
+		got, _ = identity.FromContext(c.Request.Context())
+	})
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set(constant.AuthorizationHeaderKey, "Bearer "+token)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got == nil || got.Tenant != "acme" {
+		t.Errorf("Expected tenant acme in the identity, got %+v", got)
+	}
+}
+
+func TestAuthentication_RejectsMissingToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Jwt: config.JwtConfig{Secret: "test-secret"}}
+
+	r := gin.New()
+	r.GET("/me", Authentication(cfg), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me", nil))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+}
+
+func TestAuthorization_RequiresRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := map[string]struct {
+		user     *identity.Identity
+		expected int
+	}{
+		"anonymous": {nil, http.StatusUnauthorized},
+		"no role":   {&identity.Identity{UserId: 7, Roles: []string{constant.DefaultRoleName}}, http.StatusForbidden},
+		"admin":     {&identity.Identity{UserId: 7, Roles: []string{constant.AdminRoleName}}, http.StatusOK},
+	}
+	for name, c := range cases {
+		r := gin.New()
+		r.Use(func(ctx *gin.Context) {
+			if c.user != nil {
+				ctx.Request = ctx.Request.WithContext(identity.NewContext(ctx.Request.Context(), c.user))
+			}
+		})
+		r.GET("/trash", Authorization(constant.AdminRoleName), func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/trash", nil))
+		if w.Code != c.expected {
+			t.Errorf("%s: expected status %d, got %d", name, c.expected, w.Code)
+		}
+	}
+}
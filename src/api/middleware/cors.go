@@ -11,7 +11,8 @@ func Cors(cfg *config.Config) gin.HandlerFunc {
 
 		c.Writer.Header().Set("Access-Control-Allow-Origin", cfg.Cors.AllowOrigins)
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-Id, If-Match")
+		c.Header("Access-Control-Expose-Headers", "ETag, X-Request-Id")
 		c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE,UPDATE")
 		c.Header("Access-Control-Max-Age", "21600")
 		c.Set("content-type", "application/json")
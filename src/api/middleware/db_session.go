@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"golang-clean-web-api/infra/persistence/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DbSession starts a database session per request, so that reads following a
+// write in the same request go to the primary instead of a lagging replica
+func DbSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.NewSessionContext(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"regexp"
+
+	"golang-clean-web-api/constant"
+	"golang-clean-web-api/pkg/identity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requestIdExp is what a client request id may look like, it has to fit the
+// request_id columns of the audit log and the outbox
+var requestIdExp = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestId tags every request with an id, taken from the X-Request-Id header
+// when the client sends a valid one, and attaches it to the request context
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(constant.RequestIdHeaderKey)
+		if !requestIdExp.MatchString(requestId) {
+			requestId = uuid.NewString()
+		}
+		c.Header(constant.RequestIdHeaderKey, requestId)
+
+		ctx := identity.NewContext(c.Request.Context(), &identity.Identity{RequestId: requestId})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang-clean-web-api/constant"
+	"golang-clean-web-api/pkg/identity"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestId_ReplacesInvalidHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestId())
+	var seen string
+	router.GET("/", func(c *gin.Context) {
+		seen = identity.RequestId(c.Request.Context())
+	})
+
+	for header, kept := range map[string]bool{
+		"req-42_a.b":            true,
+		"":                      false,
+		strings.Repeat("a", 65): false,
+		"bad id\r\n":            false,
+		"<script>":              false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(constant.RequestIdHeaderKey, header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if kept && seen != header {
+			t.Errorf("Expected %q kept, got %q", header, seen)
+		}
+		if !kept && (seen == header || len(seen) != 36) {
+			t.Errorf("Expected %q replaced by a generated id, got %q", header, seen)
+		}
+		if w.Header().Get(constant.RequestIdHeaderKey) != seen {
+			t.Errorf("Expected the response header to carry %q, got %q", seen, w.Header().Get(constant.RequestIdHeaderKey))
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package router
+
+import (
+	"golang-clean-web-api/api/handler"
+	"golang-clean-web-api/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Audit(r *gin.RouterGroup, cfg *config.Config) {
+	h := handler.NewAuditHandler(cfg)
+
+	r.GET("", h.GetByQuery)
+}
@@ -2,12 +2,20 @@ package router
 
 import (
 	"golang-clean-web-api/api/handler"
+	"golang-clean-web-api/api/middleware"
 	"golang-clean-web-api/config"
+	"golang-clean-web-api/constant"
 
 	"github.com/gin-gonic/gin"
 )
 
 const GetByFilterExp string = "/get-by-filter"
+const TrashExp string = "/trash"
+const BulkExp string = "/bulk"
+const ByKeyExp string = "/by-key"
+const AggregateExp string = "/aggregate"
+const ExportExp string = "/export"
+const ImportExp string = "/import"
 
 func Country(r *gin.RouterGroup, cfg *config.Config) {
 	h := handler.NewCountryHandler(cfg)
@@ -16,7 +24,19 @@ func Country(r *gin.RouterGroup, cfg *config.Config) {
 	r.PUT("/:id", h.Update)
 	r.DELETE("/:id", h.Delete)
 	r.GET("/:id", h.GetById)
+	r.GET("", h.GetByQuery)
 	r.POST(GetByFilterExp, h.GetByFilter)
+	r.POST(BulkExp, h.Bulk)
+	r.POST(AggregateExp, h.Aggregate)
+	r.GET(ExportExp, h.Export)
+	r.POST(ImportExp, h.Import)
+	r.PUT(ByKeyExp+"/:key", h.Upsert)
+	r.GET("/:id/history", middleware.Authorization(constant.AdminRoleName), h.GetHistory)
+
+	trash := r.Group(TrashExp, middleware.Authorization(constant.AdminRoleName))
+	trash.GET("", h.GetDeleted)
+	trash.POST("/:id/restore", h.Restore)
+	trash.DELETE("/:id", h.Purge)
 }
 
 func City(r *gin.RouterGroup, cfg *config.Config) {
@@ -26,7 +46,18 @@ func City(r *gin.RouterGroup, cfg *config.Config) {
 	r.PUT("/:id", h.Update)
 	r.DELETE("/:id", h.Delete)
 	r.GET("/:id", h.GetById)
+	r.GET("", h.GetByQuery)
 	r.POST(GetByFilterExp, h.GetByFilter)
+	r.POST(BulkExp, h.Bulk)
+	r.POST(AggregateExp, h.Aggregate)
+	r.GET(ExportExp, h.Export)
+	r.POST(ImportExp, h.Import)
+	r.GET("/:id/history", middleware.Authorization(constant.AdminRoleName), h.GetHistory)
+
+	trash := r.Group(TrashExp, middleware.Authorization(constant.AdminRoleName))
+	trash.GET("", h.GetDeleted)
+	trash.POST("/:id/restore", h.Restore)
+	trash.DELETE("/:id", h.Purge)
 }
 
 func Color(r *gin.RouterGroup, cfg *config.Config) {
@@ -36,5 +67,17 @@ func Color(r *gin.RouterGroup, cfg *config.Config) {
 	r.PUT("/:id", h.Update)
 	r.DELETE("/:id", h.Delete)
 	r.GET("/:id", h.GetById)
+	r.GET("", h.GetByQuery)
 	r.POST(GetByFilterExp, h.GetByFilter)
+	r.POST(BulkExp, h.Bulk)
+	r.POST(AggregateExp, h.Aggregate)
+	r.GET(ExportExp, h.Export)
+	r.POST(ImportExp, h.Import)
+	r.PUT(ByKeyExp+"/:key", h.Upsert)
+	r.GET("/:id/history", middleware.Authorization(constant.AdminRoleName), h.GetHistory)
+
+	trash := r.Group(TrashExp, middleware.Authorization(constant.AdminRoleName))
+	trash.GET("", h.GetDeleted)
+	trash.POST("/:id/restore", h.Restore)
+	trash.DELETE("/:id", h.Purge)
 }
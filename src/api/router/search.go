@@ -0,0 +1,15 @@
+package router
+
+import (
+	"golang-clean-web-api/api/handler"
+	"golang-clean-web-api/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Search(r *gin.RouterGroup, cfg *config.Config) {
+	h := handler.NewSearchHandler(cfg)
+
+	r.GET("", h.Search)
+	r.GET("/suggest", h.Suggest)
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"golang-clean-web-api/api/handler"
+	"golang-clean-web-api/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Webhook(r *gin.RouterGroup, cfg *config.Config) {
+	h := handler.NewWebhookHandler(cfg)
+
+	r.POST("/", h.Create)
+	r.PUT("/:id", h.Update)
+	r.DELETE("/:id", h.Delete)
+	r.GET("/:id", h.GetById)
+	r.GET("", h.GetByQuery)
+	r.GET("/:id/deliveries", h.GetDeliveries)
+	r.POST("/:id/ping", h.Ping)
+	r.POST("/deliveries/:id/replay", h.Replay)
+}
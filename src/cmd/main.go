@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
+
 	"golang-clean-web-api/api"
 	"golang-clean-web-api/config"
+	"golang-clean-web-api/dependency"
 	"golang-clean-web-api/infra/cache"
 	database "golang-clean-web-api/infra/persistence/database"
 	"golang-clean-web-api/infra/persistence/migration"
+	"golang-clean-web-api/infra/persistence/seed"
 	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/usecase"
 
 	_ "golang-clean-web-api/docs" // This line is necessary for Swagger to find your docs
 )
@@ -38,7 +43,11 @@ func main() {
 	err := cache.InitRedis(cfg)
 	defer cache.CloseRedis()
 	if err != nil {
-		logger.Fatal(logging.Redis, logging.Startup, err.Error(), nil)
+		// the sqlite mode runs without any server, redis included
+		if cfg.Postgres.Driver != database.DriverSqlite {
+			logger.Fatal(logging.Redis, logging.Startup, err.Error(), nil)
+		}
+		logger.Warn(logging.Redis, logging.Startup, "redis is not reachable, caching in memory: "+err.Error(), nil)
 	}
 
 	err = database.InitDb(cfg)
@@ -46,7 +55,43 @@ func main() {
 	if err != nil {
 		logger.Fatal(logging.Postgres, logging.Startup, err.Error(), nil)
 	}
-	migration.Up1()
+
+	go database.WatchReplicas(context.Background(), cfg.Postgres.ReplicaCheckInterval)
+
+	migrator, err := migration.NewMigrator(database.GetDb())
+	if err != nil {
+		logger.Fatal(logging.Postgres, logging.Migration, err.Error(), nil)
+	}
+	if _, err = migrator.Up(context.Background(), false); err != nil {
+		logger.Fatal(logging.Postgres, logging.Migration, err.Error(), nil)
+	}
+
+	if cfg.Seed.RunOnStartup {
+		if _, err = seed.NewSeeder(database.GetDb()).Run(context.Background(), cfg.Seed.Directory); err != nil {
+			logger.Fatal(logging.Postgres, logging.Seed, err.Error(), nil)
+		}
+	}
+
+	if err = seed.EnsureAdmin(context.Background(), database.GetDb(), cfg.Seed.Admin); err != nil {
+		logger.Fatal(logging.Postgres, logging.Seed, err.Error(), nil)
+	}
+
+	if cfg.Trash.RetentionDays > 0 {
+		go usecase.NewTrashRetentionUsecase(cfg, dependency.GetTrashRepositories(cfg)).Run(context.Background())
+	}
+
+	if cfg.Outbox.Enabled {
+		sinks, err := dependency.GetEventSinks(cfg)
+		if err != nil {
+			logger.Fatal(logging.Internal, logging.Outbox, err.Error(), nil)
+		}
+		if cfg.Webhook.Enabled {
+			subscriptions, deliveries := dependency.GetWebhookSubscriptionRepository(cfg), dependency.GetWebhookDeliveryRepository(cfg)
+			sinks = append(sinks, usecase.NewWebhookUsecase(cfg, subscriptions, deliveries, dependency.GetTransactionManager()))
+			go usecase.NewWebhookDispatcherUsecase(cfg, subscriptions, deliveries).Run(context.Background())
+		}
+		go usecase.NewOutboxRelayUsecase(cfg, dependency.GetOutboxRepository(cfg), sinks).Run(context.Background())
+	}
 
 	api.InitServer(cfg)
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"golang-clean-web-api/config"
+	database "golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/infra/persistence/migration"
+)
+
+const usage = `usage: migrate <command> [flags]
+
+commands:
+  up [-dry-run]                   apply all pending migrations
+  down -to <version> [-dry-run]   revert migrations newer than version
+  status                          list migrations and whether they are applied
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "print the plan without executing it")
+	toVersion := flags.Int("to", -1, "target version for down")
+	_ = flags.Parse(os.Args[2:])
+
+	cfg := config.GetConfig()
+	if err := database.InitDb(cfg); err != nil {
+		exit(err)
+	}
+	defer database.CloseDb()
+
+	migrator, err := migration.NewMigrator(database.GetDb())
+	if err != nil {
+		exit(err)
+	}
+
+	ctx := context.Background()
+	switch os.Args[1] {
+	case "up":
+		migrations, err := migrator.Up(ctx, *dryRun)
+		printPlan("up", migrations, *dryRun)
+		if err != nil {
+			exit(err)
+		}
+	case "down":
+		if *toVersion < 0 {
+			exit(fmt.Errorf("down requires -to <version>, use -to 0 to revert everything"))
+		}
+		migrations, err := migrator.Down(ctx, *toVersion, *dryRun)
+		printPlan("down", migrations, *dryRun)
+		if err != nil {
+			exit(err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			exit(err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.ChecksumMismatch {
+				state += " (checksum mismatch)"
+			}
+			if status.Unknown {
+				state += " (unknown to this build)"
+			}
+			fmt.Printf("%6d  %-30s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+}
+
+func printPlan(direction string, migrations []migration.Migration, dryRun bool) {
+	if len(migrations) == 0 {
+		fmt.Println("nothing to migrate")
+		return
+	}
+	for _, m := range migrations {
+		fmt.Printf("%s %d %s\n", direction, m.Version, m.Name)
+		if !dryRun {
+			continue
+		}
+		statements := m.UpSql
+		if direction == "down" {
+			statements = m.DownSql
+		}
+		if statements != "" {
+			fmt.Println(statements)
+		}
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
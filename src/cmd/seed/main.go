@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"golang-clean-web-api/config"
+	database "golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/infra/persistence/seed"
+)
+
+func main() {
+	cfg := config.GetConfig()
+	directory := flag.String("dir", cfg.Seed.Directory, "directory holding the fixture files")
+	flag.Parse()
+
+	if *directory == "" {
+		exit(fmt.Errorf("no fixture directory, set seed.directory in the config or pass -dir"))
+	}
+
+	if err := database.InitDb(cfg); err != nil {
+		exit(err)
+	}
+	defer database.CloseDb()
+
+	results, err := seed.NewSeeder(database.GetDb()).Run(context.Background(), *directory)
+	if err != nil {
+		exit(err)
+	}
+	if err := seed.EnsureAdmin(context.Background(), database.GetDb(), cfg.Seed.Admin); err != nil {
+		exit(err)
+	}
+	for _, result := range results {
+		fmt.Printf("%-12s created %d, updated %d, unchanged %d\n",
+			result.Entity, result.Created, result.Updated, result.Unchanged)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
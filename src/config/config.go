@@ -19,6 +19,14 @@ type Config struct {
 	Otp         OtpConfig
 	Jwt         JwtConfig
 	RateLimiter RateLimiterConfig
+	Seed        SeedConfig
+	Pagination  PaginationConfig
+	Trash       TrashConfig
+	Bulk        BulkConfig
+	Export      ExportConfig
+	Import      ImportConfig
+	Outbox      OutboxConfig
+	Webhook     WebhookConfig
 }
 
 type ServerConfig struct {
@@ -34,6 +42,27 @@ type LoggerConfig struct {
 }
 
 type PostgresConfig struct {
+	// Driver is postgres or sqlite, sqlite needs no server and ignores the connection fields
+	Driver string
+	// Path is the sqlite database file, :memory: keeps it in memory until shutdown
+	Path            string
+	Host            string
+	Port            string
+	User            string
+	Password        string
+	DbName          string
+	SSLMode         string
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+	// Replicas take the reads, unset fields are taken from the primary
+	Replicas []ReplicaConfig
+	// ReplicaCheckInterval is how often replicas are pinged, a failing one gets no reads.
+	// A replica that is down at startup gets reads once a check finds it back.
+	ReplicaCheckInterval time.Duration
+}
+
+type ReplicaConfig struct {
 	Host            string
 	Port            string
 	User            string
@@ -88,6 +117,74 @@ type RateLimiterConfig struct {
 	RequestsPerMin int
 }
 
+type SeedConfig struct {
+	Directory    string
+	RunOnStartup bool
+	// Admin is the user given the admin role on startup, created when missing
+	Admin AdminConfig
+}
+
+// AdminConfig bootstraps the first admin, nothing is done while Username is empty
+type AdminConfig struct {
+	Username string
+	// Password is only used to create the user, an existing one keeps its own
+	Password string
+	Email    string
+}
+
+type PaginationConfig struct {
+	CursorSecret string
+}
+
+type BulkConfig struct {
+	MaxOperations int
+}
+
+// ExportConfig sets up the downloads of the export endpoints
+type ExportConfig struct {
+	// BatchSize is how many rows are read from the database at a time
+	BatchSize int
+}
+
+// ImportConfig limits the uploads of the import endpoints
+type ImportConfig struct {
+	MaxRows int
+}
+
+// OutboxConfig sets up the relay that publishes the domain events of entity writes
+type OutboxConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxBackoff caps the wait before an event that failed to publish is retried
+	MaxBackoff time.Duration
+	// Sinks are log, redis and webhook, every event goes to each of them
+	Sinks          []string
+	RedisStream    string
+	RedisMaxLen    int64
+	WebhookUrl     string
+	WebhookTimeout time.Duration
+}
+
+// WebhookConfig sets up the delivery of domain events to webhook subscriptions.
+// Events reach the subscriptions through the outbox relay, which must be enabled too.
+type WebhookConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+	BatchSize    int
+	// Timeout is how long a receiver may take to answer
+	Timeout time.Duration
+	// MaxAttempts is how often a delivery is tried before it is dead
+	MaxAttempts int
+	// MaxBackoff caps the wait before a failed delivery is retried
+	MaxBackoff time.Duration
+}
+
+type TrashConfig struct {
+	RetentionDays int
+	PurgeInterval time.Duration
+}
+
 func GetConfig() *Config {
 	cfgPath := getConfigPath(os.Getenv("APP_ENV"))
 	v, err := LoadConfig(cfgPath, "yml")
@@ -126,6 +223,7 @@ func LoadConfig(filename string, fileType string) (*viper.Viper, error) {
 	v.AddConfigPath("./config")
 	v.AddConfigPath("../config")
 	v.AddConfigPath("../../config")
+	v.AddConfigPath("../../../config")
 	v.AutomaticEnv()
 
 	err := v.ReadInConfig()
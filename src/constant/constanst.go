@@ -9,7 +9,6 @@ const (
 
 	// Claims
 	AuthorizationHeaderKey string = "Authorization"
-	UserIdKey              string = "UserId"
 	FirstNameKey           string = "FirstName"
 	LastNameKey            string = "LastName"
 	UsernameKey            string = "Username"
@@ -20,4 +19,9 @@ const (
 
 	// JWT
 	RefreshTokenCookieName string = "refresh_token"
+
+	// Headers
+	RequestIdHeaderKey string = "X-Request-Id"
+	ETagHeaderKey      string = "ETag"
+	IfMatchHeaderKey   string = "If-Match"
 )
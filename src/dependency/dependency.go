@@ -1,29 +1,199 @@
 package dependency
 
 import (
+	"errors"
+	"fmt"
+	"sync"
+
 	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/event"
 	"golang-clean-web-api/domain/model"
 	contractRepository "golang-clean-web-api/domain/repository"
+	"golang-clean-web-api/infra/cache"
+	infraEvent "golang-clean-web-api/infra/event"
 	database "golang-clean-web-api/infra/persistence/database"
 	infraRepository "golang-clean-web-api/infra/persistence/repository"
+	"golang-clean-web-api/pkg/jwt"
+	"golang-clean-web-api/pkg/logging"
 )
 
-func GetCountryRepository(cfg *config.Config) contractRepository.CountryRepository {
-	var preloads []database.PreloadEntity = []database.PreloadEntity{{Entity: "Cities"}, {Entity: "Companies"}}
+// Container builds the repositories and services handlers and usecases depend on.
+// Tests swap it for in-memory fakes with Use, see the testkit package.
+type Container interface {
+	CountryRepository(cfg *config.Config) contractRepository.CountryRepository
+	CityRepository(cfg *config.Config) contractRepository.CityRepository
+	ColorRepository(cfg *config.Config) contractRepository.ColorRepository
+	CompanyRepository(cfg *config.Config) contractRepository.CompanyRepository
+	AuditRepository(cfg *config.Config) contractRepository.AuditRepository
+	UserRepository(cfg *config.Config) contractRepository.UserRepository
+	SearchRepository(cfg *config.Config) contractRepository.SearchRepository
+	OutboxRepository(cfg *config.Config) contractRepository.OutboxRepository
+	WebhookSubscriptionRepository(cfg *config.Config) contractRepository.WebhookSubscriptionRepository
+	WebhookDeliveryRepository(cfg *config.Config) contractRepository.WebhookDeliveryRepository
+	TransactionManager() contractRepository.TransactionManager
+	TokenService(cfg *config.Config) *jwt.TokenService
+	Cache() cache.Cache
+}
+
+var container Container = databaseContainer{}
+
+// Use builds every dependency from c until the returned function puts the previous
+// container back. Handlers take their dependencies when they are created, so call
+// it before registering routes.
+func Use(c Container) (restore func()) {
+	previous := container
+	container = c
+	return func() { container = previous }
+}
+
+// databaseContainer builds on the database, redis and the configured secrets
+type databaseContainer struct{}
+
+func (databaseContainer) CountryRepository(cfg *config.Config) contractRepository.CountryRepository {
+	var preloads []database.PreloadEntity = []database.PreloadEntity{{Entity: "Cities", OnDemand: true}, {Entity: "Companies", OnDemand: true}}
 	return infraRepository.NewBaseRepository[model.Country](cfg, preloads)
 }
 
-func GetCityRepository(cfg *config.Config) contractRepository.CityRepository {
+func (databaseContainer) CityRepository(cfg *config.Config) contractRepository.CityRepository {
 	var preloads []database.PreloadEntity = []database.PreloadEntity{{Entity: "Country"}}
 	return infraRepository.NewBaseRepository[model.City](cfg, preloads)
 }
 
-func GetColorRepository(cfg *config.Config) contractRepository.ColorRepository {
+func (databaseContainer) ColorRepository(cfg *config.Config) contractRepository.ColorRepository {
 	var preloads []database.PreloadEntity = []database.PreloadEntity{}
 	return infraRepository.NewBaseRepository[model.Color](cfg, preloads)
 }
 
-func GetCompanyRepository(cfg *config.Config) contractRepository.CompanyRepository {
+func (databaseContainer) CompanyRepository(cfg *config.Config) contractRepository.CompanyRepository {
 	var preloads []database.PreloadEntity = []database.PreloadEntity{{Entity: "Country"}}
 	return infraRepository.NewBaseRepository[model.Company](cfg, preloads)
 }
+
+func (databaseContainer) AuditRepository(cfg *config.Config) contractRepository.AuditRepository {
+	return infraRepository.NewBaseRepository[model.AuditLog](cfg, nil)
+}
+
+func (databaseContainer) UserRepository(cfg *config.Config) contractRepository.UserRepository {
+	return infraRepository.NewUserRepository(cfg)
+}
+
+func (databaseContainer) SearchRepository(cfg *config.Config) contractRepository.SearchRepository {
+	return infraRepository.NewSearchRepository(cfg)
+}
+
+func (databaseContainer) OutboxRepository(cfg *config.Config) contractRepository.OutboxRepository {
+	return infraRepository.NewOutboxRepository(cfg)
+}
+
+func (databaseContainer) WebhookSubscriptionRepository(cfg *config.Config) contractRepository.WebhookSubscriptionRepository {
+	return infraRepository.NewWebhookSubscriptionRepository(cfg)
+}
+
+func (databaseContainer) WebhookDeliveryRepository(cfg *config.Config) contractRepository.WebhookDeliveryRepository {
+	return infraRepository.NewWebhookDeliveryRepository(cfg)
+}
+
+func (databaseContainer) TransactionManager() contractRepository.TransactionManager {
+	return database.NewTransactionManager(database.GetDb())
+}
+
+func (databaseContainer) TokenService(cfg *config.Config) *jwt.TokenService {
+	return jwt.NewTokenService(cfg)
+}
+
+// memoryCache stands in for redis when the sqlite mode runs without it
+var memoryCache = sync.OnceValue(cache.NewMemoryCache)
+
+func (databaseContainer) Cache() cache.Cache {
+	if cache.GetRedis() == nil {
+		return memoryCache()
+	}
+	return cache.NewRedisCache(cache.GetRedis())
+}
+
+func GetCountryRepository(cfg *config.Config) contractRepository.CountryRepository {
+	return container.CountryRepository(cfg)
+}
+
+func GetCityRepository(cfg *config.Config) contractRepository.CityRepository {
+	return container.CityRepository(cfg)
+}
+
+func GetColorRepository(cfg *config.Config) contractRepository.ColorRepository {
+	return container.ColorRepository(cfg)
+}
+
+func GetCompanyRepository(cfg *config.Config) contractRepository.CompanyRepository {
+	return container.CompanyRepository(cfg)
+}
+
+func GetTransactionManager() contractRepository.TransactionManager {
+	return container.TransactionManager()
+}
+
+func GetAuditRepository(cfg *config.Config) contractRepository.AuditRepository {
+	return container.AuditRepository(cfg)
+}
+
+func GetUserRepository(cfg *config.Config) contractRepository.UserRepository {
+	return container.UserRepository(cfg)
+}
+
+func GetSearchRepository(cfg *config.Config) contractRepository.SearchRepository {
+	return container.SearchRepository(cfg)
+}
+
+func GetOutboxRepository(cfg *config.Config) contractRepository.OutboxRepository {
+	return container.OutboxRepository(cfg)
+}
+
+func GetWebhookSubscriptionRepository(cfg *config.Config) contractRepository.WebhookSubscriptionRepository {
+	return container.WebhookSubscriptionRepository(cfg)
+}
+
+func GetWebhookDeliveryRepository(cfg *config.Config) contractRepository.WebhookDeliveryRepository {
+	return container.WebhookDeliveryRepository(cfg)
+}
+
+// GetEventSinks builds the sinks the outbox relay publishes to, in the configured order
+func GetEventSinks(cfg *config.Config) ([]event.Sink, error) {
+	sinks := make([]event.Sink, 0, len(cfg.Outbox.Sinks))
+	for _, name := range cfg.Outbox.Sinks {
+		switch name {
+		case "log":
+			sinks = append(sinks, infraEvent.NewLogSink(logging.NewLogger(cfg)))
+		case "redis":
+			if cache.GetRedis() == nil {
+				return nil, errors.New("outbox redis sink needs redis, which is not connected")
+			}
+			sinks = append(sinks, infraEvent.NewRedisStreamSink(cache.GetRedis(), cfg.Outbox.RedisStream, cfg.Outbox.RedisMaxLen))
+		case "webhook":
+			if cfg.Outbox.WebhookUrl == "" {
+				return nil, errors.New("outbox webhook sink needs a webhookUrl")
+			}
+			sinks = append(sinks, infraEvent.NewWebhookSink(cfg.Outbox.WebhookUrl, cfg.Outbox.WebhookTimeout))
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+func GetTokenService(cfg *config.Config) *jwt.TokenService {
+	return container.TokenService(cfg)
+}
+
+func GetCache() cache.Cache {
+	return container.Cache()
+}
+
+// GetTrashRepositories lists the repositories purged by the trash retention,
+// dependents before the rows they reference
+func GetTrashRepositories(cfg *config.Config) []contractRepository.TrashRepository {
+	return []contractRepository.TrashRepository{
+		GetCityRepository(cfg),
+		GetCompanyRepository(cfg),
+		GetCountryRepository(cfg),
+		GetColorRepository(cfg),
+	}
+}
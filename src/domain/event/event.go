@@ -0,0 +1,30 @@
+// Package event describes the domain events the outbox relay publishes and the
+// sinks it publishes them to
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Event is a change of an entity as downstream services receive it. Delivery is
+// at least once, consumers drop the ids they have already seen. The events of one
+// aggregate arrive in the order they happened.
+type Event struct {
+	Id            int             `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateId   int             `json:"aggregateId"`
+	Payload       json.RawMessage `json:"payload"`
+	UserId        int             `json:"userId"`
+	RequestId     string          `json:"requestId,omitempty"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+}
+
+// Sink delivers events to downstream services. Publish returns once the event is
+// accepted, an error has it retried later.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event Event) error
+}
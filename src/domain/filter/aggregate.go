@@ -0,0 +1,126 @@
+package filter
+
+import "strings"
+
+const (
+	defaultAggregateLimit = 100
+	maxAggregateLimit     = 1000
+	// MaxGroupBy limits the number of fields rows are grouped by
+	MaxGroupBy = 5
+	// MaxAggregates limits the number of values computed per group
+	MaxAggregates = 10
+)
+
+// Aggregate functions. Sum and avg need a numeric field, count without a field
+// counts the rows of a group.
+const (
+	AggregateCount = "count"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+	AggregateSum   = "sum"
+	AggregateAvg   = "avg"
+)
+
+var aggregateFunctions = map[string]bool{
+	AggregateCount: true, AggregateMin: true, AggregateMax: true, AggregateSum: true, AggregateAvg: true,
+}
+
+// Aggregate is a function over a field of the rows of a group
+//
+//	{"function": "count"}
+//	{"function": "max", "field": "Version", "alias": "latest"}
+type Aggregate struct {
+	Function string `json:"function"`
+	Field    string `json:"field,omitempty"`
+	// Alias names the value in result rows, by default the function and the
+	// field, e.g. maxVersion, or just count
+	Alias string `json:"alias,omitempty"`
+}
+
+// Name is the key of the value in result rows
+func (a Aggregate) Name() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+	if a.Field == "" {
+		return a.Function
+	}
+	name := a.Function
+	for _, segment := range strings.Split(a.Field, ".") {
+		name += strings.ToUpper(segment[:1]) + segment[1:]
+	}
+	return name
+}
+
+// AggregateInput groups the rows the filter matches by the GroupBy fields and
+// computes the Aggregates of every group. Without GroupBy the result is a single
+// row over all matching rows. Sort may name group-by fields and aggregate names,
+// groups come in group-by order otherwise.
+//
+//	{"filter": {...}, "groupBy": ["Country.Name"], "aggregates": [{"function": "count"}],
+//	 "sort": [{"colId": "count", "sort": "desc"}], "limit": 10}
+type AggregateInput struct {
+	DynamicFilter
+	GroupBy    []string    `json:"groupBy"`
+	Aggregates []Aggregate `json:"aggregates"`
+	Limit      int         `json:"limit"`
+}
+
+func (a *AggregateInput) GetLimit() int {
+	if a.Limit == 0 {
+		a.Limit = defaultAggregateLimit
+	}
+	return min(a.Limit, maxAggregateLimit)
+}
+
+// Validate checks the shape of the request. Whether the fields exist and are
+// numeric is up to the model, see database.GenerateAggregate.
+func (a *AggregateInput) Validate() error {
+	if len(a.Aggregates) == 0 {
+		return invalidFilter("at least one aggregate is required")
+	}
+	if len(a.Aggregates) > MaxAggregates {
+		return invalidFilter("at most %d aggregates are allowed", MaxAggregates)
+	}
+	if len(a.GroupBy) > MaxGroupBy {
+		return invalidFilter("at most %d group by fields are allowed", MaxGroupBy)
+	}
+	if a.Limit < 0 {
+		return invalidFilter("limit must be positive")
+	}
+	for _, field := range a.GroupBy {
+		if !fieldPathExp.MatchString(field) {
+			return invalidFilter("invalid group by field %q", field)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, aggregate := range a.Aggregates {
+		if !aggregateFunctions[aggregate.Function] {
+			return invalidFilter("unknown aggregate function %q", aggregate.Function)
+		}
+		if aggregate.Field == "" && aggregate.Function != AggregateCount {
+			return invalidFilter("%s needs a field", aggregate.Function)
+		}
+		if aggregate.Field != "" && !fieldPathExp.MatchString(aggregate.Field) {
+			return invalidFilter("invalid aggregate field %q", aggregate.Field)
+		}
+		if aggregate.Alias != "" && (!fieldPathExp.MatchString(aggregate.Alias) || strings.Contains(aggregate.Alias, ".")) {
+			return invalidFilter("invalid aggregate alias %q", aggregate.Alias)
+		}
+		name := aggregate.Name()
+		if names[name] {
+			return invalidFilter("aggregate %s is computed twice, give it an alias", name)
+		}
+		names[name] = true
+	}
+	return nil
+}
+
+// AggregateRow is one group: the values of its group-by fields, keyed by field
+// path, and of its aggregates, keyed by Aggregate.Name. Counts are int64, sums
+// and averages float64, min and max have the type of the field.
+type AggregateRow struct {
+	Group  map[string]interface{}
+	Values map[string]interface{}
+}
@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type asOfKey struct{}
+
+// NewAsOfContext attaches the time to read the entities as of, for reads that
+// take no request body. Nil reads the current entities.
+func NewAsOfContext(ctx context.Context, asOf *time.Time) context.Context {
+	return context.WithValue(ctx, asOfKey{}, asOf)
+}
+
+// AsOfFromContext returns the attached time, nil when there is none
+func AsOfFromContext(ctx context.Context) *time.Time {
+	asOf, _ := ctx.Value(asOfKey{}).(*time.Time)
+	return asOf
+}
+
+// ParseAsOf reads asOf=2024-03-01T12:00:00Z, an RFC 3339 time, from the query string
+func ParseAsOf(values url.Values) (*time.Time, error) {
+	raw := values.Get("asOf")
+	if raw == "" {
+		return nil, nil
+	}
+	asOf, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return nil, &QueryError{Parameter: "asOf", Value: raw, Message: "expected an RFC 3339 time like 2024-03-01T12:00:00Z"}
+	}
+	asOf = asOf.UTC()
+	return &asOf, nil
+}
+
+// AsOfProjection checks a read as of the time. Only the entity itself keeps its
+// past versions, its relations would be read as they are now, so they cannot be
+// included, filtered or sorted by, and are not loaded by default. It returns the
+// projection to read with, unchanged when the time is nil.
+func AsOfProjection(at *time.Time, projection *Projection, f *DynamicFilter) (*Projection, error) {
+	if at == nil {
+		return projection, nil
+	}
+	if len(projection.Include) > 0 {
+		return nil, invalidFilter("relations cannot be included as of a time")
+	}
+	if f != nil {
+		for field := range f.Filter {
+			if err := currentOnly(field); err != nil {
+				return nil, err
+			}
+		}
+		if f.Sort != nil {
+			for _, sort := range *f.Sort {
+				if err := currentOnly(sort.ColId); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if f.Where != nil {
+			if err := f.Where.walk(currentOnly); err != nil {
+				return nil, err
+			}
+		}
+	}
+	past := *projection
+	past.Include = []string{}
+	return &past, nil
+}
+
+// currentOnly rejects a field path through a relation
+func currentOnly(field string) error {
+	if strings.Contains(field, ".") {
+		return invalidFilter("%s is a field of a relation, which cannot be read as of a time", field)
+	}
+	return nil
+}
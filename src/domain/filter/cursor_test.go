@@ -0,0 +1,51 @@
+package filter
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang-clean-web-api/pkg/service_errors"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	secret := []byte("secret")
+	cursor := Cursor{Sort: "name desc,id asc", Values: []json.RawMessage{json.RawMessage(`"Iran"`), json.RawMessage(`3`)}, Backward: true}
+
+	token, err := EncodeCursor(secret, cursor)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	decoded, err := DecodeCursor(secret, token, "name desc,id asc")
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if !decoded.Backward || len(decoded.Values) != 2 || string(decoded.Values[0]) != `"Iran"` {
+		t.Errorf("Expected %+v, got %+v", cursor, decoded)
+	}
+}
+
+func TestCursor_Rejected(t *testing.T) {
+	secret := []byte("secret")
+	token, _ := EncodeCursor(secret, Cursor{Sort: "id asc", Values: []json.RawMessage{json.RawMessage(`3`)}})
+	encoded, _, _ := strings.Cut(token, ".")
+	forged, _ := EncodeCursor([]byte("other"), Cursor{Sort: "id asc", Values: []json.RawMessage{json.RawMessage(`30`)}})
+
+	cases := map[string]struct {
+		token string
+		sort  string
+	}{
+		"garbage":        {"not-a-cursor", "id asc"},
+		"unsigned":       {encoded, "id asc"},
+		"other secret":   {forged, "id asc"},
+		"different sort": {token, "name asc,id asc"},
+	}
+	for name, c := range cases {
+		_, err := DecodeCursor(secret, c.token, c.sort)
+		serviceError := &service_errors.ServiceError{}
+		if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidFilter {
+			t.Errorf("%s: expected an invalid filter error, got %v", name, err)
+		}
+	}
+}
@@ -7,9 +7,12 @@ type Sort struct {
 
 type Filter struct {
 	// contains notContains equals notEqual startsWith lessThan lessThanOrEqual greaterThan greaterThanOrEqual inRange endsWith
+	// in notIn isNull isNotNull
 	Type string `json:"type"`
 	From string `json:"from"`
 	To   string `json:"to"`
+	// Values used by in and notIn
+	Values []string `json:"values,omitempty"`
 	// text number
 	FilterType string `json:"filterType"`
 }
@@ -17,4 +20,17 @@ type Filter struct {
 type DynamicFilter struct {
 	Sort   *[]Sort           `json:"sort"`
 	Filter map[string]Filter `json:"filter"`
+	// Where is an expression tree that is AND-joined with Filter
+	Where *Expression `json:"where,omitempty"`
+	// Search matches the words of the text, or a close spelling, in the search fields
+	// of the entity. Results come by relevance unless sorted.
+	Search string `json:"search,omitempty"`
+}
+
+// AndWhere narrows the filter with conditions that the client cannot lift
+func (f *DynamicFilter) AndWhere(conditions ...Expression) {
+	if f.Where != nil {
+		conditions = append([]Expression{*f.Where}, conditions...)
+	}
+	f.Where = &Expression{And: conditions}
 }
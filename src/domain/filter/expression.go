@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"fmt"
+
+	"golang-clean-web-api/pkg/service_errors"
+)
+
+const (
+	// MaxExpressionDepth limits how deep groups can be nested
+	MaxExpressionDepth = 5
+	// MaxExpressionConditions limits the number of conditions in one expression tree
+	MaxExpressionConditions = 30
+	// MaxExpressionValues limits the number of values of an in or notIn condition
+	MaxExpressionValues = 100
+)
+
+// Expression is a node of a filter tree. A node is either a group, exactly one
+// of And, Or and Not, or a condition on Field using the embedded Filter.
+//
+//	{"or": [{"field": "Name", "type": "startsWith", "from": "I"},
+//	        {"not": {"field": "Id", "type": "in", "values": ["1", "2"]}}]}
+type Expression struct {
+	And   []Expression `json:"and,omitempty"`
+	Or    []Expression `json:"or,omitempty"`
+	Not   *Expression  `json:"not,omitempty"`
+	Field string       `json:"field,omitempty"`
+	Filter
+}
+
+var conditionTypes = map[string]bool{
+	"contains": true, "notContains": true, "startsWith": true, "endsWith": true,
+	"equals": true, "notEqual": true,
+	"lessThan": true, "lessThanOrEqual": true, "greaterThan": true, "greaterThanOrEqual": true,
+	"inRange": true, "in": true, "notIn": true, "isNull": true, "isNotNull": true,
+}
+
+// IsGroup reports whether the node combines other nodes
+func (e *Expression) IsGroup() bool {
+	return e.And != nil || e.Or != nil || e.Not != nil
+}
+
+// Validate checks the shape of the tree and the depth and complexity limits
+func (e *Expression) Validate() error {
+	conditions := 0
+	return e.validate(1, &conditions)
+}
+
+func (e *Expression) validate(depth int, conditions *int) error {
+	if depth > MaxExpressionDepth {
+		return invalidFilter("expression is nested deeper than %d levels", MaxExpressionDepth)
+	}
+
+	parts := 0
+	for _, set := range []bool{e.And != nil, e.Or != nil, e.Not != nil, e.Field != ""} {
+		if set {
+			parts++
+		}
+	}
+	if parts != 1 {
+		return invalidFilter("expression node must have exactly one of and, or, not or field")
+	}
+
+	switch {
+	case e.And != nil || e.Or != nil:
+		children := e.And
+		if e.Or != nil {
+			children = e.Or
+		}
+		if len(children) == 0 {
+			return invalidFilter("empty expression group")
+		}
+		for i := range children {
+			if err := children[i].validate(depth+1, conditions); err != nil {
+				return err
+			}
+		}
+	case e.Not != nil:
+		return e.Not.validate(depth+1, conditions)
+	default:
+		*conditions++
+		if *conditions > MaxExpressionConditions {
+			return invalidFilter("expression has more than %d conditions", MaxExpressionConditions)
+		}
+		if !conditionTypes[e.Type] {
+			return invalidFilter("unknown filter type %q on %s", e.Type, e.Field)
+		}
+		if (e.Type == "in" || e.Type == "notIn") && (len(e.Values) == 0 || len(e.Values) > MaxExpressionValues) {
+			return invalidFilter("%s on %s needs between 1 and %d values", e.Type, e.Field, MaxExpressionValues)
+		}
+	}
+	return nil
+}
+
+// walk calls fn with the field of every condition in the tree, until fn fails
+func (e *Expression) walk(fn func(field string) error) error {
+	if e.Field != "" {
+		if err := fn(e.Field); err != nil {
+			return err
+		}
+	}
+	for _, group := range [][]Expression{e.And, e.Or} {
+		for i := range group {
+			if err := group[i].walk(fn); err != nil {
+				return err
+			}
+		}
+	}
+	if e.Not != nil {
+		return e.Not.walk(fn)
+	}
+	return nil
+}
+
+func invalidFilter(format string, args ...interface{}) error {
+	return Invalid("filter", format, args...)
+}
+
+// Invalid rejects a list parameter the rows cannot be read with. The reason is
+// kept as a QueryError, so the response names it next to InvalidFilter.
+func Invalid(parameter string, format string, args ...interface{}) error {
+	reason := fmt.Sprintf(format, args...)
+	return &service_errors.ServiceError{
+		EndUserMessage:   service_errors.InvalidFilter,
+		TechnicalMessage: reason,
+		Err:              &QueryError{Parameter: parameter, Message: reason},
+	}
+}
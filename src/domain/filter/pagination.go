@@ -2,6 +2,7 @@ package filter
 
 import (
 	"math"
+	"time"
 
 	"golang-clean-web-api/common"
 )
@@ -32,6 +33,39 @@ func Paginate[TInput any, TOutput any](totalRows int64, items *[]TInput, pageNum
 
 }
 
+// Cursors are the bounds of a keyset page. A cursor is empty when there is no
+// page in that direction, TotalRows is nil unless the count was requested.
+type Cursors struct {
+	Next      string
+	Previous  string
+	TotalRows *int64
+}
+
+func NewCursorPagedList[T any](items *[]T, cursors *Cursors, pageSize int64) *PagedList[T] {
+	pl := &PagedList[T]{
+		PageSize:        pageSize,
+		Items:           items,
+		NextCursor:      cursors.Next,
+		PreviousCursor:  cursors.Previous,
+		HasNextPage:     cursors.Next != "",
+		HasPreviousPage: cursors.Previous != "",
+	}
+	if cursors.TotalRows != nil {
+		pl.TotalRows = *cursors.TotalRows
+		pl.TotalPages = int(math.Ceil(float64(pl.TotalRows) / float64(pageSize)))
+	}
+	return pl
+}
+
+// PaginateCursor
+func PaginateCursor[TInput any, TOutput any](cursors *Cursors, items *[]TInput, pageSize int64) (*PagedList[TOutput], error) {
+	rItems, err := common.TypeConverter[[]TOutput](items)
+	if err != nil {
+		return nil, err
+	}
+	return NewCursorPagedList(&rItems, cursors, pageSize), nil
+}
+
 type PagedList[T any] struct {
 	PageNumber      int   `json:"pageNumber"`
 	PageSize        int64 `json:"pageSize"`
@@ -40,16 +74,32 @@ type PagedList[T any] struct {
 	HasPreviousPage bool  `json:"hasPreviousPage"`
 	HasNextPage     bool  `json:"hasNextPage"`
 	Items           *[]T  `json:"items"`
+	// Set in cursor mode only
+	NextCursor     string `json:"nextCursor,omitempty"`
+	PreviousCursor string `json:"previousCursor,omitempty"`
 }
 
 type PaginationInput struct {
 	PageSize   int `json:"pageSize"`
 	PageNumber int `json:"pageNumber"`
+	// CursorMode pages by sort keys instead of offset; Cursor continues from a
+	// previous page and implies cursor mode. The total is counted only WithTotal.
+	CursorMode bool   `json:"cursorMode,omitempty"`
+	Cursor     string `json:"cursor,omitempty"`
+	WithTotal  bool   `json:"withTotal,omitempty"`
+}
+
+func (p *PaginationInput) IsCursorMode() bool {
+	return p.CursorMode || p.Cursor != ""
 }
 
 type PaginationInputWithFilter struct {
 	PaginationInput
 	DynamicFilter
+	Projection
+	// AsOf reads the entities as they were at the time, deleted ones included when
+	// they were not deleted yet. Nil reads the current entities.
+	AsOf *time.Time `json:"asOf,omitempty"`
 }
 
 func (p *PaginationInputWithFilter) GetOffset() int {
@@ -0,0 +1,62 @@
+package filter
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// Projection narrows a response to some fields and relations. Nil keeps the
+// defaults of the entity, an empty Include loads no relations at all.
+type Projection struct {
+	Fields  []string `json:"fields,omitempty"`
+	Include []string `json:"include,omitempty"`
+}
+
+type projectionKey struct{}
+
+// NewProjectionContext attaches the projection for reads that take no request body
+func NewProjectionContext(ctx context.Context, projection *Projection) context.Context {
+	return context.WithValue(ctx, projectionKey{}, projection)
+}
+
+// ProjectionFromContext returns the attached projection, or the default one
+func ProjectionFromContext(ctx context.Context) *Projection {
+	if projection, ok := ctx.Value(projectionKey{}).(*Projection); ok && projection != nil {
+		return projection
+	}
+	return &Projection{}
+}
+
+// ParseProjection reads fields=id,name and include=country from the query string.
+// A bare include= asks for no relations.
+func ParseProjection(values url.Values) (*Projection, error) {
+	projection := &Projection{}
+	var err error
+	if raw := values.Get("fields"); raw != "" {
+		if projection.Fields, err = parseList("fields", raw); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := values["include"]; ok {
+		projection.Include = []string{}
+		if raw := values.Get("include"); raw != "" {
+			if projection.Include, err = parseList("include", raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return projection, nil
+}
+
+func parseList(parameter string, raw string) ([]string, error) {
+	items := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if !fieldPathExp.MatchString(item) {
+			return nil, &QueryError{Parameter: parameter, Value: raw, Message: "expected a comma separated list of names"}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
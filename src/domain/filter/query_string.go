@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const valueSeparator = "|"
+
+var fieldPathExp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// QueryError reports a malformed query string parameter
+type QueryError struct {
+	Parameter string
+	Value     string
+	Message   string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s %q: %s", e.Parameter, e.Value, e.Message)
+}
+
+// ParseQuery reads a list request from the query string:
+//
+//	filter=name:startsWith:Ir   repeatable, conditions are AND-joined
+//	filter=id:in:1|2|3          in and notIn take values separated by |
+//	filter=id:inRange:1|10      inRange takes from|to
+//	filter=modifiedBy:isNull    isNull and isNotNull take no value
+//	sort=-name,id               comma separated, - sorts descending
+//	search=tehran               full-text and fuzzy match, by relevance unless sorted
+//	page=2&pageSize=20
+//	cursorMode=true&withTotal=true   keyset pagination, count only on request
+//	cursor=<nextCursor>              continues a keyset page
+//	fields=id,name&include=country   see ParseProjection
+//	asOf=2024-03-01T12:00:00Z        the entities as they were then, see ParseAsOf
+func ParseQuery(values url.Values) (*PaginationInputWithFilter, error) {
+	req := &PaginationInputWithFilter{}
+
+	var err error
+	if req.PageNumber, err = parsePositive(values, "page"); err != nil {
+		return nil, err
+	}
+	if req.PageSize, err = parsePositive(values, "pageSize"); err != nil {
+		return nil, err
+	}
+	if req.CursorMode, err = parseBool(values, "cursorMode"); err != nil {
+		return nil, err
+	}
+	if req.WithTotal, err = parseBool(values, "withTotal"); err != nil {
+		return nil, err
+	}
+	req.Cursor = values.Get("cursor")
+	req.Search = strings.TrimSpace(values.Get("search"))
+
+	projection, err := ParseProjection(values)
+	if err != nil {
+		return nil, err
+	}
+	req.Projection = *projection
+	if req.AsOf, err = ParseAsOf(values); err != nil {
+		return nil, err
+	}
+
+	conditions := []Expression{}
+	for _, raw := range values["filter"] {
+		condition, err := parseCondition(raw)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	if len(conditions) > 0 {
+		req.Where = &Expression{And: conditions}
+	}
+
+	if raw := values.Get("sort"); raw != "" {
+		sorts := []Sort{}
+		for _, item := range strings.Split(raw, ",") {
+			item = strings.TrimSpace(item)
+			direction := "asc"
+			if strings.HasPrefix(item, "-") {
+				direction = "desc"
+				item = item[1:]
+			}
+			if !fieldPathExp.MatchString(item) {
+				return nil, &QueryError{Parameter: "sort", Value: raw, Message: "expected a comma separated list of fields, prefix a field with - to sort descending"}
+			}
+			sorts = append(sorts, Sort{ColId: item, Sort: direction})
+		}
+		req.Sort = &sorts
+	}
+	return req, nil
+}
+
+func parseCondition(raw string) (Expression, error) {
+	parts := strings.SplitN(raw, ":", 3)
+	if len(parts) < 2 {
+		return Expression{}, &QueryError{Parameter: "filter", Value: raw, Message: "expected field:type:value"}
+	}
+
+	field, conditionType := parts[0], parts[1]
+	if !fieldPathExp.MatchString(field) {
+		return Expression{}, &QueryError{Parameter: "filter", Value: raw, Message: "invalid field name"}
+	}
+	if !conditionTypes[conditionType] {
+		return Expression{}, &QueryError{Parameter: "filter", Value: raw, Message: fmt.Sprintf("unknown filter type %q", conditionType)}
+	}
+
+	condition := Expression{Field: field, Filter: Filter{Type: conditionType}}
+	switch conditionType {
+	case "isNull", "isNotNull":
+		if len(parts) == 3 {
+			return Expression{}, &QueryError{Parameter: "filter", Value: raw, Message: conditionType + " takes no value"}
+		}
+		return condition, nil
+	}
+
+	if len(parts) < 3 || parts[2] == "" {
+		return Expression{}, &QueryError{Parameter: "filter", Value: raw, Message: "missing value, expected field:type:value"}
+	}
+	value := parts[2]
+	switch conditionType {
+	case "in", "notIn":
+		condition.Values = strings.Split(value, valueSeparator)
+	case "inRange":
+		bounds := strings.Split(value, valueSeparator)
+		if len(bounds) != 2 {
+			return Expression{}, &QueryError{Parameter: "filter", Value: raw, Message: "inRange expects from|to"}
+		}
+		condition.From, condition.To = bounds[0], bounds[1]
+	default:
+		condition.From = value
+	}
+	return condition, nil
+}
+
+func parsePositive(values url.Values, name string) (int, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, &QueryError{Parameter: name, Value: raw, Message: "expected a positive number"}
+	}
+	return value, nil
+}
+
+func parseBool(values url.Values, name string) (bool, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, &QueryError{Parameter: name, Value: raw, Message: "expected true or false"}
+	}
+	return value, nil
+}
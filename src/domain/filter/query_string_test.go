@@ -0,0 +1,107 @@
+package filter
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	values, _ := url.ParseQuery("filter=name:startsWith:Ir&filter=id:in:1|2&filter=createdAt:greaterThan:2024-01-01T10:00:00Z" +
+		"&filter=modifiedBy:isNull&sort=-name,id&page=2&pageSize=20&fields=id,name&include=&search=+tehran+" +
+		"&asOf=2024-03-01T12:00:00%2B03:30")
+
+	req, err := ParseQuery(values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if req.PageNumber != 2 || req.PageSize != 20 {
+		t.Errorf("Expected page 2 of size 20, got %d of size %d", req.PageNumber, req.PageSize)
+	}
+	if req.Search != "tehran" {
+		t.Errorf("Expected search tehran, got %q", req.Search)
+	}
+	if expected := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC); req.AsOf == nil || !req.AsOf.Equal(expected) || req.AsOf.Location() != time.UTC {
+		t.Errorf("Expected as of %s, got %v", expected, req.AsOf)
+	}
+
+	expected := &Expression{And: []Expression{
+		{Field: "name", Filter: Filter{Type: "startsWith", From: "Ir"}},
+		{Field: "id", Filter: Filter{Type: "in", Values: []string{"1", "2"}}},
+		{Field: "createdAt", Filter: Filter{Type: "greaterThan", From: "2024-01-01T10:00:00Z"}},
+		{Field: "modifiedBy", Filter: Filter{Type: "isNull"}},
+	}}
+	if !reflect.DeepEqual(req.Where, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, req.Where)
+	}
+
+	expectedSort := &[]Sort{{ColId: "name", Sort: "desc"}, {ColId: "id", Sort: "asc"}}
+	if !reflect.DeepEqual(req.Sort, expectedSort) {
+		t.Errorf("Expected %+v, got %+v", expectedSort, req.Sort)
+	}
+
+	expectedProjection := Projection{Fields: []string{"id", "name"}, Include: []string{}}
+	if !reflect.DeepEqual(req.Projection, expectedProjection) {
+		t.Errorf("Expected %+v, got %+v", expectedProjection, req.Projection)
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	cases := map[string]string{
+		"no type":         "filter=name",
+		"unknown type":    "filter=name:like:Ir",
+		"missing value":   "filter=name:equals",
+		"bad field":       "filter=na%20me:equals:x",
+		"value on isNull": "filter=name:isNull:x",
+		"bad range":       "filter=id:inRange:1",
+		"bad sort":        "sort=name,,id",
+		"bad page":        "page=0",
+		"bad page size":   "pageSize=ten",
+		"bad cursor mode": "cursorMode=yes please",
+		"bad fields":      "fields=id,,name",
+		"bad as of":       "asOf=2024-03-01",
+	}
+	for name, query := range cases {
+		t.Run(name, func(t *testing.T) {
+			values, _ := url.ParseQuery(query)
+			_, err := ParseQuery(values)
+			var queryError *QueryError
+			if !errors.As(err, &queryError) {
+				t.Errorf("Expected a query error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseSearch(t *testing.T) {
+	values, _ := url.ParseQuery("q=+new+york+&types=country,city&limit=5&search=x")
+	input, err := ParseSearch(values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &SearchInput{Query: "new york", Types: []string{"country", "city"}, Limit: 5}
+	if !reflect.DeepEqual(input, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, input)
+	}
+
+	values, _ = url.ParseQuery("q=te&type=city&types=country")
+	input, err = ParseSuggest(values)
+	if err != nil || !reflect.DeepEqual(input.Types, []string{"city"}) {
+		t.Errorf("Expected suggestions of cities, got %+v %v", input, err)
+	}
+
+	for _, query := range []string{"q=", "q=x&limit=0", "q=x&types=a,,b", "q=x&type=Country.Name"} {
+		values, _ := url.ParseQuery(query)
+		parse := ParseSearch
+		if strings.Contains(query, "type=") {
+			parse = ParseSuggest
+		}
+		var queryError *QueryError
+		if _, err := parse(values); !errors.As(err, &queryError) {
+			t.Errorf("Expected a query error for %s, got %v", query, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package filter
+
+import (
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchInput is a search across the searchable entity types
+type SearchInput struct {
+	Query string
+	// Types narrows the search to some entity types, all of them when empty
+	Types []string
+	Limit int
+}
+
+func (s *SearchInput) GetLimit() int {
+	if s.Limit == 0 {
+		s.Limit = defaultSearchLimit
+	}
+	return min(s.Limit, maxSearchLimit)
+}
+
+// SearchHit is an entity that matches a search. Highlight is the name with the
+// matched words between <b> and </b>, empty for suggestions.
+type SearchHit struct {
+	Type      string
+	Id        int
+	Name      string
+	Rank      float64
+	Highlight string
+}
+
+// ParseSearch reads a search from the query string:
+//
+//	q=tehran                the text, required
+//	types=country,city      comma separated entity types, all when missing
+//	limit=10                at most 100 hits, 20 when missing
+func ParseSearch(values url.Values) (*SearchInput, error) {
+	input := &SearchInput{Query: strings.TrimSpace(values.Get("q"))}
+	if input.Query == "" {
+		return nil, &QueryError{Parameter: "q", Value: values.Get("q"), Message: "expected the text to search"}
+	}
+	var err error
+	if raw := values.Get("types"); raw != "" {
+		if input.Types, err = parseList("types", raw); err != nil {
+			return nil, err
+		}
+	}
+	if input.Limit, err = parsePositive(values, "limit"); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+// ParseSuggest reads a typeahead request: q=teh&type=city&limit=10. The type is
+// required, suggestions of different types would mix their ids.
+func ParseSuggest(values url.Values) (*SearchInput, error) {
+	input, err := ParseSearch(values)
+	if err != nil {
+		return nil, err
+	}
+	name := values.Get("type")
+	if !fieldPathExp.MatchString(name) || strings.Contains(name, ".") {
+		return nil, &QueryError{Parameter: "type", Value: name, Message: "expected the entity type to suggest"}
+	}
+	input.Types = []string{name}
+	return input, nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// AuditLog is one write to an entity, kept for good. EntityType is the table of
+// the entity and Changes the json encoded []FieldChange.
+type AuditLog struct {
+	Id         int       `gorm:"primarykey"`
+	EntityType string    `gorm:"size:50;not null;index:ix_audit_logs_entity,priority:1"`
+	EntityId   int       `gorm:"not null;index:ix_audit_logs_entity,priority:2"`
+	Action     string    `gorm:"size:10;not null"`
+	UserId     int       `gorm:"not null;index"`
+	Username   string    `gorm:"size:50"`
+	RequestId  string    `gorm:"size:64"`
+	CreatedAt  time.Time `gorm:"not null;index"`
+	Changes    string    `gorm:"type:text;not null"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// FieldChange is the value of a field before and after a write, nil when absent
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// SelectFields lists the columns clients may request with fields=
+func (AuditLog) SelectFields() []string {
+	return []string{"Id", "EntityType", "EntityId", "Action", "UserId", "Username", "RequestId", "CreatedAt", "Changes"}
+}
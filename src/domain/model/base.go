@@ -34,3 +34,79 @@ type File struct {
 	Description string `gorm:"size:500;type:string;not null"`
 	MimeType    string `gorm:"size:20;type:string;not null"`
 }
+
+// FilterRelations lists the relations clients may filter and sort on
+func (Country) FilterRelations() []string {
+	return []string{"Cities", "Companies"}
+}
+
+func (City) FilterRelations() []string {
+	return []string{"Country"}
+}
+
+func (Company) FilterRelations() []string {
+	return []string{"Country"}
+}
+
+// SelectFields lists the columns clients may request with fields=
+func (Country) SelectFields() []string {
+	return []string{"Id", "Name"}
+}
+
+func (City) SelectFields() []string {
+	return []string{"Id", "Name", "CountryId"}
+}
+
+func (Company) SelectFields() []string {
+	return []string{"Id", "Name", "CountryId"}
+}
+
+func (Color) SelectFields() []string {
+	return []string{"Id", "Name", "HexCode"}
+}
+
+// SearchFields lists the columns search matches, the first one names the entity
+// in results. The search migration indexes them.
+func (Country) SearchFields() []string {
+	return []string{"Name"}
+}
+
+func (City) SearchFields() []string {
+	return []string{"Name"}
+}
+
+func (Company) SearchFields() []string {
+	return []string{"Name"}
+}
+
+func (Color) SearchFields() []string {
+	return []string{"Name", "HexCode"}
+}
+
+// NaturalKey names the field that identifies reference data for upserts. It must
+// be backed by a unique index over the rows that are not deleted.
+func (Country) NaturalKey() string {
+	return "Name"
+}
+
+func (Color) NaturalKey() string {
+	return "Name"
+}
+
+// HistoryTable names the table that keeps the past versions of the rows, for
+// reads as of a time. The history migration creates it.
+func (Country) HistoryTable() string {
+	return "countries_history"
+}
+
+func (City) HistoryTable() string {
+	return "cities_history"
+}
+
+func (Company) HistoryTable() string {
+	return "companies_history"
+}
+
+func (Color) HistoryTable() string {
+	return "colors_history"
+}
@@ -4,26 +4,30 @@ import (
 	"database/sql"
 	"time"
 
+	"golang-clean-web-api/pkg/identity"
+
 	"gorm.io/gorm"
 )
 
 type BaseModel struct {
 	Id int `gorm:"primarykey"`
 
-	CreatedAt  time.Time    `gorm:"type:TIMESTAMP with time zone;not null"`
-	ModifiedAt sql.NullTime `gorm:"type:TIMESTAMP with time zone;null"`
-	DeletedAt  sql.NullTime `gorm:"type:TIMESTAMP with time zone;null"`
+	CreatedAt  time.Time    `gorm:"not null"`
+	ModifiedAt sql.NullTime `gorm:"null"`
+	DeletedAt  sql.NullTime `gorm:"null"`
 
 	CreatedBy  int            `gorm:"not null"`
 	ModifiedBy *sql.NullInt64 `gorm:"null"`
 	DeletedBy  *sql.NullInt64 `gorm:"null"`
+
+	// Version counts the updates of the row, see repository.BaseRepository.Update
+	Version int `gorm:"not null;default:1"`
 }
 
 func (m *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
-	value := tx.Statement.Context.Value("UserId")
 	var userId = -1
-	if value != nil {
-		userId = int(value.(float64))
+	if value, ok := identity.UserId(tx.Statement.Context); ok {
+		userId = value
 	}
 	m.CreatedAt = time.Now().UTC()
 	m.CreatedBy = userId
@@ -31,23 +35,20 @@ func (m *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
 }
 
 func (m *BaseModel) BeforeUpdate(tx *gorm.DB) (err error) {
-	value := tx.Statement.Context.Value("UserId")
-	var userId = &sql.NullInt64{Valid: false}
-	if value != nil {
-		userId = &sql.NullInt64{Valid: true, Int64: int64(value.(float64))}
-	}
 	m.ModifiedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
-	m.ModifiedBy = userId
+	m.ModifiedBy = currentUser(tx)
 	return
 }
 
 func (m *BaseModel) BeforeDelete(tx *gorm.DB) (err error) {
-	value := tx.Statement.Context.Value("UserId")
-	var userId = &sql.NullInt64{Valid: false}
-	if value != nil {
-		userId = &sql.NullInt64{Valid: true, Int64: int64(value.(float64))}
-	}
 	m.DeletedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
-	m.DeletedBy = userId
+	m.DeletedBy = currentUser(tx)
 	return
 }
+
+func currentUser(tx *gorm.DB) *sql.NullInt64 {
+	if value, ok := identity.UserId(tx.Statement.Context); ok {
+		return &sql.NullInt64{Valid: true, Int64: int64(value)}
+	}
+	return &sql.NullInt64{Valid: false}
+}
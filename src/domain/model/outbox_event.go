@@ -0,0 +1,46 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Domain event types recorded for entity writes
+const (
+	EntityCreated = "EntityCreated"
+	EntityUpdated = "EntityUpdated"
+	EntityDeleted = "EntityDeleted"
+)
+
+// AuditEvents names the event each audited action records. A purge removes a row
+// that was already announced as deleted and records none.
+var AuditEvents = map[string]string{
+	AuditCreate:  EntityCreated,
+	AuditUpdate:  EntityUpdated,
+	AuditRestore: EntityUpdated,
+	AuditDelete:  EntityDeleted,
+}
+
+// OutboxEvent is a domain event written in the transaction of the change it
+// describes and published afterwards by the outbox relay. AggregateType is the
+// table of the entity and Payload the json encoded row after the write, or
+// before it for deletes. An event is pending until PublishedAt is set, failed
+// attempts push NextAttemptAt back.
+type OutboxEvent struct {
+	Id            int          `gorm:"primarykey"`
+	EventType     string       `gorm:"size:30;not null"`
+	AggregateType string       `gorm:"size:50;not null;index:ix_outbox_events_aggregate,priority:1"`
+	AggregateId   int          `gorm:"not null;index:ix_outbox_events_aggregate,priority:2"`
+	Payload       string       `gorm:"type:text;not null"`
+	UserId        int          `gorm:"not null"`
+	RequestId     string       `gorm:"size:64"`
+	CreatedAt     time.Time    `gorm:"not null"`
+	PublishedAt   sql.NullTime `gorm:"null;index"`
+	Attempts      int          `gorm:"not null;default:0"`
+	NextAttemptAt time.Time    `gorm:"not null"`
+	LastError     string       `gorm:"size:500"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
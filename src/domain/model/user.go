@@ -1,13 +1,27 @@
 package model
 
+import "strings"
+
 type User struct {
 	BaseModel
 	Username string `gorm:"size:50;not null;unique"`
 	Password string `gorm:"size:255;not null"`
 	Email    string `gorm:"size:100;unique"`
 	IsActive bool   `gorm:"default:true"`
+	Roles    string `gorm:"size:200;not null;default:''"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
+
+// RoleList splits the comma separated roles, e.g. "admin,default"
+func (u User) RoleList() []string {
+	roles := []string{}
+	for _, role := range strings.Split(u.Roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
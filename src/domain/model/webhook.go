@@ -0,0 +1,76 @@
+package model
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Webhook delivery states. A pending delivery is retried until it is delivered
+// or runs out of attempts and is dead, only a replay sends a dead one again.
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookDead      = "dead"
+)
+
+// WebhookPing is the event type of the test deliveries a subscription is sent on demand
+const WebhookPing = "Ping"
+
+// WebhookSubscription sends the domain events of some entities to a partner url,
+// signed with its secret. EntityTypes are comma separated tables and EventTypes
+// comma separated event types, empty matches every one.
+type WebhookSubscription struct {
+	BaseModel
+	Url         string `gorm:"size:500;type:string;not null"`
+	EntityTypes string `gorm:"size:200;type:string;not null"`
+	EventTypes  string `gorm:"size:100;type:string;not null"`
+	Secret      string `gorm:"size:100;type:string;not null"`
+	Active      bool   `gorm:"not null"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Matches tells whether the subscription wants the event
+func (s WebhookSubscription) Matches(entityType string, eventType string) bool {
+	return s.Active && matchesList(s.EntityTypes, entityType) && matchesList(s.EventTypes, eventType)
+}
+
+func matchesList(list string, value string) bool {
+	return list == "" || slices.Contains(strings.Split(list, ","), value)
+}
+
+// SecretFields lists the columns written to neither audit records nor domain events
+func (WebhookSubscription) SecretFields() []string {
+	return []string{"Secret"}
+}
+
+// SelectFields lists the columns clients may request with fields=
+func (WebhookSubscription) SelectFields() []string {
+	return []string{"Id", "Url", "EntityTypes", "EventTypes", "Active"}
+}
+
+// WebhookDelivery is one event sent to one subscription, a log of the attempts
+// made so far. Payload is the signed json body, ResponseCode and LastError tell
+// how the last attempt went. EventId is the outbox event, null for pings.
+type WebhookDelivery struct {
+	Id             int           `gorm:"primarykey"`
+	SubscriptionId int           `gorm:"not null;uniqueIndex:ux_webhook_deliveries_event,priority:1"`
+	EventId        sql.NullInt64 `gorm:"null;uniqueIndex:ux_webhook_deliveries_event,priority:2"`
+	EventType      string        `gorm:"size:30;not null"`
+	Payload        string        `gorm:"type:text;not null"`
+	Status         string        `gorm:"size:10;not null;index:ix_webhook_deliveries_due,priority:1"`
+	Attempts       int           `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time     `gorm:"not null;index:ix_webhook_deliveries_due,priority:2"`
+	ResponseCode   int           `gorm:"not null;default:0"`
+	LastError      string        `gorm:"size:500"`
+	CreatedAt      time.Time     `gorm:"not null"`
+	DeliveredAt    sql.NullTime  `gorm:"null"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"golang-clean-web-api/domain/filter"
 	"golang-clean-web-api/domain/model"
@@ -9,11 +10,89 @@ import (
 
 type BaseRepository[TEntity any] interface {
 	Create(ctx context.Context, entity TEntity) (TEntity, error)
+	CreateMany(ctx context.Context, entities []TEntity) ([]TEntity, error)
+	Upsert(ctx context.Context, entity TEntity) (TEntity, bool, error)
 	Update(ctx context.Context, id int, entity map[string]interface{}) (TEntity, error)
 	Delete(ctx context.Context, id int) error
 	GetById(ctx context.Context, id int) (TEntity, error)
 	GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]TEntity, error)
+	GetByCursor(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.Cursors, *[]TEntity, error)
+	GetDeleted(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]TEntity, error)
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, id int) error
+	GetHistory(ctx context.Context, id int, req filter.PaginationInputWithFilter) (int64, *[]model.AuditLog, error)
+	Aggregate(ctx context.Context, input filter.AggregateInput) ([]filter.AggregateRow, error)
+	TrashRepository
 }
+
+// TrashRepository purges rows that have been soft deleted for longer than the retention
+type TrashRepository interface {
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TransactionManager makes several repository calls atomic. Repositories called
+// with the context handed to fn join its transaction.
+type TransactionManager interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// AuditRepository reads the audit records written by every entity repository
+type AuditRepository interface {
+	GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]model.AuditLog, error)
+}
+
+// OutboxRepository hands the domain events recorded with every entity write to
+// the relay that publishes them
+type OutboxRepository interface {
+	// Lock makes the caller the only relay until it calls unlock, ok is false while
+	// another instance relays. Events of an aggregate only go out in order when a
+	// single relay publishes them.
+	Lock(ctx context.Context) (unlock func(), ok bool, err error)
+	// Pending returns the oldest unpublished event of every aggregate that is due
+	// at now, so the events of an aggregate go out in order
+	Pending(ctx context.Context, now time.Time, limit int) ([]model.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id int, at time.Time) error
+	// MarkFailed counts a failed attempt and holds the event back until next
+	MarkFailed(ctx context.Context, id int, next time.Time, reason string) error
+}
+
+// WebhookSubscriptionRepository keeps the subscriptions of partners to domain events
+type WebhookSubscriptionRepository interface {
+	BaseRepository[model.WebhookSubscription]
+	// GetActive returns the active subscriptions that are not deleted
+	GetActive(ctx context.Context) ([]model.WebhookSubscription, error)
+}
+
+// WebhookDeliveryRepository keeps every event sent to a subscription with the
+// outcome of its last attempt
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error)
+	// Enqueue adds the deliveries of an event, skipping the subscriptions that
+	// already have it, so an event published twice is delivered once
+	Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error
+	GetById(ctx context.Context, id int) (model.WebhookDelivery, error)
+	GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]model.WebhookDelivery, error)
+	// Due returns the pending deliveries whose next attempt is due at now, oldest first
+	Due(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error)
+	// Save writes the state of a delivery after an attempt or a replay
+	Save(ctx context.Context, delivery model.WebhookDelivery) error
+}
+
+// UserRepository finds and registers the users that sign in
+type UserRepository interface {
+	GetByUsername(ctx context.Context, username string) (model.User, error)
+	GetById(ctx context.Context, id int) (model.User, error)
+	Create(ctx context.Context, user model.User) (model.User, error)
+}
+
+// SearchRepository finds entities of several types by text. Hits come most
+// relevant first.
+type SearchRepository interface {
+	Search(ctx context.Context, input filter.SearchInput) ([]filter.SearchHit, error)
+	// Suggest finds the entities whose name starts with the text, for typeahead
+	Suggest(ctx context.Context, input filter.SearchInput) ([]filter.SearchHit, error)
+}
+
 type CountryRepository interface {
 	BaseRepository[model.Country]
 }
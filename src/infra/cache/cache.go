@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// ErrNotFound is returned by Get for a key that is missing or has expired
+var ErrNotFound = errors.New("cache: key not found")
+
+// Cache keeps values by key until their duration runs out
+type Cache interface {
+	Set(key string, value []byte, duration time.Duration) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+type redisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache returns a Cache over the redis client
+func NewRedisCache(client *redis.Client) Cache {
+	return &redisCache{client: client}
+}
+
+func (c *redisCache) Set(key string, value []byte, duration time.Duration) error {
+	return c.client.Set(key, value, duration).Err()
+}
+
+func (c *redisCache) Get(key string) ([]byte, error) {
+	value, err := c.client.Get(key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (c *redisCache) Delete(key string) error {
+	return c.client.Del(key).Err()
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryCache keeps the entries in the process, for running without redis. It
+// is not shared between instances and is lost on restart.
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	evicted time.Time
+}
+
+// evictInterval is how often Set drops the expired entries
+const evictInterval = time.Minute
+
+type memoryEntry struct {
+	value []byte
+	// expires is zero for entries that never expire
+	expires time.Time
+}
+
+// NewMemoryCache returns an empty in-process Cache
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: map[string]memoryEntry{}}
+}
+
+func (c *memoryCache) Set(key string, value []byte, duration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict(time.Now())
+	entry := memoryEntry{value: append([]byte(nil), value...)}
+	if duration > 0 {
+		entry.expires = time.Now().Add(duration)
+	}
+	c.entries[key] = entry
+	return nil
+}
+
+func (c *memoryCache) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || entry.expired(time.Now()) {
+		delete(c.entries, key)
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), entry.value...), nil
+}
+
+func (c *memoryCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
+
+// evict drops the expired entries now and then, so keys that are never read
+// again do not pile up
+func (c *memoryCache) evict(now time.Time) {
+	if now.Sub(c.evicted) < evictInterval {
+		return
+	}
+	c.evicted = now
+	for key, entry := range c.entries {
+		if entry.expired(now) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
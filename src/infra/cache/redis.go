@@ -12,8 +12,9 @@ import (
 
 var redisClient *redis.Client
 
+// InitRedis connects to redis. On error no client is kept, GetRedis stays nil.
 func InitRedis(cfg *config.Config) error {
-	redisClient = redis.NewClient(&redis.Options{
+	client := redis.NewClient(&redis.Options{
 		Addr:               fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port),
 		Password:           cfg.Redis.Password,
 		DB:                 0,
@@ -26,36 +27,41 @@ func InitRedis(cfg *config.Config) error {
 		IdleCheckFrequency: cfg.Redis.IdleCheckFrequency * time.Millisecond,
 	})
 
-	_, err := redisClient.Ping().Result()
+	_, err := client.Ping().Result()
 	if err != nil {
+		client.Close()
 		return err
 	}
+	redisClient = client
 	return nil
 }
 
+// GetRedis returns the client, nil when running without redis
 func GetRedis() *redis.Client {
 	return redisClient
 }
 
 func CloseRedis() {
-	redisClient.Close()
+	if redisClient != nil {
+		redisClient.Close()
+	}
 }
 
-func Set[T any](c *redis.Client, key string, value T, duration time.Duration) error {
+func Set[T any](c Cache, key string, value T, duration time.Duration) error {
 	v, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
-	return c.Set(key, v, duration).Err()
+	return c.Set(key, v, duration)
 }
 
-func Get[T any](c *redis.Client, key string) (T, error) {
+func Get[T any](c Cache, key string) (T, error) {
 	var dest T = *new(T)
-	v, err := c.Get(key).Result()
+	v, err := c.Get(key)
 	if err != nil {
 		return dest, err
 	}
-	err = json.Unmarshal([]byte(v), &dest)
+	err = json.Unmarshal(v, &dest)
 	if err != nil {
 		return dest, err
 	}
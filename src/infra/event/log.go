@@ -0,0 +1,32 @@
+package event
+
+import (
+	"context"
+	"fmt"
+
+	"golang-clean-web-api/domain/event"
+	"golang-clean-web-api/pkg/logging"
+)
+
+// LogSink writes every event to the application log, for development and for
+// deployments nothing consumes events in yet
+type LogSink struct {
+	logger logging.Logger
+}
+
+func NewLogSink(logger logging.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Publish(ctx context.Context, e event.Event) error {
+	s.logger.Info(logging.Internal, logging.Outbox, string(e.Payload), map[logging.ExtraKey]interface{}{
+		logging.EventId:   e.Id,
+		logging.EventType: e.Type,
+		logging.Aggregate: fmt.Sprintf("%s/%d", e.AggregateType, e.AggregateId),
+	})
+	return nil
+}
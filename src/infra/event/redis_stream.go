@@ -0,0 +1,47 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"golang-clean-web-api/domain/event"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// RedisStreamSink appends events to a redis stream. The stream is trimmed to about
+// maxLen entries, zero keeps all of them.
+type RedisStreamSink struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+func NewRedisStreamSink(client *redis.Client, stream string, maxLen int64) *RedisStreamSink {
+	return &RedisStreamSink{client: client, stream: stream, maxLen: maxLen}
+}
+
+func (s *RedisStreamSink) Name() string {
+	return "redis"
+}
+
+// Publish adds an entry with the event as json, and its id, type and aggregate as
+// fields of their own so consumers can route without decoding
+func (s *RedisStreamSink) Publish(ctx context.Context, e event.Event) error {
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.client.WithContext(ctx).XAdd(&redis.XAddArgs{
+		Stream:       s.stream,
+		MaxLenApprox: s.maxLen,
+		Values: map[string]interface{}{
+			"id":            strconv.Itoa(e.Id),
+			"type":          e.Type,
+			"aggregateType": e.AggregateType,
+			"aggregateId":   strconv.Itoa(e.AggregateId),
+			"event":         string(encoded),
+		},
+	}).Err()
+}
@@ -0,0 +1,59 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang-clean-web-api/domain/event"
+)
+
+const (
+	EventIdHeader   = "X-Event-Id"
+	EventTypeHeader = "X-Event-Type"
+)
+
+// WebhookSink posts every event as json to one url. Any status but 2xx, or no
+// answer within the timeout, fails the delivery.
+type WebhookSink struct {
+	client *http.Client
+	url    string
+}
+
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{client: &http.Client{Timeout: timeout}, url: url}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, e event.Event) error {
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIdHeader, strconv.Itoa(e.Id))
+	req.Header.Set(EventTypeHeader, e.Type)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook %s answered %d", s.url, res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	filter "golang-clean-web-api/domain/filter"
+)
+
+// Aggregation is the select list, grouping and order of an aggregate request.
+// Every selected expression has an alias, g0.. for group-by fields and a0.. for
+// aggregates, Rows reads them back under the names the client asked for.
+type Aggregation struct {
+	Select  string
+	GroupBy string
+	Order   string
+	columns []aggregateColumn
+}
+
+type aggregateColumn struct {
+	alias string
+	name  string
+	group bool
+	// function is empty for group-by fields
+	function string
+	// field is nil when count counts rows
+	field *schema.Field
+}
+
+// GenerateAggregate validates the group-by and aggregate fields against the model
+// and builds the sql that computes the groups. Fields may be dotted paths through
+// the belongs-to and has-one relations the model allows to filter on. Sum and avg
+// need a numeric field, and so do min and max.
+func GenerateAggregate[T any](db *gorm.DB, input *filter.AggregateInput) (*Aggregation, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	b, err := newQueryBuilder[T](db)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregation := &Aggregation{}
+	selects := []string{}
+	groups := []string{}
+	for i, name := range input.GroupBy {
+		path, column, err := b.aggregateColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		alias := fmt.Sprintf("g%d", i)
+		selects = append(selects, fmt.Sprintf("%s AS %s", column, alias))
+		groups = append(groups, column)
+		aggregation.columns = append(aggregation.columns,
+			aggregateColumn{alias: alias, name: name, group: true, field: path.field})
+	}
+	for i, aggregate := range input.Aggregates {
+		alias := fmt.Sprintf("a%d", i)
+		column := aggregateColumn{alias: alias, name: aggregate.Name(), function: aggregate.Function}
+		if aggregate.Field == "" {
+			selects = append(selects, fmt.Sprintf("COUNT(*) AS %s", alias))
+			aggregation.columns = append(aggregation.columns, column)
+			continue
+		}
+		path, expression, err := b.aggregateColumn(aggregate.Field)
+		if err != nil {
+			return nil, err
+		}
+		if aggregate.Function != filter.AggregateCount && !numeric(path.field) {
+			return nil, invalidFilter("%s needs a numeric field, %s is not", aggregate.Function, aggregate.Field)
+		}
+		column.field = path.field
+		selects = append(selects, fmt.Sprintf("%s(%s) AS %s", strings.ToUpper(aggregate.Function), expression, alias))
+		aggregation.columns = append(aggregation.columns, column)
+	}
+	aggregation.Select = strings.Join(selects, ", ")
+	aggregation.GroupBy = strings.Join(groups, ", ")
+
+	order := []string{}
+	if input.Sort != nil {
+		for _, tp := range *input.Sort {
+			if tp.Sort != "asc" && tp.Sort != "desc" {
+				return nil, invalidFilter("sort on %s must be asc or desc", tp.ColId)
+			}
+			column, ok := aggregation.find(tp.ColId)
+			if !ok {
+				return nil, invalidFilter("cannot sort on %s, it is neither grouped by nor aggregated", tp.ColId)
+			}
+			order = append(order, fmt.Sprintf("%s %s", column.alias, tp.Sort))
+		}
+	}
+	if len(order) == 0 {
+		for _, column := range aggregation.columns {
+			if column.group {
+				order = append(order, column.alias)
+			}
+		}
+	}
+	aggregation.Order = strings.Join(order, ", ")
+	return aggregation, nil
+}
+
+// aggregateColumn resolves a field path to the expression that reads it for every row
+func (b *queryBuilder) aggregateColumn(name string) (fieldPath, string, error) {
+	path, err := b.resolve(name)
+	if errors.Is(err, errUnknownField) {
+		return path, "", invalidFilter("unknown field %s", name)
+	}
+	if err != nil {
+		return path, "", err
+	}
+	column, ok := b.sortColumn(b.schema.Table, path.relations, path.field)
+	if !ok {
+		return path, "", invalidFilter("cannot aggregate %s through a has-many relation", name)
+	}
+	return path, column, nil
+}
+
+func (a *Aggregation) find(name string) (aggregateColumn, bool) {
+	for _, column := range a.columns {
+		if strings.EqualFold(column.name, name) {
+			return column, true
+		}
+	}
+	return aggregateColumn{}, false
+}
+
+// Rows converts the scanned groups into typed rows. Drivers differ in what they
+// return for numeric results, postgres reads sums and averages as decimal text.
+func (a *Aggregation) Rows(scanned []map[string]interface{}) ([]filter.AggregateRow, error) {
+	rows := make([]filter.AggregateRow, 0, len(scanned))
+	for _, row := range scanned {
+		result := filter.AggregateRow{Group: map[string]interface{}{}, Values: map[string]interface{}{}}
+		for _, column := range a.columns {
+			value, err := column.convert(row[column.alias])
+			if err != nil {
+				return nil, fmt.Errorf("aggregate %s: %w", column.name, err)
+			}
+			if column.group {
+				result.Group[column.name] = value
+			} else {
+				result.Values[column.name] = value
+			}
+		}
+		rows = append(rows, result)
+	}
+	return rows, nil
+}
+
+func (c aggregateColumn) convert(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch {
+	case c.function == filter.AggregateCount:
+		return toInt64(value)
+	case c.function == filter.AggregateSum || c.function == filter.AggregateAvg:
+		return toFloat64(value)
+	case c.field.DataType == schema.Int || c.field.DataType == schema.Uint:
+		return toInt64(value)
+	case c.field.DataType == schema.Float:
+		return toFloat64(value)
+	case c.field.DataType == schema.String:
+		if raw, ok := value.([]byte); ok {
+			return string(raw), nil
+		}
+	}
+	return value, nil
+}
+
+func numeric(field *schema.Field) bool {
+	return field.DataType == schema.Int || field.DataType == schema.Uint || field.DataType == schema.Float
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("unexpected %T", value)
+}
+
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("unexpected %T", value)
+}
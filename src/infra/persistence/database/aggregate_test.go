@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/service_errors"
+)
+
+func TestGenerateAggregate(t *testing.T) {
+	input := filter.AggregateInput{
+		GroupBy: []string{"country.name", "CountryId"},
+		Aggregates: []filter.Aggregate{
+			{Function: "count"},
+			{Function: "sum", Field: "version", Alias: "edits"},
+		},
+		DynamicFilter: filter.DynamicFilter{Sort: &[]filter.Sort{{ColId: "edits", Sort: "desc"}}},
+	}
+	aggregation, err := GenerateAggregate[model.City](newPostgresDb(t), &input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	country := "(SELECT r1.name FROM countries r1 WHERE r1.id = cities.country_id LIMIT 1)"
+	if expected := country + " AS g0, cities.country_id AS g1, COUNT(*) AS a0, SUM(cities.version) AS a1"; aggregation.Select != expected {
+		t.Errorf("Unexpected select %q", aggregation.Select)
+	}
+	if aggregation.GroupBy != country+", cities.country_id" || aggregation.Order != "a1 desc" {
+		t.Errorf("Unexpected grouping %q and order %q", aggregation.GroupBy, aggregation.Order)
+	}
+
+	rows, err := aggregation.Rows([]map[string]interface{}{{"g0": "Iran", "g1": int32(1), "a0": int64(3), "a1": "7"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	row := rows[0]
+	if row.Group["country.name"] != "Iran" || row.Group["CountryId"] != int64(1) ||
+		row.Values["count"] != int64(3) || row.Values["edits"] != 7.0 {
+		t.Errorf("Unexpected row %v", row)
+	}
+}
+
+func TestGenerateAggregate_Invalid(t *testing.T) {
+	count := []filter.Aggregate{{Function: "count"}}
+	for name, input := range map[string]filter.AggregateInput{
+		"no aggregate":         {GroupBy: []string{"Name"}},
+		"unknown function":     {Aggregates: []filter.Aggregate{{Function: "median", Field: "Id"}}},
+		"missing field":        {Aggregates: []filter.Aggregate{{Function: "sum"}}},
+		"text field":           {Aggregates: []filter.Aggregate{{Function: "max", Field: "Name"}}},
+		"unknown field":        {Aggregates: count, GroupBy: []string{"Population"}},
+		"relation not allowed": {Aggregates: count, GroupBy: []string{"Country.Cities.Name"}},
+		"duplicate name":       {Aggregates: []filter.Aggregate{{Function: "count"}, {Function: "count"}}},
+		"unknown sort": {Aggregates: count, GroupBy: []string{"Name"},
+			DynamicFilter: filter.DynamicFilter{Sort: &[]filter.Sort{{ColId: "Id", Sort: "asc"}}}},
+	} {
+		_, err := GenerateAggregate[model.City](newTestDb(t), &input)
+		var serviceError *service_errors.ServiceError
+		if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidFilter {
+			t.Errorf("%s: expected an invalid filter error, got %v", name, err)
+		}
+	}
+}
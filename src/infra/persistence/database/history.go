@@ -0,0 +1,150 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// historyTable is implemented by models that keep the past versions of their rows
+type historyTable interface {
+	HistoryTable() string
+}
+
+// The period a version of a row was current, from inclusive to exclusive
+const (
+	ValidFromColumn = "valid_from"
+	ValidToColumn   = "valid_to"
+)
+
+// validSince is when the current version of a row began: its last write. Updates
+// and restores set modified_at, deletes set deleted_at only.
+const validSince = "COALESCE(deleted_at, modified_at, created_at)"
+
+// History is the history table of a model and the columns it shares with it
+type History struct {
+	Table   string
+	History string
+	Columns []string
+}
+
+// NewHistory returns the history of the model, false when it keeps none
+func NewHistory(db *gorm.DB, model interface{}) (*History, bool, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, false, err
+	}
+	tabled, ok := model.(historyTable)
+	if !ok {
+		return nil, false, nil
+	}
+	history := &History{Table: stmt.Schema.Table, History: tabled.HistoryTable(), Columns: historyColumns(stmt.Schema)}
+	// the history of a searchable table has its own generated search vector
+	if _, searchable := model.(searchFields); searchable && db.Dialector.Name() == DriverPostgres {
+		history.Columns = append(history.Columns, SearchVectorColumn)
+	}
+	return history, true, nil
+}
+
+// CreateHistoryTable creates the history table of the model: its columns without
+// keys or constraints, a version of a row is there once per period, and the
+// period it was current. It is indexed for looking up a row at a time.
+func CreateHistoryTable(db *gorm.DB, model interface{}) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	tabled, ok := model.(historyTable)
+	if !ok {
+		return fmt.Errorf("%s keeps no history", stmt.Schema.Table)
+	}
+	table := tabled.HistoryTable()
+	if db.Migrator().HasTable(table) {
+		return nil
+	}
+
+	timestamp := ""
+	columns := []string{}
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		// the id is copied, not generated
+		column := *field
+		column.AutoIncrement = false
+		dataType := db.Dialector.DataTypeOf(&column)
+		if field.DBName == "created_at" {
+			timestamp = dataType
+		}
+		columns = append(columns, field.DBName+" "+dataType)
+	}
+	if timestamp == "" {
+		return fmt.Errorf("%s has no created_at column to date its versions", stmt.Schema.Table)
+	}
+	columns = append(columns,
+		fmt.Sprintf("%s %s NOT NULL", ValidFromColumn, timestamp),
+		fmt.Sprintf("%s %s NOT NULL", ValidToColumn, timestamp))
+
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (%s)", table, strings.Join(columns, ", ")),
+		fmt.Sprintf("CREATE INDEX ix_%[1]s_id ON %[1]s (id, %[2]s)", table, ValidToColumn),
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateArchive copies the rows of the where clause into the history table, as
+// versions current from their last write until the time given, the time of the
+// write that replaces them. It is nil for models without history.
+func GenerateArchive[T any](db *gorm.DB, until time.Time, query string, args ...interface{}) (*clause.Expr, error) {
+	history, ok, err := NewHistory(db, new(T))
+	if err != nil || !ok {
+		return nil, err
+	}
+	columns := strings.Join(history.Columns, ", ")
+	return &clause.Expr{
+		SQL: fmt.Sprintf("INSERT INTO %s (%s, %s, %s) SELECT %s, %s, ? FROM %s WHERE %s",
+			history.History, columns, ValidFromColumn, ValidToColumn, columns, validSince, history.Table, query),
+		Vars: append([]interface{}{until}, args...),
+	}, nil
+}
+
+// GenerateAsOf returns the rows of the model as they were at the time, deleted
+// ones included, as a derived table named like the model table so filters and
+// sorts read it the same way. A row written after the time is there as the
+// version in its history that was current then, rows created after it are not.
+func GenerateAsOf[T any](db *gorm.DB, at time.Time) (*clause.Expr, error) {
+	history, ok, err := NewHistory(db, new(T))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, invalidFilter("%T keeps no history to read as of a time", *new(T))
+	}
+	columns := strings.Join(history.Columns, ", ")
+	return &clause.Expr{
+		SQL: fmt.Sprintf("(SELECT %[1]s FROM %[2]s WHERE %[3]s <= ? UNION ALL "+
+			"SELECT %[1]s FROM %[4]s WHERE %[5]s <= ? AND %[6]s > ?) AS %[2]s",
+			columns, history.Table, validSince, history.History, ValidFromColumn, ValidToColumn),
+		Vars: []interface{}{at, at, at},
+	}, nil
+}
+
+// historyColumns lists the columns of a schema a history table copies
+func historyColumns(s *schema.Schema) []string {
+	columns := []string{}
+	for _, field := range s.Fields {
+		if field.DBName != "" {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	filter "golang-clean-web-api/domain/filter"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// Keyset orders a query by the requested sort followed by the primary key, and
+// continues it after a row by comparing those keys. Keys must be non-nullable
+// columns of the entity itself, a NULL would drop out of the comparison.
+type Keyset struct {
+	table string
+	keys  []keysetKey
+}
+
+type keysetKey struct {
+	field *schema.Field
+	desc  bool
+}
+
+func NewKeyset[T any](db *gorm.DB, f *filter.DynamicFilter) (*Keyset, error) {
+	b, err := newQueryBuilder[T](db)
+	if err != nil {
+		return nil, err
+	}
+
+	keyset := &Keyset{table: b.schema.Table}
+	seen := map[string]bool{}
+	if f.Sort != nil {
+		for _, tp := range *f.Sort {
+			if tp.Sort != "asc" && tp.Sort != "desc" {
+				continue
+			}
+			path, err := b.resolve(tp.ColId)
+			if errors.Is(err, errUnknownField) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(path.relations) > 0 {
+				return nil, invalidFilter("cursor pagination cannot sort on the related field %s", tp.ColId)
+			}
+			if nullable(path.field) {
+				return nil, invalidFilter("cursor pagination cannot sort on the nullable field %s", tp.ColId)
+			}
+			if seen[path.field.DBName] {
+				continue
+			}
+			seen[path.field.DBName] = true
+			keyset.keys = append(keyset.keys, keysetKey{field: path.field, desc: tp.Sort == "desc"})
+		}
+	}
+
+	primary := b.schema.PrioritizedPrimaryField
+	if primary == nil {
+		return nil, fmt.Errorf("cursor pagination needs a primary key on %s", b.schema.Table)
+	}
+	if !seen[primary.DBName] {
+		keyset.keys = append(keyset.keys, keysetKey{field: primary})
+	}
+	return keyset, nil
+}
+
+func nullable(field *schema.Field) bool {
+	return field.FieldType.Kind() == reflect.Ptr || reflect.PointerTo(field.FieldType).Implements(scannerType)
+}
+
+// Fingerprint identifies the key order, cursors are only valid for the same one
+func (k *Keyset) Fingerprint() string {
+	keys := make([]string, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key.field.DBName+" "+direction(key.desc))
+	}
+	return strings.Join(keys, ",")
+}
+
+// Order builds the order by clause, reversed when reading backward
+func (k *Keyset) Order(backward bool) string {
+	order := make([]string, 0, len(k.keys))
+	for _, key := range k.keys {
+		order = append(order, fmt.Sprintf("%s.%s %s", k.table, key.field.DBName, direction(key.desc != backward)))
+	}
+	return strings.Join(order, ", ")
+}
+
+// Condition selects the rows after the cursor values, or before them when reading
+// backward: (a > ?) OR (a = ? AND b > ?) OR ...
+func (k *Keyset) Condition(values []json.RawMessage, backward bool) (string, []interface{}, error) {
+	if len(values) != len(k.keys) {
+		return "", nil, filter.Invalid("cursor", "cursor does not match the sort keys")
+	}
+	parsed := make([]interface{}, len(values))
+	for i, key := range k.keys {
+		value := reflect.New(key.field.FieldType)
+		if err := json.Unmarshal(values[i], value.Interface()); err != nil {
+			return "", nil, invalidFilter("cursor value of %s is malformed", key.field.Name)
+		}
+		parsed[i] = value.Elem().Interface()
+	}
+
+	branches := make([]string, 0, len(k.keys))
+	args := make([]interface{}, 0)
+	for i, key := range k.keys {
+		conditions := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, fmt.Sprintf("%s.%s = ?", k.table, k.keys[j].field.DBName))
+			args = append(args, parsed[j])
+		}
+		operator := ">"
+		if key.desc != backward {
+			operator = "<"
+		}
+		conditions = append(conditions, fmt.Sprintf("%s.%s %s ?", k.table, key.field.DBName, operator))
+		args = append(args, parsed[i])
+		branches = append(branches, "("+strings.Join(conditions, " AND ")+")")
+	}
+	return "(" + strings.Join(branches, " OR ") + ")", args, nil
+}
+
+// Select adds the key columns missing from a projection, nil selects everything
+func (k *Keyset) Select(columns []string) []string {
+	if columns == nil {
+		return nil
+	}
+	for _, key := range k.keys {
+		column := fmt.Sprintf("%s.%s", k.table, key.field.DBName)
+		if !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// Values reads the key values of an entity for its cursor
+func (k *Keyset) Values(ctx context.Context, entity interface{}) ([]json.RawMessage, error) {
+	value := reflect.Indirect(reflect.ValueOf(entity))
+	values := make([]json.RawMessage, 0, len(k.keys))
+	for _, key := range k.keys {
+		v, _ := key.field.ValueOf(ctx, value)
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, raw)
+	}
+	return values, nil
+}
+
+func direction(desc bool) string {
+	if desc {
+		return "desc"
+	}
+	return "asc"
+}
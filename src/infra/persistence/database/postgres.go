@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -12,39 +13,152 @@ import (
 )
 
 var dbClient *gorm.DB
+var resolver *Resolver
 
 func InitDb(cfg *config.Config) error {
-	var err error
-	// print config
-	fmt.Printf("Postgres Config: %+v\n", cfg.Postgres)
-	cnn := fmt.Sprintf("host=%s port=%s user=%s password=%s sslmode=%s TimeZone=America/Recife",
-		cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.User, cfg.Postgres.Password,
-		cfg.Postgres.SSLMode)
+	if cfg.Postgres.Driver == DriverSqlite {
+		return initSqlite(cfg)
+	}
 
-	dbClient, err = gorm.Open(postgres.Open(cnn), &gorm.Config{})
+	var err error
+	primary := config.ReplicaConfig{
+		Host: cfg.Postgres.Host, Port: cfg.Postgres.Port, User: cfg.Postgres.User, Password: cfg.Postgres.Password,
+		DbName: cfg.Postgres.DbName, SSLMode: cfg.Postgres.SSLMode,
+		MaxIdleConns: cfg.Postgres.MaxIdleConns, MaxOpenConns: cfg.Postgres.MaxOpenConns, ConnMaxLifetime: cfg.Postgres.ConnMaxLifetime,
+	}
+	dbClient, err = open(primary)
 	if err != nil {
 		return err
 	}
 
-	sqlDb, _ := dbClient.DB()
+	replicas := []*Replica{}
+	for _, replicaCfg := range cfg.Postgres.Replicas {
+		replicaCfg = inherit(replicaCfg, primary)
+		db, err := connect(replicaCfg)
+		if err != nil {
+			return fmt.Errorf("replica %s: %w", replicaCfg.Host, err)
+		}
+		replicas = append(replicas, startReplica(replicaCfg.Host+":"+replicaCfg.Port+"/"+replicaCfg.DbName, db))
+	}
+	resolver = NewResolver(dbClient, replicas...)
+
+	log.Printf("Db connection established with %d replicas", len(replicas))
+	return nil
+}
+
+// replicaPingTimeout bounds the first ping of a replica at startup
+const replicaPingTimeout = 5 * time.Second
+
+// startReplica returns the replica, ejected when it does not answer yet so a
+// replica that is down does not stop the boot. WatchReplicas takes it back.
+func startReplica(name string, db *gorm.DB) *Replica {
+	replica := NewReplica(name, db)
+	if err := ping(context.Background(), db, replicaPingTimeout); err != nil {
+		replica.healthy.Store(false)
+		log.Printf("Replica %s ejected until it answers: %v", name, err)
+	}
+	return replica
+}
+
+func open(cfg config.ReplicaConfig) (*gorm.DB, error) {
+	db, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDb, _ := db.DB()
 	err = sqlDb.Ping()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return db, nil
+}
+
+// connect sets up the connection pool without connecting yet
+func connect(cfg config.ReplicaConfig) (*gorm.DB, error) {
+	cnn := fmt.Sprintf("host=%s port=%s user=%s password=%s sslmode=%s TimeZone=America/Recife",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.SSLMode)
+	if cfg.DbName != "" {
+		cnn += " dbname=" + cfg.DbName
 	}
 
-	sqlDb.SetMaxIdleConns(cfg.Postgres.MaxIdleConns)
-	sqlDb.SetMaxOpenConns(cfg.Postgres.MaxOpenConns)
-	sqlDb.SetConnMaxLifetime(cfg.Postgres.ConnMaxLifetime * time.Minute)
+	db, err := gorm.Open(postgres.Open(cnn), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		return nil, err
+	}
 
-	log.Println("Db connection established")
-	return nil
+	sqlDb, _ := db.DB()
+	sqlDb.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDb.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDb.SetConnMaxLifetime(cfg.ConnMaxLifetime * time.Minute)
+	return db, nil
+}
+
+// inherit fills the unset fields of a replica from the primary
+func inherit(replica config.ReplicaConfig, primary config.ReplicaConfig) config.ReplicaConfig {
+	if replica.Port == "" {
+		replica.Port = primary.Port
+	}
+	if replica.User == "" {
+		replica.User = primary.User
+	}
+	if replica.Password == "" {
+		replica.Password = primary.Password
+	}
+	if replica.DbName == "" {
+		replica.DbName = primary.DbName
+	}
+	if replica.SSLMode == "" {
+		replica.SSLMode = primary.SSLMode
+	}
+	if replica.MaxIdleConns == 0 {
+		replica.MaxIdleConns = primary.MaxIdleConns
+	}
+	if replica.MaxOpenConns == 0 {
+		replica.MaxOpenConns = primary.MaxOpenConns
+	}
+	if replica.ConnMaxLifetime == 0 {
+		replica.ConnMaxLifetime = primary.ConnMaxLifetime
+	}
+	return replica
 }
 
 func GetDb() *gorm.DB {
 	return dbClient
 }
 
+// GetResolver routes between the primary and its replicas, nil before InitDb
+func GetResolver() *Resolver {
+	return resolver
+}
+
+// WatchReplicas checks the health of the replicas every interval until ctx is done
+func WatchReplicas(ctx context.Context, interval time.Duration) {
+	if resolver == nil || len(resolver.Replicas()) == 0 || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, replica := range resolver.CheckHealth(ctx, interval) {
+				log.Printf("Replica %s healthy: %v", replica.Name, replica.Healthy())
+			}
+		}
+	}
+}
+
 func CloseDb() {
 	con, _ := dbClient.DB()
 	con.Close()
+	if resolver != nil {
+		for _, replica := range resolver.Replicas() {
+			if con, err := replica.db.DB(); err == nil {
+				con.Close()
+			}
+		}
+	}
 }
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	filter "golang-clean-web-api/domain/filter"
+)
+
+// selectFields is implemented by models that let clients choose their columns
+type selectFields interface {
+	SelectFields() []string
+}
+
+// GenerateProjection returns the columns to select, nil for all of them, and the
+// relations to preload. Included relations must be among the repository preloads,
+// fields among the model SelectFields. The primary key and the foreign keys of
+// included belongs-to relations are always selected.
+func GenerateProjection[T any](db *gorm.DB, projection *filter.Projection, preloads []PreloadEntity) ([]string, []PreloadEntity, error) {
+	b, err := newQueryBuilder[T](db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	included := make([]PreloadEntity, 0, len(preloads))
+	if projection.Include == nil {
+		for _, preload := range preloads {
+			if !preload.OnDemand {
+				included = append(included, preload)
+			}
+		}
+	} else {
+		for _, name := range projection.Include {
+			preload, ok := findPreload(preloads, name)
+			if !ok {
+				return nil, nil, filter.Invalid("include", "relation %s cannot be included", name)
+			}
+			included = append(included, preload)
+		}
+	}
+
+	if projection.Fields == nil {
+		return nil, included, nil
+	}
+
+	allowed := map[string]bool{}
+	if fields, ok := any(*new(T)).(selectFields); ok {
+		for _, name := range fields.SelectFields() {
+			allowed[strings.ToLower(name)] = true
+		}
+	}
+	columns := []string{}
+	seen := map[string]bool{}
+	add := func(field *schema.Field) {
+		if !seen[field.DBName] {
+			seen[field.DBName] = true
+			columns = append(columns, fmt.Sprintf("%s.%s", b.schema.Table, field.DBName))
+		}
+	}
+
+	if primary := b.schema.PrioritizedPrimaryField; primary != nil {
+		add(primary)
+	}
+	for _, name := range projection.Fields {
+		field := findColumn(b.schema, name)
+		if field == nil || !allowed[strings.ToLower(field.Name)] {
+			return nil, nil, filter.Invalid("fields", "field %s cannot be selected", name)
+		}
+		add(field)
+	}
+	for _, preload := range included {
+		relation := findRelation(b.schema, strings.Split(preload.Entity, ".")[0])
+		if relation == nil || relation.Type != schema.BelongsTo {
+			continue
+		}
+		for _, reference := range relation.References {
+			add(reference.ForeignKey)
+		}
+	}
+	return columns, included, nil
+}
+
+func findPreload(preloads []PreloadEntity, name string) (PreloadEntity, bool) {
+	for _, preload := range preloads {
+		if strings.EqualFold(preload.Entity, name) {
+			return preload, true
+		}
+	}
+	return PreloadEntity{}, false
+}
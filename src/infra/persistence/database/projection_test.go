@@ -0,0 +1,53 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/service_errors"
+)
+
+func TestGenerateProjection(t *testing.T) {
+	db := newTestDb(t)
+	preloads := []PreloadEntity{{Entity: "Country"}}
+
+	columns, included, err := GenerateProjection[model.City](db, &filter.Projection{}, preloads)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if columns != nil || !reflect.DeepEqual(included, preloads) {
+		t.Errorf("Expected all columns and default preloads, got %v and %v", columns, included)
+	}
+
+	columns, included, err = GenerateProjection[model.City](db, &filter.Projection{Fields: []string{"name"}, Include: []string{"country"}}, preloads)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(columns, []string{"cities.id", "cities.name", "cities.country_id"}) {
+		t.Errorf("Expected id, name and the foreign key, got %v", columns)
+	}
+	if !reflect.DeepEqual(included, preloads) {
+		t.Errorf("Expected Country to be included, got %v", included)
+	}
+
+	_, included, err = GenerateProjection[model.Country](db, &filter.Projection{Include: []string{}},
+		[]PreloadEntity{{Entity: "Cities", OnDemand: true}})
+	if err != nil || len(included) != 0 {
+		t.Errorf("Expected no preloads, got %v (%v)", included, err)
+	}
+
+	for name, projection := range map[string]filter.Projection{
+		"audit column":     {Fields: []string{"createdBy"}},
+		"unknown field":    {Fields: []string{"population"}},
+		"unknown relation": {Include: []string{"companies"}},
+	} {
+		_, _, err := GenerateProjection[model.City](db, &projection, preloads)
+		var serviceError *service_errors.ServiceError
+		if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidFilter {
+			t.Errorf("%s: expected an invalid filter error, got %v", name, err)
+		}
+	}
+}
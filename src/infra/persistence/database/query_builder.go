@@ -1,88 +1,404 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
-	"golang-clean-web-api/common"
-
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 
 	filter "golang-clean-web-api/domain/filter"
 )
 
+// PreloadEntity is a relation a repository may load. OnDemand relations are only
+// loaded when a request includes them, the others unless a request narrows include.
 type PreloadEntity struct {
-	Entity string
+	Entity   string
+	OnDemand bool
+}
+
+// filterRelations is implemented by models that expose relations to filtering
+// and sorting. Each entry is a relation path such as "Country" or "Country.Cities".
+type filterRelations interface {
+	FilterRelations() []string
+}
+
+var errUnknownField = errors.New("unknown field")
+
+// fieldPath is a column reached from the entity through zero or more relations
+type fieldPath struct {
+	relations []*schema.Relationship
+	field     *schema.Field
+}
+
+type queryBuilder struct {
+	db      *gorm.DB
+	schema  *schema.Schema
+	allowed map[string]bool
+	aliases int
+}
+
+func newQueryBuilder[T any](db *gorm.DB) (*queryBuilder, error) {
+	model := new(T)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+
+	allowed := map[string]bool{}
+	if relations, ok := any(*model).(filterRelations); ok {
+		for _, path := range relations.FilterRelations() {
+			allowed[strings.ToLower(path)] = true
+		}
+	}
+	return &queryBuilder{db: db, schema: stmt.Schema, allowed: allowed}, nil
 }
 
-// GenerateDynamicQuery
-func GenerateDynamicQuery[T any](filter *filter.DynamicFilter) string {
-	t := new(T)
-	typeT := reflect.TypeOf(*t)
+// GenerateDynamicQuery builds the where clause and its arguments for the filter.
+// Fields may be dotted paths through the relations the model allows, e.g. Country.Name.
+// Entries of the Filter map on unknown fields are ignored, the Where tree is validated.
+func GenerateDynamicQuery[T any](db *gorm.DB, filter *filter.DynamicFilter) (string, []interface{}, error) {
+	return generateQuery[T](db, filter, "is null")
+}
+
+// GenerateDeletedQuery builds the same where clause over the soft deleted rows
+func GenerateDeletedQuery[T any](db *gorm.DB, filter *filter.DynamicFilter) (string, []interface{}, error) {
+	return generateQuery[T](db, filter, "is not null")
+}
+
+func generateQuery[T any](db *gorm.DB, filter *filter.DynamicFilter, deleted string) (string, []interface{}, error) {
+	b, err := newQueryBuilder[T](db)
+	if err != nil {
+		return "", nil, err
+	}
+
 	query := make([]string, 0)
-	query = append(query, "deleted_by is null")
+	args := make([]interface{}, 0)
+	if b.schema.LookUpField("deleted_by") != nil {
+		query = append(query, fmt.Sprintf("%s.deleted_by %s", b.schema.Table, deleted))
+	}
 	if filter.Filter != nil {
 		for name, filter := range filter.Filter {
-			if fld, ok := typeT.FieldByName(name); ok {
-				query = append(query, GenerateDynamicFilter(fld, filter))
+			path, err := b.resolve(name)
+			if errors.Is(err, errUnknownField) {
+				continue
 			}
+			if err != nil {
+				return "", nil, err
+			}
+			condition, conditionArgs, err := b.condition(path, filter)
+			if err != nil {
+				return "", nil, err
+			}
+			query = append(query, condition)
+			args = append(args, conditionArgs...)
+		}
+	}
+	if filter.Where != nil {
+		if err := filter.Where.Validate(); err != nil {
+			return "", nil, err
+		}
+		condition, conditionArgs, err := b.expression(filter.Where)
+		if err != nil {
+			return "", nil, err
 		}
+		query = append(query, condition)
+		args = append(args, conditionArgs...)
 	}
-	return strings.Join(query, " AND ")
+	if filter.Search != "" {
+		search, err := GenerateSearch[T](db, filter.Search)
+		if err != nil {
+			return "", nil, err
+		}
+		query = append(query, search.Condition.SQL)
+		args = append(args, search.Condition.Vars...)
+	}
+	return strings.Join(query, " AND "), args, nil
+}
+
+func (b *queryBuilder) expression(expression *filter.Expression) (string, []interface{}, error) {
+	if !expression.IsGroup() {
+		path, err := b.resolve(expression.Field)
+		if errors.Is(err, errUnknownField) {
+			return "", nil, invalidFilter("unknown field %s", expression.Field)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return b.condition(path, expression.Filter)
+	}
+
+	if expression.Not != nil {
+		condition, args, err := b.expression(expression.Not)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("NOT (%s)", condition), args, nil
+	}
+
+	children, operator := expression.And, " AND "
+	if expression.Or != nil {
+		children, operator = expression.Or, " OR "
+	}
+	conditions := make([]string, 0, len(children))
+	args := make([]interface{}, 0)
+	for i := range children {
+		condition, conditionArgs, err := b.expression(&children[i])
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, conditionArgs...)
+	}
+	return fmt.Sprintf("(%s)", strings.Join(conditions, operator)), args, nil
 }
 
-func GenerateDynamicFilter(fld reflect.StructField, filter filter.Filter) string {
-	conditionQuery := ""
-	fld.Name = common.ToSnakeCase(fld.Name)
+// condition builds the condition of a path. Every relation on the way becomes an
+// EXISTS subquery, so a has-many relation matches when any related row matches.
+func (b *queryBuilder) condition(path fieldPath, f filter.Filter) (string, []interface{}, error) {
+	return b.nest(b.schema.Table, path.relations, func(alias string) (string, []interface{}, error) {
+		return GenerateDynamicFilter(b.db, alias+"."+path.field.DBName, path.field, f)
+	})
+}
+
+func (b *queryBuilder) nest(alias string, relations []*schema.Relationship,
+	inner func(alias string) (string, []interface{}, error)) (string, []interface{}, error) {
+	if len(relations) == 0 {
+		return inner(alias)
+	}
+
+	relation := relations[0]
+	b.aliases++
+	relatedAlias := fmt.Sprintf("r%d", b.aliases)
+	condition, args, err := b.nest(relatedAlias, relations[1:], inner)
+	if err != nil {
+		return "", nil, err
+	}
+
+	where := append(correlate(relation, alias, relatedAlias), condition)
+	if relation.FieldSchema.LookUpField("deleted_by") != nil {
+		where = append(where, fmt.Sprintf("%s.deleted_by is null", relatedAlias))
+	}
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM %s %s WHERE %s)",
+		relation.FieldSchema.Table, relatedAlias, strings.Join(where, " AND ")), args, nil
+}
+
+// correlate returns the join conditions between a relation owner and the related table
+func correlate(relation *schema.Relationship, alias string, relatedAlias string) []string {
+	conditions := []string{}
+	for _, reference := range relation.References {
+		if reference.PrimaryKey == nil {
+			continue
+		}
+		if reference.OwnPrimaryKey {
+			conditions = append(conditions, fmt.Sprintf("%s.%s = %s.%s",
+				relatedAlias, reference.ForeignKey.DBName, alias, reference.PrimaryKey.DBName))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("%s.%s = %s.%s",
+				relatedAlias, reference.PrimaryKey.DBName, alias, reference.ForeignKey.DBName))
+		}
+	}
+	return conditions
+}
+
+// resolve turns Name or Country.Name into a column path. Names match the go
+// field name or the column name, case-insensitively.
+func (b *queryBuilder) resolve(name string) (fieldPath, error) {
+	segments := strings.Split(name, ".")
+	path := fieldPath{}
+	current := b.schema
+	prefix := ""
+	for _, segment := range segments[:len(segments)-1] {
+		relation := findRelation(current, segment)
+		if relation == nil {
+			return path, fmt.Errorf("%w: %s", errUnknownField, name)
+		}
+		prefix += relation.Name
+		if !b.allowed[strings.ToLower(prefix)] {
+			return path, invalidFilter("filtering on %s is not allowed", prefix)
+		}
+		if relation.Type == schema.Many2Many || relation.Polymorphic != nil {
+			return path, invalidFilter("relation %s is not supported", prefix)
+		}
+		prefix += "."
+		path.relations = append(path.relations, relation)
+		current = relation.FieldSchema
+	}
+
+	path.field = findColumn(current, segments[len(segments)-1])
+	if path.field == nil {
+		return path, fmt.Errorf("%w: %s", errUnknownField, name)
+	}
+	return path, nil
+}
+
+func findColumn(s *schema.Schema, name string) *schema.Field {
+	if field := s.LookUpField(name); field != nil && field.DBName != "" {
+		return field
+	}
+	for _, field := range s.Fields {
+		if field.DBName != "" && (strings.EqualFold(field.Name, name) || strings.EqualFold(field.DBName, name)) {
+			return field
+		}
+	}
+	return nil
+}
+
+func findRelation(s *schema.Schema, name string) *schema.Relationship {
+	for relationName, relation := range s.Relationships.Relations {
+		if strings.EqualFold(relationName, name) {
+			return relation
+		}
+	}
+	return nil
+}
+
+// GenerateDynamicFilter builds one parameterized condition on the column in the dialect of db
+func GenerateDynamicFilter(db *gorm.DB, column string, field *schema.Field, filter filter.Filter) (string, []interface{}, error) {
+	value := func(raw string) (interface{}, error) {
+		return convertValue(field, raw)
+	}
+	like := likeOperator(db)
+
 	switch filter.Type {
 	case "contains":
-		conditionQuery = fmt.Sprintf("%s ILike '%%%s%%'", fld.Name, filter.From)
+		return fmt.Sprintf("%s %s ?", column, like), []interface{}{"%" + filter.From + "%"}, nil
 	case "notContains":
-		conditionQuery = fmt.Sprintf("%s not ILike '%%%s%%'", fld.Name, filter.From)
+		return fmt.Sprintf("%s not %s ?", column, like), []interface{}{"%" + filter.From + "%"}, nil
 	case "startsWith":
-		conditionQuery = fmt.Sprintf("%s ILike '%s%%'", fld.Name, filter.From)
+		return fmt.Sprintf("%s %s ?", column, like), []interface{}{filter.From + "%"}, nil
 	case "endsWith":
-		conditionQuery = fmt.Sprintf("%s ILike '%%%s'", fld.Name, filter.From)
-	case "equals":
-		conditionQuery = fmt.Sprintf("%s = '%s'", fld.Name, filter.From)
-	case "notEqual":
-		conditionQuery = fmt.Sprintf("%s != '%s'", fld.Name, filter.From)
-	case "lessThan":
-		conditionQuery = fmt.Sprintf("%s < %s", fld.Name, filter.From)
-	case "lessThanOrEqual":
-		conditionQuery = fmt.Sprintf("%s <= %s", fld.Name, filter.From)
-	case "greaterThan":
-		conditionQuery = fmt.Sprintf("%s > %s", fld.Name, filter.From)
-	case "greaterThanOrEqual":
-		conditionQuery = fmt.Sprintf("%s >= %s", fld.Name, filter.From)
+		return fmt.Sprintf("%s %s ?", column, like), []interface{}{"%" + filter.From}, nil
+	case "isNull":
+		return fmt.Sprintf("%s is null", column), nil, nil
+	case "isNotNull":
+		return fmt.Sprintf("%s is not null", column), nil, nil
+	case "in", "notIn":
+		values := make([]interface{}, 0, len(filter.Values))
+		for _, raw := range filter.Values {
+			v, err := value(raw)
+			if err != nil {
+				return "", nil, err
+			}
+			values = append(values, v)
+		}
+		if filter.Type == "in" {
+			return fmt.Sprintf("%s in ?", column), []interface{}{values}, nil
+		}
+		return fmt.Sprintf("%s not in ?", column), []interface{}{values}, nil
 	case "inRange":
-		if fld.Type.Kind() == reflect.String {
-			conditionQuery = fmt.Sprintf("%s >= '%s%%' AND ", fld.Name, filter.From)
-			conditionQuery += fmt.Sprintf("%s <= '%%%s'", fld.Name, filter.To)
-		} else {
-			conditionQuery = fmt.Sprintf("%s >= %s AND ", fld.Name, filter.From)
-			conditionQuery += fmt.Sprintf("%s <= %s", fld.Name, filter.To)
+		from, err := value(filter.From)
+		if err != nil {
+			return "", nil, err
+		}
+		to, err := value(filter.To)
+		if err != nil {
+			return "", nil, err
 		}
+		return fmt.Sprintf("(%s >= ? AND %s <= ?)", column, column), []interface{}{from, to}, nil
 	}
-	return conditionQuery
+
+	operators := map[string]string{
+		"equals":             "=",
+		"notEqual":           "!=",
+		"lessThan":           "<",
+		"lessThanOrEqual":    "<=",
+		"greaterThan":        ">",
+		"greaterThanOrEqual": ">=",
+	}
+	operator, ok := operators[filter.Type]
+	if !ok {
+		return "", nil, invalidFilter("unknown filter type %q on %s", filter.Type, field.Name)
+	}
+	v, err := value(filter.From)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s %s ?", column, operator), []interface{}{v}, nil
 }
 
-// generateDynamicSort
-func GenerateDynamicSort[T any](filter *filter.DynamicFilter) string {
-	t := new(T)
-	typeT := reflect.TypeOf(*t)
+// convertValue parses a raw filter value into the go type of the field
+func convertValue(field *schema.Field, raw string) (interface{}, error) {
+	t := field.IndirectFieldType
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, invalidFilter("%s expects a number, got %q", field.Name, raw)
+		}
+		return v, nil
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, invalidFilter("%s expects a number, got %q", field.Name, raw)
+		}
+		return v, nil
+	case reflect.Bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, invalidFilter("%s expects a boolean, got %q", field.Name, raw)
+		}
+		return v, nil
+	}
+	return raw, nil
+}
+
+func invalidFilter(format string, args ...interface{}) error {
+	return filter.Invalid("filter", format, args...)
+}
+
+// GenerateDynamicSort builds the order by clause. Sorting through belongs-to and
+// has-one relations uses a correlated subquery, unknown or has-many paths are ignored.
+func GenerateDynamicSort[T any](db *gorm.DB, filter *filter.DynamicFilter) (string, error) {
+	b, err := newQueryBuilder[T](db)
+	if err != nil {
+		return "", err
+	}
+
 	sort := make([]string, 0)
 	if filter.Sort != nil {
 		for _, tp := range *filter.Sort {
-			fld, ok := typeT.FieldByName(tp.ColId)
-			if ok && (tp.Sort == "asc" || tp.Sort == "desc") {
-				fld.Name = common.ToSnakeCase(fld.Name)
-				sort = append(sort, fmt.Sprintf("%s %s", fld.Name, tp.Sort))
+			if tp.Sort != "asc" && tp.Sort != "desc" {
+				continue
+			}
+			path, err := b.resolve(tp.ColId)
+			if errors.Is(err, errUnknownField) {
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			column, ok := b.sortColumn(b.schema.Table, path.relations, path.field)
+			if ok {
+				sort = append(sort, fmt.Sprintf("%s %s", column, tp.Sort))
 			}
 		}
 	}
-	return strings.Join(sort, ", ")
+	return strings.Join(sort, ", "), nil
+}
+
+func (b *queryBuilder) sortColumn(alias string, relations []*schema.Relationship, field *schema.Field) (string, bool) {
+	if len(relations) == 0 {
+		return alias + "." + field.DBName, true
+	}
+
+	relation := relations[0]
+	if relation.Type != schema.BelongsTo && relation.Type != schema.HasOne {
+		return "", false
+	}
+	b.aliases++
+	relatedAlias := fmt.Sprintf("r%d", b.aliases)
+	column, ok := b.sortColumn(relatedAlias, relations[1:], field)
+	if !ok {
+		return "", false
+	}
+	where := correlate(relation, alias, relatedAlias)
+	return fmt.Sprintf("(SELECT %s FROM %s %s WHERE %s LIMIT 1)",
+		column, relation.FieldSchema.Table, relatedAlias, strings.Join(where, " AND ")), true
 }
 
 // Preload
@@ -92,3 +408,12 @@ func Preload(db *gorm.DB, preloads []PreloadEntity) *gorm.DB {
 	}
 	return db
 }
+
+// likeOperator is the case-insensitive LIKE of the dialect. Like in sqlite
+// ignores the case of ascii letters only.
+func likeOperator(db *gorm.DB) string {
+	if db.Dialector.Name() == DriverPostgres {
+		return "ILike"
+	}
+	return "Like"
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/service_errors"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newTestDb(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
+
+// newPostgresDb returns a postgres dialect that is never connected, to check the sql it builds
+func newPostgresDb(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
+
+func TestGenerateDynamicQuery_LegacyFilter(t *testing.T) {
+	f := filter.DynamicFilter{Filter: map[string]filter.Filter{
+		"Name":    {Type: "startsWith", From: "Ir"},
+		"Unknown": {Type: "equals", From: "x"},
+	}}
+
+	query, args, err := GenerateDynamicQuery[model.Country](newPostgresDb(t), &f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if query != "countries.deleted_by is null AND countries.name ILike ?" {
+		t.Errorf("Unexpected query %q", query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Ir%"}) {
+		t.Errorf("Unexpected args %v", args)
+	}
+}
+
+func TestGenerateDynamicQuery_ExpressionTree(t *testing.T) {
+	body := `{"where": {"or": [
+		{"field": "Name", "type": "startsWith", "from": "I"},
+		{"and": [
+			{"field": "Id", "type": "greaterThan", "from": "3"},
+			{"field": "id", "type": "lessThan", "from": "9"}
+		]},
+		{"not": {"field": "Id", "type": "in", "values": ["1", "2"]}},
+		{"field": "ModifiedBy", "type": "isNull"}
+	]}}`
+	var f filter.DynamicFilter
+	if err := json.Unmarshal([]byte(body), &f); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	query, args, err := GenerateDynamicQuery[model.Country](newTestDb(t), &f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "countries.deleted_by is null AND (countries.name Like ? OR (countries.id > ? AND countries.id < ?) OR " +
+		"NOT (countries.id in ?) OR countries.modified_by is null)"
+	if query != expected {
+		t.Errorf("Expected %q, got %q", expected, query)
+	}
+	expectedArgs := []interface{}{"I%", int64(3), int64(9), []interface{}{int64(1), int64(2)}}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+func TestGenerateDynamicQuery_RelationPaths(t *testing.T) {
+	db := newTestDb(t)
+
+	f := filter.DynamicFilter{Where: &filter.Expression{Field: "Country.Name", Filter: filter.Filter{Type: "startsWith", From: "I"}}}
+	query, _, err := GenerateDynamicQuery[model.City](db, &f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "cities.deleted_by is null AND EXISTS (SELECT 1 FROM countries r1 WHERE r1.id = cities.country_id AND " +
+		"r1.name Like ? AND r1.deleted_by is null)"
+	if query != expected {
+		t.Errorf("Expected %q, got %q", expected, query)
+	}
+
+	f = filter.DynamicFilter{Where: &filter.Expression{Field: "cities.name", Filter: filter.Filter{Type: "equals", From: "Tehran"}}}
+	query, _, err = GenerateDynamicQuery[model.Country](db, &f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = "countries.deleted_by is null AND EXISTS (SELECT 1 FROM cities r1 WHERE r1.country_id = countries.id AND " +
+		"r1.name = ? AND r1.deleted_by is null)"
+	if query != expected {
+		t.Errorf("Expected %q, got %q", expected, query)
+	}
+
+	f = filter.DynamicFilter{Where: &filter.Expression{Field: "Country.Cities.Name", Filter: filter.Filter{Type: "equals", From: "Tehran"}}}
+	_, _, err = GenerateDynamicQuery[model.City](db, &f)
+	var serviceError *service_errors.ServiceError
+	if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidFilter {
+		t.Errorf("Expected a relation outside the allow-list to be rejected, got %v", err)
+	}
+}
+
+func TestGenerateDynamicSort_RelationPaths(t *testing.T) {
+	db := newTestDb(t)
+	f := filter.DynamicFilter{Sort: &[]filter.Sort{
+		{ColId: "Country.Name", Sort: "desc"},
+		{ColId: "Id", Sort: "asc"},
+	}}
+
+	sort, err := GenerateDynamicSort[model.City](db, &f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "(SELECT r1.name FROM countries r1 WHERE r1.id = cities.country_id LIMIT 1) desc, cities.id asc"
+	if sort != expected {
+		t.Errorf("Expected %q, got %q", expected, sort)
+	}
+
+	f = filter.DynamicFilter{Sort: &[]filter.Sort{{ColId: "Cities.Name", Sort: "asc"}}}
+	sort, _ = GenerateDynamicSort[model.Country](db, &f)
+	if sort != "" {
+		t.Errorf("Expected has-many sort to be ignored, got %q", sort)
+	}
+}
+
+func TestGenerateDynamicQuery_InvalidExpressions(t *testing.T) {
+	deep := &filter.Expression{Field: "Name", Filter: filter.Filter{Type: "equals", From: "x"}}
+	for i := 0; i < filter.MaxExpressionDepth; i++ {
+		deep = &filter.Expression{Not: deep}
+	}
+
+	tooMany := filter.Expression{}
+	for i := 0; i <= filter.MaxExpressionConditions; i++ {
+		tooMany.And = append(tooMany.And, filter.Expression{Field: "Id", Filter: filter.Filter{Type: "equals", From: "1"}})
+	}
+
+	cases := map[string]*filter.Expression{
+		"unknown field":     {Field: "Password", Filter: filter.Filter{Type: "equals", From: "x"}},
+		"relation field":    {Field: "Cities", Filter: filter.Filter{Type: "isNull"}},
+		"unknown type":      {Field: "Name", Filter: filter.Filter{Type: "like", From: "x"}},
+		"bad number":        {Field: "Id", Filter: filter.Filter{Type: "equals", From: "one"}},
+		"empty in":          {Field: "Id", Filter: filter.Filter{Type: "in"}},
+		"field and group":   {Field: "Id", Or: []filter.Expression{}},
+		"empty group":       {And: []filter.Expression{}},
+		"too deep":          deep,
+		"too many children": &tooMany,
+	}
+	db := newTestDb(t)
+	for name, expression := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := GenerateDynamicQuery[model.Country](db, &filter.DynamicFilter{Where: expression})
+			var serviceError *service_errors.ServiceError
+			if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidFilter {
+				t.Errorf("Expected invalid filter error, got %v", err)
+			}
+		})
+	}
+}
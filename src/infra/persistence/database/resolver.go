@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Resolver sends reads to the healthy replicas in turn and everything else to
+// the primary. Reads that must see the writes of their request stay on the
+// primary, see NewSessionContext.
+type Resolver struct {
+	primary  *gorm.DB
+	replicas []*Replica
+	next     atomic.Uint64
+}
+
+// Replica is a read only copy of the primary, ejected while it fails its health check
+type Replica struct {
+	Name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+func NewReplica(name string, db *gorm.DB) *Replica {
+	replica := &Replica{Name: name, db: db}
+	replica.healthy.Store(true)
+	return replica
+}
+
+func (r *Replica) Healthy() bool {
+	return r.healthy.Load()
+}
+
+func NewResolver(primary *gorm.DB, replicas ...*Replica) *Resolver {
+	return &Resolver{primary: primary, replicas: replicas}
+}
+
+func (r *Resolver) Primary() *gorm.DB {
+	return r.primary
+}
+
+func (r *Resolver) Replicas() []*Replica {
+	return r.replicas
+}
+
+// Writer returns the primary, or the transaction ctx carries
+func (r *Resolver) Writer(ctx context.Context) *gorm.DB {
+	return Conn(ctx, r.primary)
+}
+
+// Reader returns a healthy replica, or the primary when ctx is in a transaction,
+// its session has written or no replica is healthy
+func (r *Resolver) Reader(ctx context.Context) *gorm.DB {
+	if _, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok || written(ctx) || len(r.replicas) == 0 {
+		return r.Writer(ctx)
+	}
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		replica := r.replicas[(int(start)+i)%len(r.replicas)]
+		if replica.Healthy() {
+			return replica.db.WithContext(ctx)
+		}
+	}
+	return r.Writer(ctx)
+}
+
+// CheckHealth pings every replica, ejecting the ones that fail and taking back
+// the ones that recovered. It returns the replicas whose state changed.
+func (r *Resolver) CheckHealth(ctx context.Context, timeout time.Duration) []*Replica {
+	changed := []*Replica{}
+	for _, replica := range r.replicas {
+		healthy := ping(ctx, replica.db, timeout) == nil
+		if replica.healthy.Swap(healthy) != healthy {
+			changed = append(changed, replica)
+		}
+	}
+	return changed
+}
+
+func ping(ctx context.Context, db *gorm.DB, timeout time.Duration) error {
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return sqlDb.PingContext(ctx)
+}
+
+type sessionKey struct{}
+
+type session struct {
+	written atomic.Bool
+}
+
+// NewSessionContext starts a session, usually one per request. Once a repository
+// wrote in it, its reads go to the primary so they see their own writes.
+func NewSessionContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// MarkWritten records that the session of ctx wrote, if there is one
+func MarkWritten(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.written.Store(true)
+	}
+}
+
+func written(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.written.Load()
+}
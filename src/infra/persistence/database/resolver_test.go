@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// newNamedDb opens a database that knows its own name, to tell which one served a read
+func newNamedDb(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	db := newTestDb(t)
+	sqlDb, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	sqlDb.SetMaxOpenConns(1)
+	if err := db.Exec("CREATE TABLE nodes (name TEXT)").Error; err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if err := db.Exec("INSERT INTO nodes (name) VALUES (?)", name).Error; err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	return db
+}
+
+func nodeName(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var name string
+	if err := db.Raw("SELECT name FROM nodes").Scan(&name).Error; err != nil {
+		t.Fatalf("Failed to read node: %v", err)
+	}
+	return name
+}
+
+func TestResolver_Reader(t *testing.T) {
+	primary := newNamedDb(t, "primary")
+	first := NewReplica("first", newNamedDb(t, "first"))
+	second := NewReplica("second", newNamedDb(t, "second"))
+	resolver := NewResolver(primary, first, second)
+
+	ctx := NewSessionContext(context.Background())
+	served := map[string]int{}
+	for i := 0; i < 4; i++ {
+		served[nodeName(t, resolver.Reader(ctx))]++
+	}
+	if served["first"] != 2 || served["second"] != 2 {
+		t.Errorf("Expected reads to alternate between replicas, got %v", served)
+	}
+
+	if name := nodeName(t, resolver.Reader(context.Background())); name == "primary" {
+		t.Errorf("Expected a read without a session to go to a replica")
+	}
+
+	err := NewTransactionManager(primary).Do(ctx, func(ctx context.Context) error {
+		if name := nodeName(t, resolver.Reader(ctx)); name != "primary" {
+			t.Errorf("Expected a read in a transaction to go to the primary, got %s", name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	MarkWritten(ctx)
+	if name := nodeName(t, resolver.Reader(ctx)); name != "primary" {
+		t.Errorf("Expected a read after a write to go to the primary, got %s", name)
+	}
+	if name := nodeName(t, resolver.Reader(NewSessionContext(context.Background()))); name == "primary" {
+		t.Errorf("Expected another session to read from a replica")
+	}
+}
+
+func TestResolver_CheckHealth(t *testing.T) {
+	primary := newNamedDb(t, "primary")
+	first := NewReplica("first", newNamedDb(t, "first"))
+	second := NewReplica("second", newNamedDb(t, "second"))
+	resolver := NewResolver(primary, first, second)
+
+	if changed := resolver.CheckHealth(context.Background(), time.Second); len(changed) != 0 {
+		t.Errorf("Expected no change, got %d", len(changed))
+	}
+
+	sqlDb, _ := first.db.DB()
+	sqlDb.Close()
+	changed := resolver.CheckHealth(context.Background(), time.Second)
+	if len(changed) != 1 || changed[0] != first || first.Healthy() {
+		t.Fatalf("Expected the closed replica to be ejected, got %v", changed)
+	}
+	for i := 0; i < 3; i++ {
+		if name := nodeName(t, resolver.Reader(context.Background())); name != "second" {
+			t.Errorf("Expected reads to go to the healthy replica, got %s", name)
+		}
+	}
+
+	sqlDb, _ = second.db.DB()
+	sqlDb.Close()
+	resolver.CheckHealth(context.Background(), time.Second)
+	if name := nodeName(t, resolver.Reader(context.Background())); name != "primary" {
+		t.Errorf("Expected reads to fall back to the primary, got %s", name)
+	}
+}
+
+func TestStartReplica(t *testing.T) {
+	primary := newNamedDb(t, "primary")
+	up := startReplica("up", newNamedDb(t, "up"))
+	downDb := newNamedDb(t, "down")
+	sqlDb, _ := downDb.DB()
+	sqlDb.Close()
+	down := startReplica("down", downDb)
+
+	if !up.Healthy() || down.Healthy() {
+		t.Fatalf("Expected only the unreachable replica ejected, got up %v and down %v", up.Healthy(), down.Healthy())
+	}
+	resolver := NewResolver(primary, down, up)
+	for i := 0; i < 2; i++ {
+		if name := nodeName(t, resolver.Reader(context.Background())); name != "up" {
+			t.Errorf("Expected reads to skip the ejected replica, got %s", name)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	filter "golang-clean-web-api/domain/filter"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// searchFields is implemented by models that can be searched. The first field
+// names a row in search results.
+type searchFields interface {
+	SearchFields() []string
+}
+
+// SearchVectorColumn is the generated tsvector of the search fields, postgres only
+const SearchVectorColumn = "search_vector"
+
+// searchConfig is the text search configuration of the vectors and queries,
+// names are not stemmed
+const searchConfig = "simple"
+
+const (
+	highlightStart = "<b>"
+	highlightStop  = "</b>"
+)
+
+var searchWordExp = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Search matches a text against the search fields of a model
+type Search struct {
+	Table string
+	// Title is the column that names a row
+	Title     string
+	Condition clause.Expr
+	// Rank is the relevance of a matching row, higher first
+	Rank clause.Expr
+	// Highlight marks the matched words in the title, nil when the dialect cannot,
+	// see HighlightText
+	Highlight *clause.Expr
+}
+
+// SearchWords splits a search text into lower case words
+func SearchWords(text string) []string {
+	words := searchWordExp.FindAllString(strings.ToLower(text), -1)
+	if words == nil {
+		return []string{}
+	}
+	return words
+}
+
+// GenerateSearch matches rows containing the words of the text. On postgres the
+// words are looked up in the tsvector column and close spellings are found by
+// pg_trgm similarity, other dialects look for every word with LIKE.
+func GenerateSearch[T any](db *gorm.DB, text string) (*Search, error) {
+	search, columns, err := newSearch[T](db, text)
+	if err != nil {
+		return nil, err
+	}
+	words := SearchWords(text)
+
+	if db.Dialector.Name() != DriverPostgres {
+		conditions := make([]string, 0, len(words))
+		args := []interface{}{}
+		for _, word := range words {
+			matches := make([]string, 0, len(columns))
+			for _, column := range columns {
+				matches = append(matches, fmt.Sprintf("lower(%s) LIKE ?", column))
+				args = append(args, "%"+word+"%")
+			}
+			conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+		}
+		phrase := strings.Join(words, " ")
+		search.Condition = clause.Expr{SQL: "(" + strings.Join(conditions, " AND ") + ")", Vars: args}
+		search.Rank = clause.Expr{
+			SQL:  fmt.Sprintf("CASE WHEN lower(%[1]s) = ? THEN 3 WHEN lower(%[1]s) LIKE ? THEN 2 ELSE 1 END", search.Title),
+			Vars: []interface{}{phrase, phrase + "%"},
+		}
+		return search, nil
+	}
+
+	vector := search.Table + "." + SearchVectorColumn
+	query := fmt.Sprintf("websearch_to_tsquery('%s', ?)", searchConfig)
+	conditions := []string{fmt.Sprintf("%s @@ %s", vector, query)}
+	similarities := make([]string, 0, len(columns))
+	args := []interface{}{text}
+	rankArgs := []interface{}{text}
+	for _, column := range columns {
+		conditions = append(conditions, column+" % ?")
+		similarities = append(similarities, fmt.Sprintf("similarity(%s, ?)", column))
+		args = append(args, text)
+		rankArgs = append(rankArgs, text)
+	}
+	search.Condition = clause.Expr{SQL: "(" + strings.Join(conditions, " OR ") + ")", Vars: args}
+	search.Rank = clause.Expr{
+		SQL:  fmt.Sprintf("ts_rank(%s, %s) + greatest(%s)", vector, query, strings.Join(similarities, ", ")),
+		Vars: rankArgs,
+	}
+	search.Highlight = &clause.Expr{
+		SQL: fmt.Sprintf("ts_headline('%s', %s, %s, 'StartSel=%s, StopSel=%s, HighlightAll=true')",
+			searchConfig, search.Title, query, highlightStart, highlightStop),
+		Vars: []interface{}{text},
+	}
+	return search, nil
+}
+
+// GenerateSuggest matches rows whose title, or a word of it, starts with the text,
+// for typeahead. The closest titles rank first.
+func GenerateSuggest[T any](db *gorm.DB, text string) (*Search, error) {
+	search, _, err := newSearch[T](db, text)
+	if err != nil {
+		return nil, err
+	}
+	words := SearchWords(text)
+	phrase := strings.Join(words, " ")
+
+	if db.Dialector.Name() != DriverPostgres {
+		search.Condition = clause.Expr{
+			SQL:  fmt.Sprintf("(lower(%[1]s) LIKE ? OR lower(%[1]s) LIKE ?)", search.Title),
+			Vars: []interface{}{phrase + "%", "% " + phrase + "%"},
+		}
+		search.Rank = clause.Expr{
+			SQL:  fmt.Sprintf("CASE WHEN lower(%s) LIKE ? THEN 1 ELSE 0 END", search.Title),
+			Vars: []interface{}{phrase + "%"},
+		}
+		return search, nil
+	}
+
+	// every word is a prefix: 'new':* & 'yo':*
+	prefixes := make([]string, 0, len(words))
+	for _, word := range words {
+		prefixes = append(prefixes, "'"+word+"':*")
+	}
+	search.Condition = clause.Expr{
+		SQL: fmt.Sprintf("(%s ILIKE ? OR %s.%s @@ to_tsquery('%s', ?))",
+			search.Title, search.Table, SearchVectorColumn, searchConfig),
+		Vars: []interface{}{phrase + "%", strings.Join(prefixes, " & ")},
+	}
+	search.Rank = clause.Expr{SQL: fmt.Sprintf("similarity(%s, ?)", search.Title), Vars: []interface{}{phrase}}
+	return search, nil
+}
+
+// GenerateSearchOrder orders the rows of a search by relevance, then id. It is
+// nil when the filter does not search or sorts itself.
+func GenerateSearchOrder[T any](db *gorm.DB, f *filter.DynamicFilter) (clause.Expression, error) {
+	if f.Search == "" {
+		return nil, nil
+	}
+	sorted, err := GenerateDynamicSort[T](db, f)
+	if err != nil || sorted != "" {
+		return nil, err
+	}
+	search, err := GenerateSearch[T](db, f.Search)
+	if err != nil {
+		return nil, err
+	}
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:  fmt.Sprintf("%s DESC, %s.id", search.Rank.SQL, search.Table),
+		Vars: search.Rank.Vars,
+	}}, nil
+}
+
+// HighlightText marks the words of the text in value like Search.Highlight, for
+// dialects that cannot highlight
+func HighlightText(value string, text string) string {
+	words := SearchWords(text)
+	if len(words) == 0 {
+		return value
+	}
+	// longer words first, so a word is not cut by a shorter one it contains
+	sort.Slice(words, func(i, j int) bool { return len(words[i]) > len(words[j]) })
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	exp := regexp.MustCompile("(?i)" + strings.Join(words, "|"))
+	return exp.ReplaceAllString(value, highlightStart+"$0"+highlightStop)
+}
+
+func newSearch[T any](db *gorm.DB, text string) (*Search, []string, error) {
+	model := new(T)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, nil, err
+	}
+	fields, ok := any(*model).(searchFields)
+	if !ok {
+		return nil, nil, invalidFilter("%s cannot be searched", stmt.Schema.Table)
+	}
+	if len(SearchWords(text)) == 0 {
+		return nil, nil, filter.Invalid("search", "search %q has no words", text)
+	}
+
+	columns := []string{}
+	for _, name := range fields.SearchFields() {
+		field := findColumn(stmt.Schema, name)
+		if field == nil {
+			return nil, nil, fmt.Errorf("search field %s is not a column of %s", name, stmt.Schema.Table)
+		}
+		columns = append(columns, qualified(stmt.Schema, field))
+	}
+	return &Search{Table: stmt.Schema.Table, Title: columns[0]}, columns, nil
+}
+
+func qualified(s *schema.Schema, field *schema.Field) string {
+	return s.Table + "." + field.DBName
+}
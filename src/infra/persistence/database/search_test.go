@@ -0,0 +1,87 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/service_errors"
+)
+
+func TestGenerateSearch_Postgres(t *testing.T) {
+	search, err := GenerateSearch[model.Color](newPostgresDb(t), "dark red")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "(colors.search_vector @@ websearch_to_tsquery('simple', ?) OR colors.name % ? OR colors.hex_code % ?)"
+	if search.Condition.SQL != expected {
+		t.Errorf("Unexpected condition %q", search.Condition.SQL)
+	}
+	if !reflect.DeepEqual(search.Condition.Vars, []interface{}{"dark red", "dark red", "dark red"}) {
+		t.Errorf("Unexpected args %v", search.Condition.Vars)
+	}
+	if search.Title != "colors.name" || search.Highlight == nil {
+		t.Errorf("Expected the name highlighted, got %+v", search)
+	}
+
+	suggest, err := GenerateSuggest[model.Country](newPostgresDb(t), "New Yo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(suggest.Condition.Vars, []interface{}{"new yo%", "'new':* & 'yo':*"}) {
+		t.Errorf("Unexpected suggest args %v", suggest.Condition.Vars)
+	}
+}
+
+func TestGenerateSearch_Sqlite(t *testing.T) {
+	search, err := GenerateSearch[model.Country](newTestDb(t), "Iran!")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if search.Condition.SQL != "((lower(countries.name) LIKE ?))" || search.Highlight != nil {
+		t.Errorf("Unexpected search %+v", search)
+	}
+	if !reflect.DeepEqual(search.Condition.Vars, []interface{}{"%iran%"}) {
+		t.Errorf("Unexpected args %v", search.Condition.Vars)
+	}
+}
+
+func TestGenerateSearch_Invalid(t *testing.T) {
+	var serviceError *service_errors.ServiceError
+	if _, err := GenerateSearch[model.AuditLog](newTestDb(t), "x"); !errors.As(err, &serviceError) {
+		t.Errorf("Expected audit records not to be searchable, got %v", err)
+	}
+	if _, err := GenerateSearch[model.Country](newTestDb(t), " -- "); !errors.As(err, &serviceError) {
+		t.Errorf("Expected a search without words to be rejected, got %v", err)
+	}
+}
+
+func TestGenerateSearchOrder(t *testing.T) {
+	db := newPostgresDb(t)
+	f := filter.DynamicFilter{Search: "iran"}
+	query, args, err := GenerateDynamicQuery[model.Country](db, &f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if query != "countries.deleted_by is null AND (countries.search_vector @@ websearch_to_tsquery('simple', ?) OR countries.name % ?)" ||
+		len(args) != 2 {
+		t.Errorf("Unexpected query %q %v", query, args)
+	}
+
+	order, err := GenerateSearchOrder[model.Country](db, &f)
+	if err != nil || order == nil {
+		t.Fatalf("Expected results by relevance, got %v", err)
+	}
+	f.Sort = &[]filter.Sort{{ColId: "Name", Sort: "asc"}}
+	if order, err := GenerateSearchOrder[model.Country](db, &f); err != nil || order != nil {
+		t.Errorf("Expected the sort of the filter to win, got %v %v", order, err)
+	}
+}
+
+func TestHighlightText(t *testing.T) {
+	if got := HighlightText("New York", "york ne"); got != "<b>Ne</b>w <b>York</b>" {
+		t.Errorf("Unexpected highlight %q", got)
+	}
+}
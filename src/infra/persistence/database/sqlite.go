@@ -0,0 +1,60 @@
+package database
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"golang-clean-web-api/config"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSqlite   = "sqlite"
+)
+
+const sqliteMemory = ":memory:"
+
+func initSqlite(cfg *config.Config) error {
+	db, err := OpenSqlite(cfg.Postgres.Path)
+	if err != nil {
+		return err
+	}
+	dbClient = db
+	resolver = NewResolver(db)
+
+	log.Printf("Sqlite database %s opened", cfg.Postgres.Path)
+	return nil
+}
+
+// OpenSqlite opens the sqlite database at path, or a fresh one in memory for
+// :memory:, with foreign keys enforced. It keeps a single connection: sqlite
+// has one writer at a time and a memory database lives as long as its connection.
+func OpenSqlite(path string) (*gorm.DB, error) {
+	if path == "" {
+		path = sqliteMemory
+	}
+	dsn := "file::memory:?_pragma=foreign_keys(1)"
+	if path != sqliteMemory {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		dsn = path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	}
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDb.SetMaxOpenConns(1)
+	sqlDb.SetMaxIdleConns(1)
+	sqlDb.SetConnMaxLifetime(0)
+	return db, nil
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type transactionKey struct{}
+
+// TransactionManager runs a function in a transaction carried by its context.
+// Repositories called with that context join the transaction instead of
+// committing on their own, a nested Do becomes a savepoint.
+type TransactionManager struct {
+	database *gorm.DB
+}
+
+func NewTransactionManager(db *gorm.DB) *TransactionManager {
+	return &TransactionManager{database: db}
+}
+
+// Do commits when fn returns nil. An error or a panic rolls back everything fn
+// did, or only back to the savepoint when called inside another Do.
+func (m *TransactionManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Conn(ctx, m.database).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, transactionKey{}, tx))
+	})
+}
+
+// Conn returns the transaction of ctx, or db when there is none, bound to ctx
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
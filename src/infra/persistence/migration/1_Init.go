@@ -1,43 +1,96 @@
 package migration
 
 import (
+	"database/sql"
+	"time"
+
 	"golang-clean-web-api/config"
-	models "golang-clean-web-api/domain/model"
-	database "golang-clean-web-api/infra/persistence/database"
 	"golang-clean-web-api/pkg/logging"
 
 	"gorm.io/gorm"
 )
 
-const countStarExp = "count(*)"
-
 var logger = logging.NewLogger(config.GetConfig())
 
-func Up1() {
-	database := database.GetDb()
+// The tables of migration 1 are built from the structs below rather than from
+// the domain models, so a later change to a model does not change what this
+// migration creates without changing its checksum. Columns added since, like
+// roles and version, come with migrations of their own.
+
+type initBase struct {
+	Id int `gorm:"primarykey"`
+
+	CreatedAt  time.Time    `gorm:"not null"`
+	ModifiedAt sql.NullTime `gorm:"null"`
+	DeletedAt  sql.NullTime `gorm:"null"`
+
+	CreatedBy  int            `gorm:"not null"`
+	ModifiedBy *sql.NullInt64 `gorm:"null"`
+	DeletedBy  *sql.NullInt64 `gorm:"null"`
+}
+
+type initUser struct {
+	Base     initBase `gorm:"embedded"`
+	Username string   `gorm:"size:50;not null;unique"`
+	Password string   `gorm:"size:255;not null"`
+	Email    string   `gorm:"size:100;unique"`
+	IsActive bool     `gorm:"default:true"`
+}
+
+type initCountry struct {
+	Base initBase `gorm:"embedded"`
+	Name string   `gorm:"size:15;type:string;not null;"`
+}
+
+type initCity struct {
+	Base      initBase `gorm:"embedded"`
+	Name      string   `gorm:"size:10;type:string;not null;"`
+	CountryId int
+	Country   initCountry `gorm:"foreignKey:CountryId;constraint:fk_countries_cities,"`
+}
 
-	createTables(database)
-	createCountry(database)
-	createColor(database)
+type initCompany struct {
+	Base      initBase `gorm:"embedded"`
+	Name      string   `gorm:"size:20;type:string;not null,unique"`
+	CountryId int
+	Country   initCountry `gorm:"foreignKey:CountryId;constraint:fk_countries_companies,"`
 }
 
-func createTables(database *gorm.DB) {
+type initColor struct {
+	Base    initBase `gorm:"embedded"`
+	Name    string   `gorm:"size:15;type:string;not null,unique"`
+	HexCode string   `gorm:"size:7;type:string;not null,unique"`
+}
+
+func (initUser) TableName() string    { return "users" }
+func (initCountry) TableName() string { return "countries" }
+func (initCity) TableName() string    { return "cities" }
+func (initCompany) TableName() string { return "companies" }
+func (initColor) TableName() string   { return "colors" }
+
+func Up1(database *gorm.DB) error {
+	return createTables(database)
+}
+
+func createTables(database *gorm.DB) error {
 	tables := []interface{}{}
 
 	// Authentication
-	tables = addNewTable(database, models.User{}, tables)
+	tables = addNewTable(database, initUser{}, tables)
 
 	// Basic entities
-	tables = addNewTable(database, models.Country{}, tables)
-	tables = addNewTable(database, models.City{}, tables)
-	tables = addNewTable(database, models.Company{}, tables)
-	tables = addNewTable(database, models.Color{}, tables)
+	tables = addNewTable(database, initCountry{}, tables)
+	tables = addNewTable(database, initCity{}, tables)
+	tables = addNewTable(database, initCompany{}, tables)
+	tables = addNewTable(database, initColor{}, tables)
 
 	err := database.Migrator().CreateTable(tables...)
 	if err != nil {
 		logger.Error(logging.Postgres, logging.Migration, err.Error(), nil)
+		return err
 	}
 	logger.Info(logging.Postgres, logging.Migration, "tables created", nil)
+	return nil
 }
 
 func addNewTable(database *gorm.DB, model interface{}, tables []interface{}) []interface{} {
@@ -47,88 +100,12 @@ func addNewTable(database *gorm.DB, model interface{}, tables []interface{}) []i
 	return tables
 }
 
-func createCountry(database *gorm.DB) {
-	count := 0
-	database.
-		Model(&models.Country{}).
-		Select(countStarExp).
-		Find(&count)
-	if count == 0 {
-		database.Create(&models.Country{Name: "Iran", Cities: []models.City{
-			{Name: "Tehran"},
-			{Name: "Isfahan"},
-			{Name: "Shiraz"},
-			{Name: "Chalus"},
-			{Name: "Ahwaz"},
-		}, Companies: []models.Company{
-			{Name: "Saipa"},
-			{Name: "Iran khodro"},
-		}})
-		database.Create(&models.Country{Name: "USA", Cities: []models.City{
-			{Name: "New York"},
-			{Name: "Washington"},
-		}, Companies: []models.Company{
-			{Name: "Tesla"},
-			{Name: "Jeep"},
-		}})
-		database.Create(&models.Country{Name: "Germany", Cities: []models.City{
-			{Name: "Berlin"},
-			{Name: "Munich"},
-		}, Companies: []models.Company{
-			{Name: "Opel"},
-			{Name: "Benz"},
-		}})
-		database.Create(&models.Country{Name: "China", Cities: []models.City{
-			{Name: "Beijing"},
-			{Name: "Shanghai"},
-		}, Companies: []models.Company{
-			{Name: "Chery"},
-			{Name: "Geely"},
-		}})
-		database.Create(&models.Country{Name: "Italy", Cities: []models.City{
-			{Name: "Roma"},
-			{Name: "Turin"},
-		}, Companies: []models.Company{
-			{Name: "Ferrari"},
-			{Name: "Fiat"},
-		}})
-		database.Create(&models.Country{Name: "France", Cities: []models.City{
-			{Name: "Paris"},
-			{Name: "Lyon"},
-		}, Companies: []models.Company{
-			{Name: "Renault"},
-			{Name: "Bugatti"},
-		}})
-		database.Create(&models.Country{Name: "Japan", Cities: []models.City{
-			{Name: "Tokyo"},
-			{Name: "Kyoto"},
-		}, Companies: []models.Company{
-			{Name: "Toyota"},
-			{Name: "Honda"},
-		}})
-		database.Create(&models.Country{Name: "South Korea", Cities: []models.City{
-			{Name: "Seoul"},
-			{Name: "Ulsan"},
-		}, Companies: []models.Company{
-			{Name: "Kia"},
-			{Name: "Hyundai"},
-		}})
-	}
-}
-
-func createColor(database *gorm.DB) {
-	count := 0
-	database.
-		Model(&models.Color{}).
-		Select(countStarExp).
-		Find(&count)
-	if count == 0 {
-		database.Create(&models.Color{Name: "Black", HexCode: "#000000"})
-		database.Create(&models.Color{Name: "White", HexCode: "#ffffff"})
-		database.Create(&models.Color{Name: "Blue", HexCode: "#0000ff"})
-	}
-}
-
-func Down1() {
-	// nothing
+func Down1(database *gorm.DB) error {
+	return database.Migrator().DropTable(
+		initColor{},
+		initCompany{},
+		initCity{},
+		initCountry{},
+		initUser{},
+	)
 }
@@ -0,0 +1,19 @@
+package migration
+
+import (
+	models "golang-clean-web-api/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// Up3 adds the comma separated roles a user carries in its tokens
+func Up3(database *gorm.DB) error {
+	if database.Migrator().HasColumn(&models.User{}, "Roles") {
+		return nil
+	}
+	return database.Migrator().AddColumn(&models.User{}, "Roles")
+}
+
+func Down3(database *gorm.DB) error {
+	return database.Migrator().DropColumn(&models.User{}, "Roles")
+}
@@ -0,0 +1,37 @@
+package migration
+
+import (
+	models "golang-clean-web-api/domain/model"
+
+	"gorm.io/gorm"
+)
+
+var versionedModels = []interface{}{
+	&models.User{},
+	&models.Country{},
+	&models.City{},
+	&models.Company{},
+	&models.Color{},
+}
+
+// Up4 adds the row version used for optimistic concurrency
+func Up4(database *gorm.DB) error {
+	for _, model := range versionedModels {
+		if database.Migrator().HasColumn(model, "Version") {
+			continue
+		}
+		if err := database.Migrator().AddColumn(model, "Version"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Down4(database *gorm.DB) error {
+	for _, model := range versionedModels {
+		if err := database.Migrator().DropColumn(model, "Version"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package migration
+
+import (
+	models "golang-clean-web-api/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// Up5 creates the audit log written alongside every entity write
+func Up5(database *gorm.DB) error {
+	if database.Migrator().HasTable(&models.AuditLog{}) {
+		return nil
+	}
+	return database.Migrator().CreateTable(&models.AuditLog{})
+}
+
+func Down5(database *gorm.DB) error {
+	return database.Migrator().DropTable(&models.AuditLog{})
+}
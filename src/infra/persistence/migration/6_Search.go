@@ -0,0 +1,69 @@
+package migration
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// searchTables are the searchable tables and the expression their search vector
+// is generated from, the search fields of the model
+var searchTables = []struct {
+	table    string
+	document string
+	columns  []string
+}{
+	{table: "countries", document: "coalesce(name, '')", columns: []string{"name"}},
+	{table: "cities", document: "coalesce(name, '')", columns: []string{"name"}},
+	{table: "companies", document: "coalesce(name, '')", columns: []string{"name"}},
+	{table: "colors", document: "coalesce(name, '') || ' ' || coalesce(hex_code, '')", columns: []string{"name", "hex_code"}},
+}
+
+// Up6 adds the generated tsvector column searched by full text, with a gin index,
+// and trigram indexes for fuzzy matching and prefix typeahead. Other dialects
+// search with LIKE and need nothing.
+func Up6(database *gorm.DB) error {
+	if database.Dialector.Name() != "postgres" {
+		return nil
+	}
+	if err := database.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return err
+	}
+	for _, t := range searchTables {
+		statements := []string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS search_vector tsvector "+
+				"GENERATED ALWAYS AS (to_tsvector('simple', %s)) STORED", t.table, t.document),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS ix_%[1]s_search_vector ON %[1]s USING gin (search_vector)", t.table),
+		}
+		for _, column := range t.columns {
+			statements = append(statements, fmt.Sprintf(
+				"CREATE INDEX IF NOT EXISTS ix_%[1]s_%[2]s_trgm ON %[1]s USING gin (%[2]s gin_trgm_ops)", t.table, column))
+		}
+		for _, statement := range statements {
+			if err := database.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Down6 keeps the pg_trgm extension, other schemas may use it
+func Down6(database *gorm.DB) error {
+	if database.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, t := range searchTables {
+		statements := []string{fmt.Sprintf("DROP INDEX IF EXISTS ix_%s_search_vector", t.table)}
+		for _, column := range t.columns {
+			statements = append(statements, fmt.Sprintf("DROP INDEX IF EXISTS ix_%s_%s_trgm", t.table, column))
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS search_vector", t.table))
+		for _, statement := range statements {
+			if err := database.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package migration
+
+import (
+	models "golang-clean-web-api/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// Up7 creates the outbox the repositories record domain events in
+func Up7(database *gorm.DB) error {
+	if database.Migrator().HasTable(&models.OutboxEvent{}) {
+		return nil
+	}
+	return database.Migrator().CreateTable(&models.OutboxEvent{})
+}
+
+func Down7(database *gorm.DB) error {
+	return database.Migrator().DropTable(&models.OutboxEvent{})
+}
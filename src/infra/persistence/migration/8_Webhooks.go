@@ -0,0 +1,26 @@
+package migration
+
+import (
+	models "golang-clean-web-api/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// Up8 creates the webhook subscriptions and the log of their deliveries
+func Up8(database *gorm.DB) error {
+	tables := []interface{}{}
+	if !database.Migrator().HasTable(&models.WebhookSubscription{}) {
+		tables = append(tables, &models.WebhookSubscription{})
+	}
+	if !database.Migrator().HasTable(&models.WebhookDelivery{}) {
+		tables = append(tables, &models.WebhookDelivery{})
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	return database.Migrator().CreateTable(tables...)
+}
+
+func Down8(database *gorm.DB) error {
+	return database.Migrator().DropTable(&models.WebhookDelivery{}, &models.WebhookSubscription{})
+}
@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"fmt"
+
+	models "golang-clean-web-api/domain/model"
+	"golang-clean-web-api/infra/persistence/database"
+
+	"gorm.io/gorm"
+)
+
+// historyModels keep the past versions of their rows, see model HistoryTable
+var historyModels = []interface{ HistoryTable() string }{
+	&models.Country{},
+	&models.City{},
+	&models.Company{},
+	&models.Color{},
+}
+
+// Up9 creates the history tables read as of a time. On postgres the history of
+// a searchable table gets the generated search vector of the table, so searches
+// in the past match the same way.
+func Up9(db *gorm.DB) error {
+	tables := map[string]bool{}
+	for _, model := range historyModels {
+		if err := database.CreateHistoryTable(db, model); err != nil {
+			return err
+		}
+		tables[model.HistoryTable()] = true
+	}
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, t := range searchTables {
+		history := t.table + "_history"
+		if !tables[history] {
+			continue
+		}
+		err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS search_vector tsvector "+
+			"GENERATED ALWAYS AS (to_tsvector('simple', %s)) STORED", history, t.document)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Down9(db *gorm.DB) error {
+	for _, model := range historyModels {
+		if err := db.Migrator().DropTable(model.HistoryTable()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
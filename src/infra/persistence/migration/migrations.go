@@ -0,0 +1,119 @@
+package migration
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// goMigrations are the migrations written in go. Add new ones at the end with the next version.
+var goMigrations = []Migration{
+	{Version: 1, Name: "Init", Up: Up1, Down: Down1},
+	{Version: 3, Name: "UserRoles", Up: Up3, Down: Down3},
+	{Version: 4, Name: "RowVersion", Up: Up4, Down: Down4},
+	{Version: 5, Name: "AuditLog", Up: Up5, Down: Down5},
+	{Version: 6, Name: "Search", Up: Up6, Down: Down6},
+	{Version: 7, Name: "Outbox", Up: Up7, Down: Down7},
+	{Version: 8, Name: "Webhooks", Up: Up8, Down: Down8},
+	{Version: 9, Name: "History", Up: Up9, Down: Down9},
+}
+
+// goSources holds the source of the go migrations, named <version>_<Name>.go, so
+// their checksum changes with their code like the one of a sql migration
+//
+//go:embed [0-9]*_*.go
+var goSources embed.FS
+
+// sqlFiles holds file based migrations named <version>_<Name>.up.sql and <version>_<Name>.down.sql
+//
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+var sqlFileExp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migrations returns the go and sql migrations ordered by version
+func Migrations() ([]Migration, error) {
+	migrations := []Migration{}
+	for _, migration := range goMigrations {
+		if migration.Checksum == "" {
+			source, err := goSources.ReadFile(fmt.Sprintf("%d_%s.go", migration.Version, migration.Name))
+			if err != nil {
+				return nil, fmt.Errorf("migration %d %s has no source file: %w", migration.Version, migration.Name, err)
+			}
+			migration.Checksum = checksum(string(source))
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sqlMigrations, err := loadSqlMigrations()
+	if err != nil {
+		return nil, err
+	}
+	migrations = append(migrations, sqlMigrations...)
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+func loadSqlMigrations() ([]Migration, error) {
+	entries, err := sqlFiles.ReadDir("sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		parts := sqlFileExp.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(parts[1])
+		content, err := sqlFiles.ReadFile(path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = migration
+		} else if migration.Name != parts[2] {
+			return nil, fmt.Errorf("migration %d has files with different names", version)
+		}
+		if parts[3] == "up" {
+			migration.UpSql = string(content)
+		} else {
+			migration.DownSql = string(content)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.UpSql == "" {
+			return nil, fmt.Errorf("migration %d %s has no up file", migration.Version, migration.Name)
+		}
+		migration.Checksum = checksum(migration.UpSql)
+		migration.Up = execSql(migration.UpSql)
+		if migration.DownSql != "" {
+			migration.Down = execSql(migration.DownSql)
+		}
+		migrations = append(migrations, *migration)
+	}
+	return migrations, nil
+}
+
+func execSql(statements string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Exec(statements).Error
+	}
+}
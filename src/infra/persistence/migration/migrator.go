@@ -0,0 +1,286 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang-clean-web-api/pkg/logging"
+
+	"gorm.io/gorm"
+)
+
+// advisoryLockKey identifies the postgres advisory lock held while migrating,
+// so several instances starting together apply each migration only once
+const advisoryLockKey int64 = 7_205_114_117
+
+// Migration is a single versioned schema change. Up and Down run inside a
+// transaction together with the schema_migrations bookkeeping.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       func(tx *gorm.DB) error
+	Down     func(tx *gorm.DB) error
+	Checksum string
+
+	// UpSql and DownSql keep the statements of file based migrations for dry-run output
+	UpSql   string
+	DownSql string
+}
+
+type MigrationStatus struct {
+	Version          int        `json:"version"`
+	Name             string     `json:"name"`
+	Applied          bool       `json:"applied"`
+	AppliedAt        *time.Time `json:"appliedAt,omitempty"`
+	ChecksumMismatch bool       `json:"checksumMismatch"`
+	// Unknown marks a version recorded in the history table that this binary does not ship
+	Unknown bool `json:"unknown"`
+}
+
+// schemaMigration is a row of the history table
+type schemaMigration struct {
+	Version     int       `gorm:"primaryKey;autoIncrement:false"`
+	Name        string    `gorm:"size:255;not null"`
+	Checksum    string    `gorm:"size:64;not null"`
+	AppliedAt   time.Time `gorm:"not null"`
+	ExecutionMs int64     `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Migrator struct {
+	database   *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a migrator over all registered go and sql migrations
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{database: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration in version order and returns the applied ones.
+// With dryRun nothing is executed and the pending migrations are returned.
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]Migration, error) {
+	unlock, err := m.lock(ctx, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.verify(applied); err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	if dryRun {
+		return pending, nil
+	}
+
+	for i, migration := range pending {
+		if err = m.run(ctx, migration, true); err != nil {
+			return pending[:i], err
+		}
+	}
+	if len(pending) == 0 {
+		logger.Info(logging.Postgres, logging.Migration, "schema is up to date", nil)
+	}
+	return pending, nil
+}
+
+// Down reverts, newest first, every applied migration with a version greater than toVersion
+func (m *Migrator) Down(ctx context.Context, toVersion int, dryRun bool) ([]Migration, error) {
+	unlock, err := m.lock(ctx, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[int]Migration{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	versions := []int{}
+	for version := range applied {
+		if version > toVersion {
+			versions = append(versions, version)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	reverting := []Migration{}
+	for _, version := range versions {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("migration %d is applied but unknown to this build", version)
+		}
+		if migration.Down == nil {
+			return nil, fmt.Errorf("migration %d %s is irreversible", migration.Version, migration.Name)
+		}
+		reverting = append(reverting, migration)
+	}
+	if dryRun {
+		return reverting, nil
+	}
+
+	for i, migration := range reverting {
+		if err = m.run(ctx, migration, false); err != nil {
+			return reverting[:i], err
+		}
+	}
+	return reverting, nil
+}
+
+// Status reports every known migration and every recorded version
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []MigrationStatus{}
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+			status.ChecksumMismatch = row.Checksum != migration.Checksum
+			delete(applied, migration.Version)
+		}
+		result = append(result, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		result = append(result, MigrationStatus{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+func (m *Migrator) run(ctx context.Context, migration Migration, up bool) error {
+	start := time.Now()
+	err := m.database.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if !up {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, migration.Version).Error
+		}
+
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&schemaMigration{
+			Version:     migration.Version,
+			Name:        migration.Name,
+			Checksum:    migration.Checksum,
+			AppliedAt:   time.Now().UTC(),
+			ExecutionMs: time.Since(start).Milliseconds(),
+		}).Error
+	})
+
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+	if err != nil {
+		logger.Error(logging.Postgres, logging.Migration,
+			fmt.Sprintf("migration %d %s %s failed: %s", migration.Version, migration.Name, direction, err.Error()), nil)
+		return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	logger.Info(logging.Postgres, logging.Migration,
+		fmt.Sprintf("migration %d %s %s done in %s", migration.Version, migration.Name, direction, time.Since(start)), nil)
+	return nil
+}
+
+// applied loads the history table. When readOnly is set a missing table is
+// reported as an empty history instead of being created.
+func (m *Migrator) applied(ctx context.Context, readOnly bool) (map[int]schemaMigration, error) {
+	db := m.database.WithContext(ctx)
+	result := map[int]schemaMigration{}
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		if readOnly {
+			return result, nil
+		}
+		if err := db.Migrator().CreateTable(&schemaMigration{}); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := []schemaMigration{}
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// verify refuses to migrate when an applied migration was edited afterwards
+func (m *Migrator) verify(applied map[int]schemaMigration) error {
+	for _, migration := range m.migrations {
+		if row, ok := applied[migration.Version]; ok && row.Checksum != migration.Checksum {
+			return fmt.Errorf("migration %d %s: checksum mismatch, applied %s but found %s",
+				migration.Version, migration.Name, row.Checksum, migration.Checksum)
+		}
+	}
+	return nil
+}
+
+// lock takes the postgres advisory lock on a dedicated connection. Other
+// dialects, and dry runs, do not lock.
+func (m *Migrator) lock(ctx context.Context, dryRun bool) (func(), error) {
+	if dryRun || m.database.Dialector.Name() != "postgres" {
+		return func() {}, nil
+	}
+
+	sqlDb, err := m.database.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDb.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockKey); err != nil {
+			logger.Error(logging.Postgres, logging.Migration, err.Error(), nil)
+		}
+		conn.Close()
+	}, nil
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
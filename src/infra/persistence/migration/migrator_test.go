@@ -0,0 +1,162 @@
+package migration
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/infra/persistence/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestMigrator(t *testing.T, migrations []Migration) *Migrator {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return &Migrator{database: db, migrations: migrations}
+}
+
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 1, Name: "Books", Checksum: checksum("1"),
+			Up:   execSql("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT)"),
+			Down: execSql("DROP TABLE books")},
+		{Version: 2, Name: "BookAuthor", Checksum: checksum("2"),
+			Up:   execSql("ALTER TABLE books ADD COLUMN author TEXT"),
+			Down: execSql("ALTER TABLE books DROP COLUMN author")},
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMigrator(t, testMigrations())
+
+	planned, err := m.Up(ctx, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(planned) != 2 || m.database.Migrator().HasTable("books") {
+		t.Fatalf("Expected dry run to plan 2 migrations without executing, got %d", len(planned))
+	}
+
+	applied, err := m.Up(ctx, false)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if len(applied) != 2 || !m.database.Migrator().HasColumn("books", "author") {
+		t.Fatalf("Expected 2 applied migrations, got %d", len(applied))
+	}
+
+	applied, err = m.Up(ctx, false)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("Expected second up to be a no-op, got %d, %v", len(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, 1, false)
+	if err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != 2 || m.database.Migrator().HasColumn("books", "author") {
+		t.Fatalf("Expected migration 2 to be reverted, got %v", reverted)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Expected only migration 1 applied, got %+v", statuses)
+	}
+}
+
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	m := newTestMigrator(t, testMigrations())
+	if _, err := m.Up(ctx, false); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	m.migrations[0].Checksum = checksum("edited")
+	if _, err := m.Up(ctx, false); err == nil {
+		t.Error("Expected checksum mismatch error")
+	}
+	statuses, _ := m.Status(ctx)
+	if !statuses[0].ChecksumMismatch {
+		t.Errorf("Expected status to report checksum mismatch, got %+v", statuses[0])
+	}
+}
+
+func TestMigrations_GoChecksum(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	source, err := os.ReadFile("1_Init.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrations[0].Version != 1 || migrations[0].Checksum != checksum(string(source)) {
+		t.Errorf("Expected the checksum of the source of migration 1, got %+v", migrations[0])
+	}
+}
+
+func TestMigrations_Registered(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	for i, migration := range migrations {
+		if migration.Up == nil || migration.Checksum == "" {
+			t.Errorf("Migration %d %s is incomplete", migration.Version, migration.Name)
+		}
+		if i > 0 && migrations[i-1].Version >= migration.Version {
+			t.Errorf("Migrations are not ordered at %d", migration.Version)
+		}
+	}
+}
+
+func TestMigrations_InitIsFrozen(t *testing.T) {
+	db, err := database.OpenSqlite(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := Up1(db); err != nil {
+		t.Fatalf("Up1 failed: %v", err)
+	}
+	if db.Migrator().HasColumn(&model.User{}, "Roles") || db.Migrator().HasColumn(&model.Country{}, "Version") {
+		t.Error("Expected migration 1 to create the tables without the columns of later migrations")
+	}
+	if !db.Migrator().HasConstraint(&model.City{}, "fk_countries_cities") {
+		t.Error("Expected cities to reference countries")
+	}
+}
+
+func TestMigrations_Sqlite(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenSqlite(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	m, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	if _, err := m.Up(ctx, false); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if !db.Migrator().HasColumn(&model.Country{}, "Version") || !db.Migrator().HasTable(&model.AuditLog{}) {
+		t.Error("Expected every migration to be applied")
+	}
+	if _, err := m.Down(ctx, 0, false); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if db.Migrator().HasTable(&model.Country{}) {
+		t.Error("Expected every migration to be reverted")
+	}
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS ux_colors_hex_code;
+DROP INDEX IF EXISTS ux_colors_name;
+DROP INDEX IF EXISTS ux_countries_name;
@@ -0,0 +1,4 @@
+-- Reference data is identified by name, ignore soft deleted rows so a name can be reused
+CREATE UNIQUE INDEX IF NOT EXISTS ux_countries_name ON countries (name) WHERE deleted_by IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS ux_colors_name ON colors (name) WHERE deleted_by IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS ux_colors_hex_code ON colors (hex_code) WHERE deleted_by IS NULL;
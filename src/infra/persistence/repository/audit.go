@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/identity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// auditIgnored are bookkeeping columns, the audit record carries actor and time itself
+var auditIgnored = map[string]bool{
+	"created_at":  true,
+	"created_by":  true,
+	"modified_at": true,
+	"modified_by": true,
+	"version":     true,
+}
+
+// secretFields is implemented by models with columns that must not leave the table
+type secretFields interface {
+	SecretFields() []string
+}
+
+// redactedValue replaces a secret in audit records and domain events
+const redactedValue = "[redacted]"
+
+// redact returns the snapshot with the secret columns of the model masked
+func redact(sch *schema.Schema, row map[string]interface{}) map[string]interface{} {
+	secrets, ok := reflect.New(sch.ModelType).Interface().(secretFields)
+	if !ok || row == nil {
+		return row
+	}
+	redacted := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		redacted[column] = value
+	}
+	for _, name := range secrets.SecretFields() {
+		if field := sch.LookUpField(name); field != nil {
+			if _, ok := redacted[field.DBName]; ok {
+				redacted[field.DBName] = redactedValue
+			}
+		}
+	}
+	return redacted
+}
+
+// snapshot reads the columns of a row, nil when there is none
+func (r BaseRepository[TEntity]) snapshot(tx *gorm.DB, id int) (map[string]interface{}, error) {
+	return r.snapshotWhere(tx, "id = ?", id)
+}
+
+func (r BaseRepository[TEntity]) snapshotWhere(tx *gorm.DB, query string, args ...interface{}) (map[string]interface{}, error) {
+	rows := []map[string]interface{}{}
+	if err := tx.Model(new(TEntity)).Where(query, args...).Limit(1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// audit records a write in the transaction that made it. before and after are
+// snapshots of the row, nil when it did not exist.
+func (r BaseRepository[TEntity]) audit(tx *gorm.DB, action string, id int, before map[string]interface{}, after map[string]interface{}) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(new(TEntity)); err != nil {
+		return err
+	}
+	before, after = redact(stmt.Schema, before), redact(stmt.Schema, after)
+
+	changes, err := diff(stmt, before, after)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	ctx := tx.Statement.Context
+	entry := model.AuditLog{
+		EntityType: stmt.Schema.Table,
+		EntityId:   id,
+		Action:     action,
+		UserId:     -1,
+		RequestId:  identity.RequestId(ctx),
+		CreatedAt:  time.Now().UTC(),
+		Changes:    string(encoded),
+	}
+	if user, ok := identity.FromContext(ctx); ok && user.IsAuthenticated() {
+		entry.UserId = user.UserId
+		entry.Username = user.Username
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return err
+	}
+	return r.record(tx, stmt, &entry, before, after)
+}
+
+// auditCreate records a created entity with every column as new
+func (r BaseRepository[TEntity]) auditCreate(ctx context.Context, tx *gorm.DB, entity *TEntity) error {
+	id, err := primaryKey(ctx, tx, entity)
+	if err != nil {
+		return err
+	}
+	after, err := r.snapshot(tx, id)
+	if err != nil {
+		return err
+	}
+	return r.audit(tx, model.AuditCreate, id, nil, after)
+}
+
+// auditChange records a write against the row as it was before
+func (r BaseRepository[TEntity]) auditChange(tx *gorm.DB, action string, id int, before map[string]interface{}) error {
+	after, err := r.snapshot(tx, id)
+	if err != nil {
+		return err
+	}
+	return r.audit(tx, action, id, before, after)
+}
+
+func diff(stmt *gorm.Statement, before map[string]interface{}, after map[string]interface{}) ([]model.FieldChange, error) {
+	columns := map[string]bool{}
+	for column := range before {
+		columns[column] = true
+	}
+	for column := range after {
+		columns[column] = true
+	}
+	ordered := make([]string, 0, len(columns))
+	for column := range columns {
+		if !auditIgnored[column] {
+			ordered = append(ordered, column)
+		}
+	}
+	sort.Strings(ordered)
+
+	changes := []model.FieldChange{}
+	for _, column := range ordered {
+		oldValue, newValue := before[column], after[column]
+		same, err := equalJson(oldValue, newValue)
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			continue
+		}
+		name := column
+		if field := stmt.Schema.LookUpField(column); field != nil {
+			name = field.Name
+		}
+		changes = append(changes, model.FieldChange{Field: name, Old: oldValue, New: newValue})
+	}
+	return changes, nil
+}
+
+func equalJson(a interface{}, b interface{}) (bool, error) {
+	encodedA, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	encodedB, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return string(encodedA) == string(encodedB), nil
+}
+
+// primaryKey reads the id of a created entity
+func primaryKey(ctx context.Context, tx *gorm.DB, entity interface{}) (int, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(entity); err != nil {
+		return 0, err
+	}
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return 0, fmt.Errorf("%s has no primary key", stmt.Schema.Table)
+	}
+	value, _ := stmt.Schema.PrioritizedPrimaryField.ValueOf(ctx, reflect.Indirect(reflect.ValueOf(entity)))
+	id, ok := toInt(value)
+	if !ok {
+		return 0, fmt.Errorf("%s has a non int primary key", stmt.Schema.Table)
+	}
+	return id, nil
+}
+
+// toInt reads an id scanned by any driver
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	}
+	return 0, false
+}
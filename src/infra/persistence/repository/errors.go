@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"golang-clean-web-api/pkg/service_errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// pgErrorMessages maps the postgres error codes a client can cause to service errors
+var pgErrorMessages = map[string]string{
+	"23505": service_errors.DuplicateRecord,
+	"23503": service_errors.InvalidReference,
+	"23502": service_errors.RequiredValue,
+	"23514": service_errors.InvalidValue,
+	"22001": service_errors.ValueTooLong,
+}
+
+// keyDetailExp reads the first column of a detail like Key (name)=(Black) already exists.
+var keyDetailExp = regexp.MustCompile(`^Key \(([^,)]+)`)
+
+// translate turns database errors into service errors that name the offending
+// field, and the ones a client cannot cause into UnExpectedError. The statement
+// and the values stay in the technical message.
+func (r BaseRepository[TEntity]) translate(err error) error {
+	var serviceError *service_errors.ServiceError
+	if err == nil || errors.As(err, &serviceError) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound, Err: err}
+	}
+
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		message, ok := pgErrorMessages[pgError.Code]
+		if !ok {
+			return unexpected(err)
+		}
+		column := pgError.ColumnName
+		if match := keyDetailExp.FindStringSubmatch(pgError.Detail); column == "" && match != nil {
+			column = match[1]
+		}
+		if message == service_errors.InvalidReference && strings.Contains(pgError.Detail, "still referenced") {
+			// the row itself is referenced, not missing a reference
+			message, column = service_errors.RecordInUse, ""
+		}
+		return &service_errors.ServiceError{
+			EndUserMessage:   message,
+			TechnicalMessage: strings.TrimSpace(pgError.Message + " " + pgError.Detail),
+			Field:            r.fieldName(pgError.TableName, column),
+			Err:              err,
+		}
+	}
+
+	// dialects that translate their errors for gorm, without naming the column
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.DuplicateRecord, TechnicalMessage: err.Error(), Err: err}
+	}
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.InvalidReference, TechnicalMessage: err.Error(), Err: err}
+	}
+	return unexpected(err)
+}
+
+// unexpected hides an error a client cannot cause behind a generic message, the
+// response must not carry the statement or the driver's wording
+func unexpected(err error) error {
+	return &service_errors.ServiceError{EndUserMessage: service_errors.UnExpectedError, TechnicalMessage: err.Error(), Err: err}
+}
+
+// referenced tells whether a delete failed because other rows still reference
+// the row
+func referenced(err error) bool {
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) {
+		return pgError.Code == "23503"
+	}
+	return errors.Is(err, gorm.ErrForeignKeyViolated)
+}
+
+// fieldName returns the go name of a column of the entity, the column itself
+// when it belongs to another table
+func (r BaseRepository[TEntity]) fieldName(table string, column string) string {
+	if column == "" {
+		return ""
+	}
+	stmt := &gorm.Statement{DB: r.database}
+	if err := stmt.Parse(new(TEntity)); err != nil || (table != "" && table != stmt.Schema.Table) {
+		return column
+	}
+	if field := stmt.Schema.LookUpField(column); field != nil {
+		return field.Name
+	}
+	return column
+}
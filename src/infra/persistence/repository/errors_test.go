@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/service_errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestBaseRepository_Translate(t *testing.T) {
+	repo, _ := newTestRepository[model.City](t)
+
+	cases := []struct {
+		err     error
+		message string
+		field   string
+	}{
+		{&pgconn.PgError{Code: "23505", TableName: "cities", Detail: "Key (name)=(Tehran) already exists."},
+			service_errors.DuplicateRecord, "Name"},
+		{&pgconn.PgError{Code: "23503", TableName: "cities", Detail: `Key (country_id)=(99) is not present in table "countries".`},
+			service_errors.InvalidReference, "CountryId"},
+		{&pgconn.PgError{Code: "23503", TableName: "cities", Detail: `Key (id)=(1) is still referenced from table "companies".`},
+			service_errors.RecordInUse, ""},
+		{&pgconn.PgError{Code: "23502", TableName: "cities", ColumnName: "name"},
+			service_errors.RequiredValue, "Name"},
+		{&pgconn.PgError{Code: "22001", Message: "value too long for type character varying(10)"},
+			service_errors.ValueTooLong, ""},
+		{fmt.Errorf("wrapped: %w", gorm.ErrRecordNotFound), service_errors.RecordNotFound, ""},
+		{gorm.ErrDuplicatedKey, service_errors.DuplicateRecord, ""},
+	}
+	for _, c := range cases {
+		var serviceError *service_errors.ServiceError
+		if !errors.As(repo.translate(c.err), &serviceError) {
+			t.Errorf("%v: expected a service error", c.err)
+			continue
+		}
+		if serviceError.EndUserMessage != c.message || serviceError.Field != c.field {
+			t.Errorf("%v: expected %q on %q, got %q on %q", c.err, c.message, c.field, serviceError.EndUserMessage, serviceError.Field)
+		}
+		if strings.Contains(serviceError.Error(), "Key (") {
+			t.Errorf("%v: expected no database detail in %q", c.err, serviceError.Error())
+		}
+	}
+
+	for _, other := range []error{
+		&pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"},
+		errors.New(`pq: relation "cities" does not exist`),
+	} {
+		var serviceError *service_errors.ServiceError
+		if !errors.As(repo.translate(other), &serviceError) || serviceError.EndUserMessage != service_errors.UnExpectedError ||
+			serviceError.Err != other {
+			t.Errorf("%v: expected errors a client cannot cause to be hidden, got %v", other, serviceError)
+		}
+	}
+}
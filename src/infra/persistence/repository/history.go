@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"time"
+
+	database "golang-clean-web-api/infra/persistence/database"
+
+	"gorm.io/gorm"
+)
+
+// archive keeps the rows of the where clause in the history table of the model,
+// if it has one, before a write replaces them at the time given. It must run in
+// the transaction of the write, so a write that fails keeps no history.
+func (r BaseRepository[TEntity]) archive(tx *gorm.DB, at time.Time, query string, args ...interface{}) error {
+	statement, err := database.GenerateArchive[TEntity](tx, at, query, args...)
+	if err != nil || statement == nil {
+		return err
+	}
+	return tx.Exec(statement.SQL, statement.Vars...).Error
+}
+
+// asOf reads the rows as they were at the time, see database.GenerateAsOf. A nil
+// time reads the current rows.
+func (r BaseRepository[TEntity]) asOf(db *gorm.DB, at *time.Time) (*gorm.DB, error) {
+	if at == nil {
+		return db, nil
+	}
+	table, err := database.GenerateAsOf[TEntity](r.database, *at)
+	if err != nil {
+		return nil, err
+	}
+	return db.Table(table.SQL, table.Vars...), nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/model"
+	database "golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/pkg/metrics"
+
+	"gorm.io/gorm"
+)
+
+// maxErrorLength fits the last error of an event or a webhook delivery into its column
+const maxErrorLength = 500
+
+// record writes the domain event of an audited write to the outbox, in the same
+// transaction. The payload is the row after the write, before it for deletes,
+// keyed by field name.
+func (r BaseRepository[TEntity]) record(tx *gorm.DB, stmt *gorm.Statement, entry *model.AuditLog,
+	before map[string]interface{}, after map[string]interface{}) error {
+	eventType, ok := model.AuditEvents[entry.Action]
+	if !ok {
+		return nil
+	}
+	row := after
+	if row == nil {
+		row = before
+	}
+	fields := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		name := column
+		if field := stmt.Schema.LookUpField(column); field != nil {
+			name = field.Name
+		}
+		fields[name] = value
+	}
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&model.OutboxEvent{
+		EventType:     eventType,
+		AggregateType: entry.EntityType,
+		AggregateId:   entry.EntityId,
+		Payload:       string(payload),
+		UserId:        entry.UserId,
+		RequestId:     entry.RequestId,
+		CreatedAt:     entry.CreatedAt,
+		NextAttemptAt: entry.CreatedAt,
+	}).Error
+}
+
+// OutboxRepository hands the events recorded by the entity repositories to the relay
+type OutboxRepository struct {
+	database *gorm.DB
+	logger   logging.Logger
+}
+
+func NewOutboxRepository(cfg *config.Config) *OutboxRepository {
+	return &OutboxRepository{
+		database: database.GetDb(),
+		logger:   logging.NewLogger(cfg),
+	}
+}
+
+// relayLockKey identifies the postgres advisory lock held by the relay that publishes
+const relayLockKey int64 = 7_205_114_118
+
+// Lock takes the postgres advisory lock on a dedicated connection, without waiting
+// for it. Other dialects run in a single process and do not lock.
+func (r *OutboxRepository) Lock(ctx context.Context) (func(), bool, error) {
+	if r.database.Dialector.Name() != database.DriverPostgres {
+		return func() {}, true, nil
+	}
+
+	sqlDb, err := r.database.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDb.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	locked := false
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", relayLockKey).Scan(&locked); err != nil || !locked {
+		conn.Close()
+		return nil, false, err
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", relayLockKey); err != nil {
+			r.logger.Error(logging.Postgres, logging.Outbox, err.Error(), nil)
+		}
+		conn.Close()
+	}, true, nil
+}
+
+// Pending returns the oldest unpublished event of every aggregate when it is due,
+// oldest first. A later event waits until the ones before it are published.
+func (r *OutboxRepository) Pending(ctx context.Context, now time.Time, limit int) ([]model.OutboxEvent, error) {
+	events := []model.OutboxEvent{}
+	err := database.Conn(ctx, r.database).
+		Where("published_at is null and next_attempt_at <= ?", now).
+		Where(`not exists (SELECT 1 FROM outbox_events e WHERE e.aggregate_type = outbox_events.aggregate_type
+			AND e.aggregate_id = outbox_events.aggregate_id AND e.published_at is null AND e.id < outbox_events.id)`).
+		Order("id").
+		Limit(limit).
+		Find(&events).
+		Error
+	if err != nil {
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(model.OutboxEvent{}).String(), "Pending", "Failed").Inc()
+		return nil, err
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(model.OutboxEvent{}).String(), "Pending", "Success").Inc()
+	return events, nil
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id int, at time.Time) error {
+	return r.update(ctx, "MarkPublished", id, map[string]interface{}{
+		"published_at": sql.NullTime{Valid: true, Time: at},
+		"attempts":     gorm.Expr("attempts + 1"),
+		"last_error":   "",
+	})
+}
+
+// MarkFailed counts a failed attempt and holds the event back until next
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int, next time.Time, reason string) error {
+	return r.update(ctx, "MarkFailed", id, map[string]interface{}{
+		"next_attempt_at": next,
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      truncate(reason, maxErrorLength),
+	})
+}
+
+// truncate cuts a message to at most length bytes without splitting a character
+func truncate(message string, length int) string {
+	if len(message) <= length {
+		return message
+	}
+	return strings.ToValidUTF8(message[:length], "")
+}
+
+func (r *OutboxRepository) update(ctx context.Context, method string, id int, values map[string]interface{}) error {
+	err := database.Conn(ctx, r.database).
+		Model(&model.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(values).
+		Error
+	if err != nil {
+		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(model.OutboxEvent{}).String(), method, "Failed").Inc()
+		return err
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(model.OutboxEvent{}).String(), method, "Success").Inc()
+	return nil
+}
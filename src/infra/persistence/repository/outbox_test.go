@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/identity"
+	"golang-clean-web-api/pkg/logging"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	repo, db := newTestRepository[model.Color](t)
+	cfg := &config.Config{Logger: config.LoggerConfig{Logger: "zap", FilePath: t.TempDir() + "/", Level: "error"}}
+	outbox := &OutboxRepository{database: db, logger: logging.NewLogger(cfg)}
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7, Username: "tester", RequestId: "req-7"})
+
+	black, err := repo.Create(ctx, model.Color{Name: "Black", HexCode: "#000000"})
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	white, err := repo.Create(ctx, model.Color{Name: "White", HexCode: "#ffffff"})
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	if _, err := repo.Update(ctx, black.Id, map[string]interface{}{"Name": "Ink"}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := repo.Delete(ctx, black.Id); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if err := repo.Purge(ctx, black.Id); err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+
+	var events []model.OutboxEvent
+	if err := db.Order("id").Find(&events).Error; err != nil {
+		t.Fatalf("Failed to read the outbox: %v", err)
+	}
+	types := []string{}
+	for _, e := range events {
+		types = append(types, fmt.Sprintf("%s:%d", e.EventType, e.AggregateId))
+		if e.AggregateType != "colors" || e.UserId != 7 || e.RequestId != "req-7" {
+			t.Errorf("Unexpected event %+v", e)
+		}
+	}
+	expected := fmt.Sprintf("[EntityCreated:%d EntityCreated:%d EntityUpdated:%d EntityDeleted:%d]", black.Id, white.Id, black.Id, black.Id)
+	if fmt.Sprint(types) != expected {
+		t.Fatalf("Expected %s without an event for the purge, got %v", expected, types)
+	}
+	if !strings.Contains(events[2].Payload, `"Name":"Ink"`) || !strings.Contains(events[3].Payload, `"DeletedBy":7`) {
+		t.Errorf("Expected the row after the write as payload, got %s and %s", events[2].Payload, events[3].Payload)
+	}
+
+	now := time.Now().UTC().Add(time.Second)
+	pending, err := outbox.Pending(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("Failed to read pending events: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Id != events[0].Id || pending[1].Id != events[1].Id {
+		t.Fatalf("Expected the first event of every color, got %+v", pending)
+	}
+
+	if err := outbox.MarkFailed(ctx, events[0].Id, now.Add(time.Minute), "sink is down"); err != nil {
+		t.Fatalf("Failed to mark failed: %v", err)
+	}
+	if err := outbox.MarkPublished(ctx, events[1].Id, now); err != nil {
+		t.Fatalf("Failed to mark published: %v", err)
+	}
+	if pending, _ = outbox.Pending(ctx, now, 10); len(pending) != 0 {
+		t.Fatalf("Expected the failed event and its successors to wait, got %+v", pending)
+	}
+
+	pending, _ = outbox.Pending(ctx, now.Add(2*time.Minute), 10)
+	if len(pending) != 1 || pending[0].Id != events[0].Id || pending[0].Attempts != 1 || pending[0].LastError != "sink is down" {
+		t.Fatalf("Expected the failed event to be retried, got %+v", pending)
+	}
+	if err := outbox.MarkPublished(ctx, events[0].Id, now); err != nil {
+		t.Fatalf("Failed to mark published: %v", err)
+	}
+	if pending, _ = outbox.Pending(ctx, now, 10); len(pending) != 1 || pending[0].Id != events[2].Id {
+		t.Fatalf("Expected the update next, got %+v", pending)
+	}
+}
@@ -3,14 +3,19 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
 	"time"
 
 	"golang-clean-web-api/common"
 	"golang-clean-web-api/config"
-	"golang-clean-web-api/constant"
 	filter "golang-clean-web-api/domain/filter"
+	model_ "golang-clean-web-api/domain/model"
 	database "golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/concurrency"
+	"golang-clean-web-api/pkg/identity"
 	"golang-clean-web-api/pkg/logging"
 	"golang-clean-web-api/pkg/metrics"
 	"golang-clean-web-api/pkg/service_errors"
@@ -19,129 +24,478 @@ import (
 )
 
 const softDeleteExp string = "id = ? and deleted_by is null"
+const deletedExp string = "id = ? and deleted_by is not null"
+const createBatchSize int = 100
 
 type BaseRepository[TEntity any] struct {
-	database *gorm.DB
-	logger   logging.Logger
-	preloads []database.PreloadEntity
+	database     *gorm.DB
+	resolver     *database.Resolver
+	logger       logging.Logger
+	preloads     []database.PreloadEntity
+	cursorSecret []byte
 }
 
 func NewBaseRepository[TEntity any](cfg *config.Config, preloads []database.PreloadEntity) *BaseRepository[TEntity] {
 	return &BaseRepository[TEntity]{
-		database: database.GetDb(),
-		logger:   logging.NewLogger(cfg),
-		preloads: preloads,
+		database:     database.GetDb(),
+		resolver:     database.GetResolver(),
+		logger:       logging.NewLogger(cfg),
+		preloads:     preloads,
+		cursorSecret: []byte(cfg.Pagination.CursorSecret),
+	}
+}
+
+// NewBaseRepositoryOn returns a repository over db instead of the shared connection,
+// for tools like the seeder that open their own. It reads from db too.
+func NewBaseRepositoryOn[TEntity any](cfg *config.Config, db *gorm.DB) *BaseRepository[TEntity] {
+	return &BaseRepository[TEntity]{
+		database:     db,
+		logger:       logging.NewLogger(cfg),
+		cursorSecret: []byte(cfg.Pagination.CursorSecret),
 	}
 }
 
 func (r BaseRepository[TEntity]) Create(ctx context.Context, entity TEntity) (TEntity, error) {
-	tx := r.database.WithContext(ctx).Begin()
-	err := tx.
-		Create(&entity).
-		Error
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(&entity).Error; err != nil {
+			return err
+		}
+		return r.auditCreate(ctx, tx, &entity)
+	})
 	if err != nil {
-		tx.Rollback()
-		r.logger.Error(logging.Postgres, logging.Insert, err.Error(), nil)
-		metrics.DbCall.WithLabelValues(reflect.TypeOf(entity).String(), "Create", "Failed").Inc()
-		return entity, err
+		return entity, r.failed(logging.Insert, "Create", err)
 	}
-	tx.Commit()
 
 	metrics.DbCall.WithLabelValues(reflect.TypeOf(entity).String(), "Create", "Success").Inc()
 	return entity, nil
 }
 
+// CreateMany inserts the entities in batches of createBatchSize, in one transaction
+func (r BaseRepository[TEntity]) CreateMany(ctx context.Context, entities []TEntity) ([]TEntity, error) {
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&entities, createBatchSize).Error; err != nil {
+			return err
+		}
+		for i := range entities {
+			if err := r.auditCreate(ctx, tx, &entities[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return entities, r.failed(logging.Insert, "CreateMany", err)
+	}
+
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(TEntity)).String(), "CreateMany", "Success").Inc()
+	return entities, nil
+}
+
+// Update writes the changes and bumps the row version. When ctx carries an expected
+// version (see concurrency.NewContext) a row that has moved on is left untouched
+// and a ConcurrencyConflict error is returned.
 func (r BaseRepository[TEntity]) Update(ctx context.Context, id int, entity map[string]interface{}) (TEntity, error) {
 	snakeMap := map[string]interface{}{}
 	for k, v := range entity {
 		snakeMap[common.ToSnakeCase(k)] = v
 	}
-	if userId := ctx.Value(constant.UserIdKey); userId != nil {
-		if userIdFloat, ok := userId.(float64); ok {
-			snakeMap["modified_by"] = &sql.NullInt64{Int64: int64(userIdFloat), Valid: true}
-		}
+	if userId, ok := identity.UserId(ctx); ok {
+		snakeMap["modified_by"] = &sql.NullInt64{Int64: int64(userId), Valid: true}
 	}
-	snakeMap["modified_at"] = sql.NullTime{Valid: true, Time: time.Now().UTC()}
+	now := time.Now().UTC()
+	snakeMap["modified_at"] = sql.NullTime{Valid: true, Time: now}
+	snakeMap["version"] = gorm.Expr("version + 1")
 	model := new(TEntity)
-	tx := r.database.WithContext(ctx).Begin()
-	if err := tx.Model(model).
-		Where(softDeleteExp, id).
-		Updates(snakeMap).
-		Error; err != nil {
-		tx.Rollback()
-		r.logger.Error(logging.Postgres, logging.Update, err.Error(), nil)
-		metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Update", "Failed").Inc()
-		return *model, err
+	updated := new(TEntity)
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		before, err := r.snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := r.archive(tx, now, softDeleteExp, id); err != nil {
+			return err
+		}
+		result := versioned(ctx, tx.Model(model).Where(softDeleteExp, id)).
+			Updates(snakeMap)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return r.unchanged(tx, id)
+		}
+		if err := tx.Where(softDeleteExp, id).First(updated).Error; err != nil {
+			return err
+		}
+		return r.auditChange(tx, model_.AuditUpdate, id, before)
+	})
+	if err != nil {
+		return *model, r.failed(logging.Update, "Update", err)
 	}
-	tx.Commit()
 	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Update", "Success").Inc()
-	return *model, nil
+	return *updated, nil
 }
 
 func (r BaseRepository[TEntity]) Delete(ctx context.Context, id int) error {
-	tx := r.database.WithContext(ctx).Begin()
+	userId, ok := identity.UserId(ctx)
+	if !ok {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
 
 	model := new(TEntity)
+	now := time.Now().UTC()
+	deleteMap := map[string]interface{}{
+		"deleted_at": sql.NullTime{Valid: true, Time: now},
+		"deleted_by": &sql.NullInt64{Int64: int64(userId), Valid: true},
+		"version":    gorm.Expr("version + 1"),
+	}
+
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		before, err := r.snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := r.archive(tx, now, softDeleteExp, id); err != nil {
+			return err
+		}
+		result := versioned(ctx, tx.Model(model).Where(softDeleteExp, id)).
+			Updates(deleteMap)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return r.unchanged(tx, id)
+		}
+		return r.auditChange(tx, model_.AuditDelete, id, before)
+	})
+	if err != nil {
+		return r.failed(logging.Update, "Delete", err)
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Delete", "Success").Inc()
+	return nil
+}
+
+// versioned restricts a write to the version the caller expects, if any
+func versioned(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if version, ok := concurrency.FromContext(ctx); ok {
+		return db.Where("version = ?", version)
+	}
+	return db
+}
+
+// unchanged explains why a write matched no row: the row is gone, or it exists
+// with another version than the caller expected
+func (r BaseRepository[TEntity]) unchanged(tx *gorm.DB, id int) error {
+	var count int64
+	if err := tx.Model(new(TEntity)).Where(softDeleteExp, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.ConcurrencyConflict}
+	}
+	return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+}
+
+// transaction runs fn in a transaction of its own, or in a savepoint of the
+// transaction ctx carries (see database.TransactionManager)
+func (r BaseRepository[TEntity]) transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	database.MarkWritten(ctx)
+	return database.Conn(ctx, r.database).Transaction(fn)
+}
+
+// reader returns the connection for reads, a replica when one can serve ctx
+func (r BaseRepository[TEntity]) reader(ctx context.Context) *gorm.DB {
+	if r.resolver == nil {
+		return database.Conn(ctx, r.database)
+	}
+	return r.resolver.Reader(ctx)
+}
+
+// writer returns the connection for writes that need no transaction of their own
+func (r BaseRepository[TEntity]) writer(ctx context.Context) *gorm.DB {
+	database.MarkWritten(ctx)
+	return database.Conn(ctx, r.database)
+}
+
+// failed logs, counts and translates a failed write. Service errors are expected
+// outcomes like a missing row or a duplicate name and are only warned about.
+func (r BaseRepository[TEntity]) failed(subCategory logging.SubCategory, method string, err error) error {
+	translated := r.translate(err)
+	var serviceError *service_errors.ServiceError
+	if errors.As(translated, &serviceError) && serviceError.EndUserMessage != service_errors.UnExpectedError {
+		r.logger.Warn(logging.Postgres, subCategory, err.Error(), nil)
+	} else {
+		r.logger.Error(logging.Postgres, subCategory, err.Error(), nil)
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*new(TEntity)).String(), method, "Failed").Inc()
+	return translated
+}
 
-	if ctx.Value(constant.UserIdKey) == nil {
+// Restore brings a soft deleted row back, recording who restored it as the modifier
+func (r BaseRepository[TEntity]) Restore(ctx context.Context, id int) error {
+	userId, ok := identity.UserId(ctx)
+	if !ok {
 		return &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
 	}
 
-	deleteMap := map[string]interface{}{
-		"deleted_at": sql.NullTime{Valid: true, Time: time.Now().UTC()},
+	model := new(TEntity)
+	now := time.Now().UTC()
+	restoreMap := map[string]interface{}{
+		"deleted_at":  nil,
+		"deleted_by":  nil,
+		"modified_at": sql.NullTime{Valid: true, Time: now},
+		"modified_by": &sql.NullInt64{Int64: int64(userId), Valid: true},
+		"version":     gorm.Expr("version + 1"),
 	}
 
-	if userId := ctx.Value(constant.UserIdKey); userId != nil {
-		if userIdFloat, ok := userId.(float64); ok {
-			deleteMap["deleted_by"] = &sql.NullInt64{Int64: int64(userIdFloat), Valid: true}
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		before, err := r.snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := r.archive(tx, now, deletedExp, id); err != nil {
+			return err
+		}
+		result := tx.
+			Model(model).
+			Where(deletedExp, id).
+			Updates(restoreMap)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
 		}
+		return r.auditChange(tx, model_.AuditRestore, id, before)
+	})
+	if err != nil {
+		return r.failed(logging.Update, "Restore", err)
 	}
-	if cnt := tx.
-		Model(model).
-		Where(softDeleteExp, id).
-		Updates(deleteMap).
-		RowsAffected; cnt == 0 {
-		tx.Rollback()
-		r.logger.Error(logging.Postgres, logging.Update, service_errors.RecordNotFound, nil)
-		metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Delete", "Failed").Inc()
-		return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
-	}
-	tx.Commit()
-	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Delete", "Success").Inc()
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Restore", "Success").Inc()
 	return nil
 }
 
+// Purge permanently removes a soft deleted row
+func (r BaseRepository[TEntity]) Purge(ctx context.Context, id int) error {
+	model := new(TEntity)
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		before, err := r.snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := r.archive(tx, time.Now().UTC(), deletedExp, id); err != nil {
+			return err
+		}
+		result := tx.
+			Where(deletedExp, id).
+			Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+		}
+		return r.audit(tx, model_.AuditPurge, id, before, nil)
+	})
+	if err != nil {
+		return r.failed(logging.Purge, "Purge", err)
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Purge", "Success").Inc()
+	return nil
+}
+
+// PurgeDeletedBefore permanently removes the rows soft deleted before the given
+// time. Rows other rows still reference are kept for a later run, each row is
+// purged under a savepoint so skipping one keeps the others.
+func (r BaseRepository[TEntity]) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	model := new(TEntity)
+	var purged int64
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		rows := []map[string]interface{}{}
+		err := tx.Model(model).
+			Where("deleted_by is not null and deleted_at < ?", before).
+			Find(&rows).
+			Error
+		if err != nil {
+			return err
+		}
+		at := time.Now().UTC()
+		for _, row := range rows {
+			id, _ := toInt(row["id"])
+			if err := tx.SavePoint("purge").Error; err != nil {
+				return err
+			}
+			err := r.purgeRow(tx, at, id, row)
+			if referenced(err) {
+				if err := tx.RollbackTo("purge").Error; err != nil {
+					return err
+				}
+				r.logger.Warn(logging.Postgres, logging.Purge, fmt.Sprintf("row %d is still referenced and is kept", id), nil)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			purged++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, r.failed(logging.Purge, "PurgeDeletedBefore", err)
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "PurgeDeletedBefore", "Success").Inc()
+	return purged, nil
+}
+
+func (r BaseRepository[TEntity]) purgeRow(tx *gorm.DB, at time.Time, id int, row map[string]interface{}) error {
+	if err := r.archive(tx, at, "id = ?", id); err != nil {
+		return err
+	}
+	if err := tx.Where("id = ?", id).Delete(new(TEntity)).Error; err != nil {
+		return err
+	}
+	return r.audit(tx, model_.AuditPurge, id, row, nil)
+}
+
 func (r BaseRepository[TEntity]) GetById(ctx context.Context, id int) (TEntity, error) {
 	model := new(TEntity)
-	db := database.Preload(r.database, r.preloads)
-	err := db.
+	projection, err := filter.AsOfProjection(filter.AsOfFromContext(ctx), filter.ProjectionFromContext(ctx), nil)
+	if err != nil {
+		return *model, err
+	}
+	db, columns, err := r.read(ctx, projection)
+	if err != nil {
+		return *model, err
+	}
+	if columns != nil {
+		db = db.Select(columns)
+	}
+	if db, err = r.asOf(db, filter.AsOfFromContext(ctx)); err != nil {
+		return *model, err
+	}
+	err = db.
 		Where(softDeleteExp, id).
 		First(model).
 		Error
 	if err != nil {
 		metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "GetById", "Failed").Inc()
-		return *model, err
+		return *model, r.translate(err)
 	}
 	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "GetById", "Success").Inc()
 	return *model, nil
 }
 
 func (r BaseRepository[TEntity]) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]TEntity, error) {
+	return r.page(ctx, req, database.GenerateDynamicQuery[TEntity])
+}
+
+// GetDeleted pages through the soft deleted rows with the same filter engine
+func (r BaseRepository[TEntity]) GetDeleted(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]TEntity, error) {
+	return r.page(ctx, req, database.GenerateDeletedQuery[TEntity])
+}
+
+// GetHistory pages the audit records of one entity, oldest first unless sorted otherwise
+func (r BaseRepository[TEntity]) GetHistory(ctx context.Context, id int, req filter.PaginationInputWithFilter) (int64, *[]model_.AuditLog, error) {
+	stmt := &gorm.Statement{DB: r.database}
+	if err := stmt.Parse(new(TEntity)); err != nil {
+		return 0, &[]model_.AuditLog{}, err
+	}
+	req.AndWhere(
+		filter.Expression{Field: "EntityType", Filter: filter.Filter{Type: "equals", From: stmt.Schema.Table}},
+		filter.Expression{Field: "EntityId", Filter: filter.Filter{Type: "equals", From: strconv.Itoa(id)}},
+	)
+	if req.Sort == nil || len(*req.Sort) == 0 {
+		req.Sort = &[]filter.Sort{{ColId: "Id", Sort: "asc"}}
+	}
+	history := BaseRepository[model_.AuditLog]{database: r.database, resolver: r.resolver, logger: r.logger}
+	return history.GetByFilter(ctx, req)
+}
+
+// Aggregate groups the rows the filter matches and computes the requested values
+// of every group, see filter.AggregateInput
+func (r BaseRepository[TEntity]) Aggregate(ctx context.Context, input filter.AggregateInput) ([]filter.AggregateRow, error) {
+	model := new(TEntity)
+	aggregation, err := database.GenerateAggregate[TEntity](r.database, &input)
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := database.GenerateDynamicQuery[TEntity](r.database, &input.DynamicFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	db := r.reader(ctx).
+		Model(model).
+		Select(aggregation.Select).
+		Where(query, args...)
+	if aggregation.GroupBy != "" {
+		db = db.Group(aggregation.GroupBy)
+	}
+	if aggregation.Order != "" {
+		db = db.Order(aggregation.Order)
+	}
+	scanned := []map[string]interface{}{}
+	err = db.
+		Limit(input.GetLimit()).
+		Find(&scanned).
+		Error
+	if err != nil {
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Aggregate", "Failed").Inc()
+		return nil, err
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "Aggregate", "Success").Inc()
+	return aggregation.Rows(scanned)
+}
+
+func (r BaseRepository[TEntity]) page(ctx context.Context, req filter.PaginationInputWithFilter,
+	generateQuery func(db *gorm.DB, filter *filter.DynamicFilter) (string, []interface{}, error)) (int64, *[]TEntity, error) {
 	model := new(TEntity)
 	var items *[]TEntity
 
-	db := database.Preload(r.database, r.preloads)
-	query := database.GenerateDynamicQuery[TEntity](&req.DynamicFilter)
-	sort := database.GenerateDynamicSort[TEntity](&req.DynamicFilter)
+	projection, err := filter.AsOfProjection(req.AsOf, &req.Projection, &req.DynamicFilter)
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
+	db, columns, err := r.read(ctx, projection)
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
+	if columns != nil {
+		db = db.Select(columns)
+	}
+	query, args, err := generateQuery(r.database, &req.DynamicFilter)
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
+	sort, err := database.GenerateDynamicSort[TEntity](r.database, &req.DynamicFilter)
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
+	relevance, err := database.GenerateSearchOrder[TEntity](r.database, &req.DynamicFilter)
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
+	if relevance != nil {
+		db = db.Clauses(relevance)
+	}
+	if db, err = r.asOf(db, req.AsOf); err != nil {
+		return 0, &[]TEntity{}, err
+	}
+	counter, err := r.asOf(r.reader(ctx).Model(model), req.AsOf)
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
 	var totalRows int64 = 0
 
-	db.
-		Model(model).
-		Where(query).
-		Count(&totalRows)
+	err = counter.
+		Where(query, args...).
+		Count(&totalRows).
+		Error
+	if err != nil {
+		return 0, &[]TEntity{}, err
+	}
 
-	err := db.
-		Where(query).
+	err = db.
+		Where(query, args...).
 		Offset(req.GetOffset()).
 		Limit(req.GetPageSize()).
 		Order(sort).
@@ -154,3 +508,122 @@ func (r BaseRepository[TEntity]) GetByFilter(ctx context.Context, req filter.Pag
 	return totalRows, items, err
 
 }
+
+// GetByCursor reads a keyset page: rows after (or before) the cursor in sort order,
+// so deep pages stay fast and do not shift under concurrent inserts.
+func (r BaseRepository[TEntity]) GetByCursor(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.Cursors, *[]TEntity, error) {
+	model := new(TEntity)
+	cursors := &filter.Cursors{}
+
+	projection, err := filter.AsOfProjection(req.AsOf, &req.Projection, &req.DynamicFilter)
+	if err != nil {
+		return cursors, &[]TEntity{}, err
+	}
+	keyset, err := database.NewKeyset[TEntity](r.database, &req.DynamicFilter)
+	if err != nil {
+		return cursors, &[]TEntity{}, err
+	}
+	query, args, err := database.GenerateDynamicQuery[TEntity](r.database, &req.DynamicFilter)
+	if err != nil {
+		return cursors, &[]TEntity{}, err
+	}
+
+	if req.WithTotal {
+		counter, err := r.asOf(r.reader(ctx).Model(model), req.AsOf)
+		if err != nil {
+			return cursors, &[]TEntity{}, err
+		}
+		var totalRows int64 = 0
+		err = counter.
+			Where(query, args...).
+			Count(&totalRows).
+			Error
+		if err != nil {
+			return cursors, &[]TEntity{}, err
+		}
+		cursors.TotalRows = &totalRows
+	}
+
+	db, columns, err := r.read(ctx, projection)
+	if err != nil {
+		return cursors, &[]TEntity{}, err
+	}
+	if columns := keyset.Select(columns); columns != nil {
+		db = db.Select(columns)
+	}
+	if db, err = r.asOf(db, req.AsOf); err != nil {
+		return cursors, &[]TEntity{}, err
+	}
+	db = db.Where(query, args...)
+	backward := false
+	if req.Cursor != "" {
+		cursor, err := filter.DecodeCursor(r.cursorSecret, req.Cursor, keyset.Fingerprint())
+		if err != nil {
+			return cursors, &[]TEntity{}, err
+		}
+		condition, conditionArgs, err := keyset.Condition(cursor.Values, cursor.Backward)
+		if err != nil {
+			return cursors, &[]TEntity{}, err
+		}
+		db = db.Where(condition, conditionArgs...)
+		backward = cursor.Backward
+	}
+
+	// one extra row tells whether there is a page beyond this one
+	items := []TEntity{}
+	err = db.
+		Order(keyset.Order(backward)).
+		Limit(req.GetPageSize() + 1).
+		Find(&items).
+		Error
+	if err != nil {
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "GetByCursor", "Failed").Inc()
+		return cursors, &[]TEntity{}, err
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(*model).String(), "GetByCursor", "Success").Inc()
+
+	more := len(items) > req.GetPageSize()
+	if more {
+		items = items[:req.GetPageSize()]
+	}
+	hasNext, hasPrevious := more, req.Cursor != ""
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+		hasNext, hasPrevious = true, more
+	}
+	if len(items) == 0 {
+		return cursors, &items, nil
+	}
+
+	if hasNext {
+		if cursors.Next, err = r.cursor(ctx, keyset, &items[len(items)-1], false); err != nil {
+			return cursors, &[]TEntity{}, err
+		}
+	}
+	if hasPrevious {
+		if cursors.Previous, err = r.cursor(ctx, keyset, &items[0], true); err != nil {
+			return cursors, &[]TEntity{}, err
+		}
+	}
+	return cursors, &items, nil
+}
+
+func (r BaseRepository[TEntity]) cursor(ctx context.Context, keyset *database.Keyset, entity *TEntity, backward bool) (string, error) {
+	values, err := keyset.Values(ctx, entity)
+	if err != nil {
+		return "", err
+	}
+	return filter.EncodeCursor(r.cursorSecret, filter.Cursor{Sort: keyset.Fingerprint(), Values: values, Backward: backward})
+}
+
+// read starts a query preloading the relations of the projection, and returns the
+// columns it selects or nil for all of them
+func (r BaseRepository[TEntity]) read(ctx context.Context, projection *filter.Projection) (*gorm.DB, []string, error) {
+	columns, preloads, err := database.GenerateProjection[TEntity](r.database, projection, r.preloads)
+	if err != nil {
+		return nil, nil, err
+	}
+	return database.Preload(r.reader(ctx), preloads), columns, nil
+}
@@ -0,0 +1,610 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	database "golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/concurrency"
+	"golang-clean-web-api/pkg/identity"
+	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/pkg/service_errors"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestRepository[TEntity any](t *testing.T) (*BaseRepository[TEntity], *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(new(TEntity), &model.AuditLog{}, &model.OutboxEvent{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if _, ok := any(new(TEntity)).(interface{ HistoryTable() string }); ok {
+		if err := database.CreateHistoryTable(db, new(TEntity)); err != nil {
+			t.Fatalf("Failed to create the history table: %v", err)
+		}
+	}
+	cfg := &config.Config{Logger: config.LoggerConfig{Logger: "zap", FilePath: t.TempDir() + "/", Level: "error"}}
+	return &BaseRepository[TEntity]{database: db, logger: logging.NewLogger(cfg), cursorSecret: []byte("secret")}, db
+}
+
+// auditRow reads the audit columns only, so the test does not depend on how the
+// driver decodes timestamp columns
+type auditRow struct {
+	Name       string
+	CreatedBy  int
+	ModifiedBy sql.NullInt64
+	DeletedBy  sql.NullInt64
+	ModifiedAt sql.NullString
+	DeletedAt  sql.NullString
+}
+
+func readAuditRow(t *testing.T, db *gorm.DB, table string, id int) auditRow {
+	t.Helper()
+	var row auditRow
+	err := db.Table(table).
+		Select("name, created_by, modified_by, deleted_by, modified_at, deleted_at").
+		Where("id = ?", id).
+		Scan(&row).
+		Error
+	if err != nil {
+		t.Fatalf("Failed to read audit columns: %v", err)
+	}
+	return row
+}
+
+func TestBaseRepository_AuditColumns(t *testing.T) {
+	repo, db := newTestRepository[model.Color](t)
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7, Username: "tester", RequestId: "req-1"})
+
+	color, err := repo.Create(ctx, model.Color{Name: "Black", HexCode: "#000000"})
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	if color.CreatedBy != 7 {
+		t.Errorf("Expected CreatedBy 7, got %d", color.CreatedBy)
+	}
+
+	if _, err := repo.Update(ctx, color.Id, map[string]interface{}{"Name": "Dark"}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	stored := readAuditRow(t, db, "colors", color.Id)
+	if stored.Name != "Dark" {
+		t.Errorf("Expected name Dark, got %s", stored.Name)
+	}
+	if stored.CreatedBy != 7 {
+		t.Errorf("Expected stored CreatedBy 7, got %d", stored.CreatedBy)
+	}
+	if !stored.ModifiedBy.Valid || stored.ModifiedBy.Int64 != 7 {
+		t.Errorf("Expected ModifiedBy 7, got %v", stored.ModifiedBy)
+	}
+	if !stored.ModifiedAt.Valid {
+		t.Error("Expected ModifiedAt to be set")
+	}
+
+	if err := repo.Delete(ctx, color.Id); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	stored = readAuditRow(t, db, "colors", color.Id)
+	if !stored.DeletedBy.Valid || stored.DeletedBy.Int64 != 7 {
+		t.Errorf("Expected DeletedBy 7, got %v", stored.DeletedBy)
+	}
+	if !stored.DeletedAt.Valid {
+		t.Error("Expected DeletedAt to be set")
+	}
+}
+
+func TestBaseRepository_AnonymousContext(t *testing.T) {
+	repo, _ := newTestRepository[model.Color](t)
+	ctx := identity.NewContext(context.Background(), &identity.Identity{RequestId: "req-2"})
+
+	color, err := repo.Create(ctx, model.Color{Name: "White", HexCode: "#ffffff"})
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	if color.CreatedBy != -1 {
+		t.Errorf("Expected CreatedBy -1, got %d", color.CreatedBy)
+	}
+
+	err = repo.Delete(ctx, color.Id)
+	var serviceError *service_errors.ServiceError
+	if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.PermissionDenied {
+		t.Errorf("Expected permission denied, got %v", err)
+	}
+}
+
+// filterItem keeps only plain columns so rows scan back on every driver
+type filterItem struct {
+	Id        int `gorm:"primarykey"`
+	Name      string
+	HexCode   string
+	DeletedBy *sql.NullInt64
+}
+
+func TestBaseRepository_GetByFilterExpression(t *testing.T) {
+	repo, db := newTestRepository[filterItem](t)
+	ctx := context.Background()
+	db.Create(&[]filterItem{{Name: "Black", HexCode: "#000000"}, {Name: "White", HexCode: "#ffffff"}, {Name: "Blue", HexCode: "#0000ff"}})
+
+	req := filter.PaginationInputWithFilter{DynamicFilter: filter.DynamicFilter{
+		Where: &filter.Expression{Or: []filter.Expression{
+			{Field: "Name", Filter: filter.Filter{Type: "equals", From: "Black"}},
+			{Field: "HexCode", Filter: filter.Filter{Type: "in", Values: []string{"#0000ff", "#123456"}}},
+		}},
+	}}
+	count, items, err := repo.GetByFilter(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to filter: %v", err)
+	}
+	if count != 2 || len(*items) != 2 {
+		t.Errorf("Expected 2 items, got count %d and %d items", count, len(*items))
+	}
+}
+
+func TestBaseRepository_GetByCursor(t *testing.T) {
+	repo, db := newTestRepository[filterItem](t)
+	ctx := context.Background()
+	db.Create(&[]filterItem{{Name: "A"}, {Name: "B"}, {Name: "B"}, {Name: "C"}, {Name: "D"}})
+
+	names := func(items *[]filterItem) []string {
+		result := []string{}
+		for _, item := range *items {
+			result = append(result, fmt.Sprintf("%s%d", item.Name, item.Id))
+		}
+		return result
+	}
+	req := filter.PaginationInputWithFilter{
+		PaginationInput: filter.PaginationInput{PageSize: 2, CursorMode: true, WithTotal: true},
+		DynamicFilter:   filter.DynamicFilter{Sort: &[]filter.Sort{{ColId: "Name", Sort: "desc"}}},
+	}
+
+	pages := [][]string{{"D5", "C4"}, {"B2", "B3"}, {"A1"}}
+	cursors := []*filter.Cursors{}
+	for i, expected := range pages {
+		page, items, err := repo.GetByCursor(ctx, req)
+		if err != nil {
+			t.Fatalf("Failed to read page %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(names(items), expected) {
+			t.Fatalf("Page %d: expected %v, got %v", i, expected, names(items))
+		}
+		cursors = append(cursors, page)
+		req.Cursor = page.Next
+	}
+	if cursors[0].TotalRows == nil || *cursors[0].TotalRows != 5 {
+		t.Errorf("Expected a total of 5, got %v", cursors[0].TotalRows)
+	}
+	if cursors[0].Previous != "" || cursors[2].Next != "" {
+		t.Errorf("Expected no cursor before the first and after the last page")
+	}
+
+	req.Cursor = cursors[2].Previous
+	_, items, err := repo.GetByCursor(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to read backward: %v", err)
+	}
+	if !reflect.DeepEqual(names(items), pages[1]) {
+		t.Errorf("Expected %v going back, got %v", pages[1], names(items))
+	}
+
+	req.Sort = &[]filter.Sort{{ColId: "Name", Sort: "asc"}}
+	if _, _, err := repo.GetByCursor(ctx, req); err == nil {
+		t.Error("Expected a cursor issued for another sort to be rejected")
+	}
+}
+
+func TestBaseRepository_Trash(t *testing.T) {
+	repo, db := newTestRepository[model.Color](t)
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7})
+
+	colors := []model.Color{{Name: "Black", HexCode: "#000000"}, {Name: "White", HexCode: "#ffffff"}, {Name: "Blue", HexCode: "#0000ff"}}
+	db.WithContext(ctx).Create(&colors)
+	for _, color := range colors {
+		if err := repo.Delete(ctx, color.Id); err != nil {
+			t.Fatalf("Failed to delete: %v", err)
+		}
+	}
+
+	req := filter.PaginationInputWithFilter{
+		DynamicFilter: filter.DynamicFilter{Where: &filter.Expression{Field: "Name", Filter: filter.Filter{Type: "in", Values: []string{"Black", "Blue"}}}},
+		Projection:    filter.Projection{Fields: []string{"Name"}},
+	}
+	count, _, err := repo.GetDeleted(ctx, req)
+	if err != nil || count != 2 {
+		t.Fatalf("Expected 2 deleted colors, got %d (%v)", count, err)
+	}
+
+	if err := repo.Restore(ctx, colors[0].Id); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	row := readAuditRow(t, db, "colors", colors[0].Id)
+	if row.DeletedBy.Valid || row.DeletedAt.Valid || row.ModifiedBy.Int64 != 7 {
+		t.Errorf("Expected delete columns cleared and modifier 7, got %+v", row)
+	}
+	if err := repo.Restore(ctx, colors[0].Id); err == nil {
+		t.Error("Expected restoring a live row to fail")
+	}
+
+	if err := repo.Purge(ctx, colors[1].Id); err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+	if err := repo.Purge(ctx, colors[0].Id); err == nil {
+		t.Error("Expected purging a live row to fail")
+	}
+
+	purged, err := repo.PurgeDeletedBefore(ctx, time.Now().Add(time.Hour))
+	if err != nil || purged != 1 {
+		t.Errorf("Expected the remaining deleted color to be purged, got %d (%v)", purged, err)
+	}
+	var remaining int64
+	db.Table("colors").Count(&remaining)
+	if remaining != 1 {
+		t.Errorf("Expected only the restored color to remain, got %d", remaining)
+	}
+}
+
+func TestBaseRepository_PurgeKeepsReferencedRows(t *testing.T) {
+	db, err := database.OpenSqlite("")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Country{}, &model.City{}, &model.AuditLog{}, &model.OutboxEvent{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if err := database.CreateHistoryTable(db, &model.Country{}); err != nil {
+		t.Fatalf("Failed to create the history table: %v", err)
+	}
+	cfg := &config.Config{Logger: config.LoggerConfig{Logger: "zap", FilePath: t.TempDir() + "/", Level: "error"}}
+	repo := &BaseRepository[model.Country]{database: db, logger: logging.NewLogger(cfg)}
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7})
+
+	iran, spain := model.Country{Name: "Iran"}, model.Country{Name: "Spain"}
+	db.WithContext(ctx).Create(&iran)
+	db.WithContext(ctx).Create(&spain)
+	db.WithContext(ctx).Create(&model.City{Name: "Tehran", CountryId: iran.Id})
+	for _, id := range []int{iran.Id, spain.Id} {
+		if err := repo.Delete(ctx, id); err != nil {
+			t.Fatalf("Failed to delete %d: %v", id, err)
+		}
+	}
+
+	// Tehran is alive and still references Iran
+	purged, err := repo.PurgeDeletedBefore(ctx, time.Now().Add(time.Hour))
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected Spain to be purged, got %d (%v)", purged, err)
+	}
+	var remaining []int
+	db.Table("countries").Order("id").Pluck("id", &remaining)
+	if len(remaining) != 1 || remaining[0] != iran.Id {
+		t.Errorf("Expected only Iran to be kept, got %v", remaining)
+	}
+	var audits int64
+	db.Model(&model.AuditLog{}).Where("action = ?", model.AuditPurge).Count(&audits)
+	if audits != 1 {
+		t.Errorf("Expected one purge audited, got %d", audits)
+	}
+}
+
+func TestBaseRepository_OptimisticConcurrency(t *testing.T) {
+	repo, db := newTestRepository[model.Color](t)
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7})
+
+	color := model.Color{Name: "Black", HexCode: "#000000"}
+	db.WithContext(ctx).Create(&color)
+	if color.Version != 1 {
+		t.Fatalf("Expected a new row at version 1, got %d", color.Version)
+	}
+
+	updated, err := repo.Update(concurrency.NewContext(ctx, 1), color.Id, map[string]interface{}{"Name": "Ink"})
+	if err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if updated.Version != 2 || updated.Name != "Ink" {
+		t.Errorf("Expected Ink at version 2, got %s at %d", updated.Name, updated.Version)
+	}
+
+	// a second writer still holding version 1
+	_, err = repo.Update(concurrency.NewContext(ctx, 1), color.Id, map[string]interface{}{"Name": "Coal"})
+	serviceError := &service_errors.ServiceError{}
+	if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.ConcurrencyConflict {
+		t.Errorf("Expected a concurrency conflict, got %v", err)
+	}
+	if err := repo.Delete(concurrency.NewContext(ctx, 1), color.Id); !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.ConcurrencyConflict {
+		t.Errorf("Expected delete of a stale version to conflict, got %v", err)
+	}
+
+	_, err = repo.Update(concurrency.NewContext(ctx, 1), 999, map[string]interface{}{"Name": "Coal"})
+	if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.RecordNotFound {
+		t.Errorf("Expected a missing row to be not found, got %v", err)
+	}
+
+	if err := repo.Delete(concurrency.NewContext(ctx, 2), color.Id); err != nil {
+		t.Errorf("Expected delete of the current version to succeed, got %v", err)
+	}
+}
+
+func TestBaseRepository_AuditLog(t *testing.T) {
+	repo, _ := newTestRepository[model.Color](t)
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7, Username: "tester", RequestId: "req-7"})
+
+	color, err := repo.Create(ctx, model.Color{Name: "Black", HexCode: "#000000"})
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	if _, err := repo.Update(ctx, color.Id, map[string]interface{}{"Name": "Dark"}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if err := repo.Delete(ctx, color.Id); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+
+	count, history, err := repo.GetHistory(ctx, color.Id, filter.PaginationInputWithFilter{})
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 audit records, got %d", count)
+	}
+	actions := []string{}
+	for _, entry := range *history {
+		actions = append(actions, entry.Action)
+		if entry.EntityType != "colors" || entry.UserId != 7 || entry.Username != "tester" || entry.RequestId != "req-7" {
+			t.Errorf("Unexpected audit record %+v", entry)
+		}
+	}
+	if fmt.Sprint(actions) != "[create update delete]" {
+		t.Errorf("Expected create, update and delete in order, got %v", actions)
+	}
+
+	update := (*history)[1].Changes
+	if update != `[{"field":"Name","old":"Black","new":"Dark"}]` {
+		t.Errorf("Expected only the name change, got %s", update)
+	}
+	if !strings.Contains((*history)[0].Changes, `{"field":"HexCode","old":null,"new":"#000000"}`) {
+		t.Errorf("Expected the created columns, got %s", (*history)[0].Changes)
+	}
+	if !strings.Contains((*history)[2].Changes, `"field":"DeletedBy","old":null,"new":7`) {
+		t.Errorf("Expected the deleting user, got %s", (*history)[2].Changes)
+	}
+
+	if _, err := repo.Update(ctx, color.Id+1, map[string]interface{}{"Name": "Gray"}); err == nil {
+		t.Fatal("Expected updating a missing row to fail")
+	}
+	if count, _, _ := repo.GetHistory(ctx, color.Id+1, filter.PaginationInputWithFilter{}); count != 0 {
+		t.Errorf("Expected a failed write to leave no audit record, got %d", count)
+	}
+}
+
+func TestBaseRepository_CreateMany(t *testing.T) {
+	repo, _ := newTestRepository[model.Color](t)
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7, Username: "tester"})
+
+	colors := []model.Color{}
+	for i := 0; i < createBatchSize+5; i++ {
+		colors = append(colors, model.Color{Name: fmt.Sprintf("Color%d", i), HexCode: fmt.Sprintf("#%06d", i)})
+	}
+	created, err := repo.CreateMany(ctx, colors)
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	for i, color := range created {
+		if color.Id == 0 || color.CreatedBy != 7 || color.CreatedAt.IsZero() {
+			t.Fatalf("Expected color %d to have an id and audit fields, got %+v", i, color.BaseModel)
+		}
+	}
+
+	count, _, err := repo.GetByFilter(ctx, filter.PaginationInputWithFilter{})
+	if err != nil || count != int64(len(colors)) {
+		t.Errorf("Expected %d colors, got %d (%v)", len(colors), count, err)
+	}
+	last := created[len(created)-1].Id
+	if count, _, _ := repo.GetHistory(ctx, last, filter.PaginationInputWithFilter{}); count != 1 {
+		t.Errorf("Expected the last color to be audited, got %d records", count)
+	}
+}
+
+func TestBaseRepository_Upsert(t *testing.T) {
+	repo, db := newTestRepository[model.Color](t)
+	if err := db.Exec("CREATE UNIQUE INDEX ux_colors_name ON colors (name) WHERE deleted_by IS NULL").Error; err != nil {
+		t.Fatalf("Failed to create the natural key index: %v", err)
+	}
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7, Username: "tester"})
+
+	color, created, err := repo.Upsert(ctx, model.Color{Name: "Black", HexCode: "#000000"})
+	if err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	if !created || color.Id == 0 || color.Version != 1 {
+		t.Fatalf("Expected a new row at version 1, got created %v and %+v", created, color)
+	}
+
+	updated, created, err := repo.Upsert(ctx, model.Color{Name: "Black", HexCode: "#111111"})
+	if err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	if created || updated.Id != color.Id || updated.HexCode != "#111111" || updated.Version != 2 {
+		t.Errorf("Expected the same row updated to version 2, got created %v and %+v", created, updated)
+	}
+	if updated.CreatedBy != 7 || !updated.ModifiedBy.Valid || updated.ModifiedBy.Int64 != 7 {
+		t.Errorf("Expected creator and modifier 7, got %+v", updated.BaseModel)
+	}
+
+	_, history, err := repo.GetHistory(ctx, color.Id, filter.PaginationInputWithFilter{})
+	if err != nil || len(*history) != 2 || (*history)[1].Action != model.AuditUpdate {
+		t.Fatalf("Expected a create and an update record, got %v (%v)", history, err)
+	}
+	if changes := (*history)[1].Changes; changes != `[{"field":"HexCode","old":"#000000","new":"#111111"}]` {
+		t.Errorf("Expected only the hex code change, got %s", changes)
+	}
+
+	// a deleted row frees its key
+	if err := repo.Delete(ctx, color.Id); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	recreated, created, err := repo.Upsert(ctx, model.Color{Name: "Black", HexCode: "#000000"})
+	if err != nil {
+		t.Fatalf("Failed to insert again: %v", err)
+	}
+	if !created || recreated.Id == color.Id {
+		t.Errorf("Expected a new row next to the deleted one, got created %v and id %d", created, recreated.Id)
+	}
+
+	// a row written outside the repository may be at any version, it is still updated
+	if err := db.Exec("INSERT INTO colors (name, hex_code, created_at, created_by, version) VALUES ('White', '#ffffff', CURRENT_TIMESTAMP, 1, 0)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, created, err := repo.Upsert(ctx, model.Color{Name: "White", HexCode: "#fefefe"}); err != nil || created {
+		t.Errorf("Expected the row at version 0 reported as updated, got created %v (%v)", created, err)
+	}
+}
+
+func TestBaseRepository_Aggregate(t *testing.T) {
+	repo, db := newTestRepository[model.City](t)
+	if err := db.AutoMigrate(&model.Country{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7, Username: "tester"})
+	iran, germany := model.Country{Name: "Iran"}, model.Country{Name: "Germany"}
+	if err := db.Create(&iran).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&germany).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, city := range []model.City{
+		{Name: "Tehran", CountryId: iran.Id}, {Name: "Tabriz", CountryId: iran.Id},
+		{Name: "Shiraz", CountryId: iran.Id}, {Name: "Berlin", CountryId: germany.Id},
+	} {
+		if _, err := repo.Create(ctx, city); err != nil {
+			t.Fatalf("Failed to create: %v", err)
+		}
+	}
+	if err := repo.Delete(ctx, 3); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+
+	rows, err := repo.Aggregate(ctx, filter.AggregateInput{
+		GroupBy:       []string{"Country.Name"},
+		Aggregates:    []filter.Aggregate{{Function: "count"}, {Function: "avg", Field: "Id"}},
+		DynamicFilter: filter.DynamicFilter{Sort: &[]filter.Sort{{ColId: "count", Sort: "desc"}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to aggregate: %v", err)
+	}
+	expected := []filter.AggregateRow{
+		{Group: map[string]interface{}{"Country.Name": "Iran"}, Values: map[string]interface{}{"count": int64(2), "avgId": 1.5}},
+		{Group: map[string]interface{}{"Country.Name": "Germany"}, Values: map[string]interface{}{"count": int64(1), "avgId": 4.0}},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected the live cities per country, got %v", rows)
+	}
+
+	rows, err = repo.Aggregate(ctx, filter.AggregateInput{
+		Aggregates: []filter.Aggregate{{Function: "max", Field: "CountryId", Alias: "country"}},
+		DynamicFilter: filter.DynamicFilter{Filter: map[string]filter.Filter{
+			"Name": {Type: "startsWith", From: "Z"},
+		}},
+	})
+	if err != nil || len(rows) != 1 || rows[0].Values["country"] != nil {
+		t.Errorf("Expected one row with a null max, got %v (%v)", rows, err)
+	}
+
+	_, err = repo.Aggregate(ctx, filter.AggregateInput{Aggregates: []filter.Aggregate{{Function: "sum", Field: "Name"}}})
+	var serviceError *service_errors.ServiceError
+	if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidFilter {
+		t.Errorf("Expected summing a text field to be rejected, got %v", err)
+	}
+}
+
+func TestBaseRepository_AsOf(t *testing.T) {
+	repo, _ := newTestRepository[model.Country](t)
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7, Username: "tester"})
+	// moments between the writes, the clock of the database has to move past them
+	moment := func() time.Time {
+		time.Sleep(2 * time.Millisecond)
+		at := time.Now().UTC()
+		time.Sleep(2 * time.Millisecond)
+		return at
+	}
+
+	beforeCreate := moment()
+	country, err := repo.Create(ctx, model.Country{Name: "Iran"})
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	created := moment()
+	if _, err := repo.Update(ctx, country.Id, map[string]interface{}{"name": "Persia"}); err != nil {
+		t.Fatalf("Failed to update: %v", err)
+	}
+	updated := moment()
+	if err := repo.Delete(ctx, country.Id); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	deleted := moment()
+	if err := repo.Purge(ctx, country.Id); err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+
+	for _, c := range []struct {
+		at   time.Time
+		name string
+	}{{created, "Iran"}, {updated, "Persia"}} {
+		found, err := repo.GetById(filter.NewAsOfContext(ctx, &c.at), country.Id)
+		if err != nil || found.Name != c.name {
+			t.Errorf("Expected %s as of %s, got %+v (%v)", c.name, c.at, found, err)
+		}
+		total, items, err := repo.GetByFilter(ctx, filter.PaginationInputWithFilter{AsOf: &c.at})
+		if err != nil || total != 1 || len(*items) != 1 || (*items)[0].Name != c.name {
+			t.Errorf("Expected only %s in the list as of %s, got %d %v (%v)", c.name, c.at, total, items, err)
+		}
+		_, page, err := repo.GetByCursor(ctx, filter.PaginationInputWithFilter{AsOf: &c.at})
+		if err != nil || len(*page) != 1 || (*page)[0].Name != c.name {
+			t.Errorf("Expected only %s in the cursor page as of %s, got %v (%v)", c.name, c.at, page, err)
+		}
+	}
+
+	for _, at := range []time.Time{beforeCreate, deleted, time.Now().UTC()} {
+		_, err := repo.GetById(filter.NewAsOfContext(ctx, &at), country.Id)
+		var serviceError *service_errors.ServiceError
+		if !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.RecordNotFound {
+			t.Errorf("Expected no country as of %s, got %v", at, err)
+		}
+		total, _, err := repo.GetByFilter(ctx, filter.PaginationInputWithFilter{AsOf: &at})
+		if err != nil || total != 0 {
+			t.Errorf("Expected an empty list as of %s, got %d (%v)", at, total, err)
+		}
+	}
+
+	deletedAt := filter.PaginationInputWithFilter{AsOf: &deleted}
+	total, trash, err := repo.GetDeleted(ctx, deletedAt)
+	if err != nil || total != 1 || (*trash)[0].Name != "Persia" {
+		t.Errorf("Expected the deleted country in the trash as of its deletion, got %d %v (%v)", total, trash, err)
+	}
+
+	// relations keep no history, reading them as of a time would mix in the present
+	for name, req := range map[string]filter.PaginationInputWithFilter{
+		"include": {AsOf: &updated, Projection: filter.Projection{Include: []string{"Cities"}}},
+		"sort":    {AsOf: &updated, DynamicFilter: filter.DynamicFilter{Sort: &[]filter.Sort{{ColId: "Cities.Name", Sort: "asc"}}}},
+	} {
+		var serviceError *service_errors.ServiceError
+		if _, _, err := repo.GetByFilter(ctx, req); !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidFilter {
+			t.Errorf("Expected a relation in the %s rejected as of a time, got %v", name, err)
+		}
+		if _, _, err := repo.GetByCursor(ctx, req); !errors.As(err, &serviceError) || serviceError.EndUserMessage != service_errors.InvalidFilter {
+			t.Errorf("Expected a relation in the %s rejected as of a time in cursor mode, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	database "golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/pkg/service_errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchType searches the live rows of one entity type
+type searchType struct {
+	name string
+	find func(db *gorm.DB, text string, suggest bool, limit int) ([]filter.SearchHit, error)
+}
+
+// searchTypes are the entity types global search looks in, by the name clients use
+var searchTypes = []searchType{
+	searchOf[model.Country]("country"),
+	searchOf[model.City]("city"),
+	searchOf[model.Company]("company"),
+	searchOf[model.Color]("color"),
+}
+
+func searchOf[TEntity any](name string) searchType {
+	return searchType{name: name, find: func(db *gorm.DB, text string, suggest bool, limit int) ([]filter.SearchHit, error) {
+		generate := database.GenerateSearch[TEntity]
+		if suggest {
+			generate = database.GenerateSuggest[TEntity]
+		}
+		search, err := generate(db, text)
+		if err != nil {
+			return nil, err
+		}
+		highlight := clause.Expr{SQL: "''"}
+		if search.Highlight != nil && !suggest {
+			highlight = *search.Highlight
+		}
+
+		hits := []filter.SearchHit{}
+		err = db.Model(new(TEntity)).
+			Select(fmt.Sprintf("%s.id AS id, %s AS name, ? AS rank, ? AS highlight", search.Table, search.Title),
+				search.Rank, highlight).
+			Where(search.Table+".deleted_by is null").
+			Where(search.Condition.SQL, search.Condition.Vars...).
+			Clauses(clause.OrderBy{Expression: clause.Expr{SQL: fmt.Sprintf("rank DESC, %s, %s.id", search.Title, search.Table)}}).
+			Limit(limit).
+			Scan(&hits).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		for i := range hits {
+			hits[i].Type = name
+			if search.Highlight == nil && !suggest {
+				hits[i].Highlight = database.HighlightText(hits[i].Name, text)
+			}
+		}
+		return hits, nil
+	}}
+}
+
+type SearchRepository struct {
+	database *gorm.DB
+	resolver *database.Resolver
+	logger   logging.Logger
+}
+
+func NewSearchRepository(cfg *config.Config) *SearchRepository {
+	return &SearchRepository{
+		database: database.GetDb(),
+		resolver: database.GetResolver(),
+		logger:   logging.NewLogger(cfg),
+	}
+}
+
+// Search finds the entities matching the words of the query, or close spellings of them
+func (r SearchRepository) Search(ctx context.Context, input filter.SearchInput) ([]filter.SearchHit, error) {
+	return r.find(ctx, input, false)
+}
+
+func (r SearchRepository) Suggest(ctx context.Context, input filter.SearchInput) ([]filter.SearchHit, error) {
+	return r.find(ctx, input, true)
+}
+
+// find takes the best hits of every type and keeps the best of them all. Ranks of
+// different types compare, they are computed alike.
+func (r SearchRepository) find(ctx context.Context, input filter.SearchInput, suggest bool) ([]filter.SearchHit, error) {
+	types, err := selectSearchTypes(input.Types)
+	if err != nil {
+		return nil, err
+	}
+	limit := input.GetLimit()
+	hits := []filter.SearchHit{}
+	for _, t := range types {
+		found, err := t.find(r.reader(ctx), input.Query, suggest, limit)
+		if err != nil {
+			var serviceError *service_errors.ServiceError
+			if !errors.As(err, &serviceError) {
+				r.logger.Error(logging.Postgres, logging.Select, fmt.Sprintf("search %s: %v", t.name, err), nil)
+			}
+			return nil, err
+		}
+		hits = append(hits, found...)
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (r SearchRepository) reader(ctx context.Context) *gorm.DB {
+	if r.resolver == nil {
+		return database.Conn(ctx, r.database)
+	}
+	return r.resolver.Reader(ctx)
+}
+
+func selectSearchTypes(names []string) ([]searchType, error) {
+	if len(names) == 0 {
+		return searchTypes, nil
+	}
+	selected := []searchType{}
+	for _, name := range names {
+		found := false
+		for _, t := range searchTypes {
+			if strings.EqualFold(t.name, name) {
+				selected = append(selected, t)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, filter.Invalid("types", "unknown search type %s", name)
+		}
+	}
+	return selected, nil
+}
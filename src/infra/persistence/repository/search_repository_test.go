@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/logging"
+)
+
+func TestSearchRepository(t *testing.T) {
+	cities, db := newTestRepository[model.City](t)
+	if err := db.AutoMigrate(&model.Country{}, &model.Company{}, &model.Color{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	ctx := context.Background()
+	for _, name := range []string{"Tehran", "New Tehran", "Berlin"} {
+		if _, err := cities.Create(ctx, model.City{Name: name}); err != nil {
+			t.Fatalf("Failed to create: %v", err)
+		}
+	}
+	if err := db.Create(&model.Color{Name: "Tehran Blue", HexCode: "#0000ff"}).Error; err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+
+	cfg := &config.Config{Logger: config.LoggerConfig{Logger: "zap", FilePath: t.TempDir() + "/", Level: "error"}}
+	search := SearchRepository{database: db, logger: logging.NewLogger(cfg)}
+	hits, err := search.Search(ctx, filter.SearchInput{Query: "tehran"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(hits) != 3 || hits[0].Name != "Tehran" || hits[0].Type != "city" || hits[0].Highlight != "<b>Tehran</b>" {
+		t.Fatalf("Expected the exact name first, got %+v", hits)
+	}
+
+	suggestions, err := search.Suggest(ctx, filter.SearchInput{Query: "Teh", Types: []string{"city"}})
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	if len(suggestions) != 2 || suggestions[0].Name != "Tehran" || suggestions[1].Name != "New Tehran" {
+		t.Errorf("Expected names starting with the text first, got %+v", suggestions)
+	}
+
+	if _, err := search.Search(ctx, filter.SearchInput{Query: "x", Types: []string{"planet"}}); err == nil {
+		t.Error("Expected an unknown type to be rejected")
+	}
+
+	total, items, err := cities.GetByFilter(ctx, filter.PaginationInputWithFilter{DynamicFilter: filter.DynamicFilter{Search: "tehran"}})
+	if err != nil || total != 2 || (*items)[0].Name != "Tehran" {
+		t.Errorf("Expected the cities by relevance, got %d %+v %v", total, items, err)
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/identity"
+)
+
+func colorNames(t *testing.T, repo *BaseRepository[model.Color]) []string {
+	t.Helper()
+	_, colors, err := repo.GetByFilter(context.Background(), filter.PaginationInputWithFilter{
+		DynamicFilter: filter.DynamicFilter{Sort: &[]filter.Sort{{ColId: "Id", Sort: "asc"}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to list: %v", err)
+	}
+	names := []string{}
+	for _, color := range *colors {
+		names = append(names, color.Name)
+	}
+	return names
+}
+
+func TestTransactionManager(t *testing.T) {
+	repo, db := newTestRepository[model.Color](t)
+	transactions := database.NewTransactionManager(db)
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7, Username: "tester"})
+	failure := errors.New("failure")
+
+	err := transactions.Do(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, model.Color{Name: "Black", HexCode: "#000000"}); err != nil {
+			return err
+		}
+		// a nested failure only rolls back to its savepoint
+		err := transactions.Do(ctx, func(ctx context.Context) error {
+			if _, err := repo.Create(ctx, model.Color{Name: "Gray", HexCode: "#808080"}); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Errorf("Expected the nested failure, got %v", err)
+		}
+		_, err = repo.Create(ctx, model.Color{Name: "White", HexCode: "#ffffff"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if names := colorNames(t, repo); len(names) != 2 || names[0] != "Black" || names[1] != "White" {
+		t.Errorf("Expected Black and White to be committed, got %v", names)
+	}
+
+	err = transactions.Do(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, model.Color{Name: "Red", HexCode: "#ff0000"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the failure, got %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the panic to be rethrown")
+			}
+		}()
+		_ = transactions.Do(ctx, func(ctx context.Context) error {
+			if _, err := repo.Create(ctx, model.Color{Name: "Blue", HexCode: "#0000ff"}); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+
+	if names := colorNames(t, repo); len(names) != 2 {
+		t.Errorf("Expected the failed transactions to be rolled back, got %v", names)
+	}
+	if count, _, _ := repo.GetHistory(ctx, 3, filter.PaginationInputWithFilter{}); count != 0 {
+		t.Errorf("Expected the audit records to be rolled back with the row, got %d", count)
+	}
+}
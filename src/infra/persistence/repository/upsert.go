@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+
+	model_ "golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/identity"
+	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/pkg/metrics"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// upsertIgnored are the columns an upsert leaves alone on an existing row
+var upsertIgnored = map[string]bool{
+	"created_at":  true,
+	"created_by":  true,
+	"modified_at": true,
+	"modified_by": true,
+	"deleted_at":  true,
+	"deleted_by":  true,
+	"version":     true,
+}
+
+type naturalKeyed interface {
+	NaturalKey() string
+}
+
+// Upsert inserts the entity, or overwrites the live row with the same natural key
+// (see model NaturalKey) in one statement. It reports whether the row was created.
+func (r BaseRepository[TEntity]) Upsert(ctx context.Context, entity TEntity) (TEntity, bool, error) {
+	keyed, ok := any(entity).(naturalKeyed)
+	if !ok {
+		return entity, false, fmt.Errorf("%T has no natural key", entity)
+	}
+	stmt := &gorm.Statement{DB: r.database}
+	if err := stmt.Parse(new(TEntity)); err != nil {
+		return entity, false, err
+	}
+	key := stmt.Schema.LookUpField(keyed.NaturalKey())
+	if key == nil {
+		return entity, false, fmt.Errorf("natural key %s is not a field of %s", keyed.NaturalKey(), stmt.Schema.Table)
+	}
+
+	columns := []string{}
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName != "" && !field.PrimaryKey && field != key && !upsertIgnored[field.DBName] {
+			columns = append(columns, field.DBName)
+		}
+	}
+	now := time.Now().UTC()
+	assignments := clause.AssignmentColumns(columns)
+	assignments = append(assignments,
+		clause.Assignment{Column: clause.Column{Name: "modified_at"}, Value: sql.NullTime{Valid: true, Time: now}},
+		clause.Assignment{Column: clause.Column{Name: "version"}, Value: gorm.Expr(stmt.Schema.Table + ".version + 1")})
+	if userId, ok := identity.UserId(ctx); ok {
+		assignments = append(assignments,
+			clause.Assignment{Column: clause.Column{Name: "modified_by"}, Value: &sql.NullInt64{Int64: int64(userId), Valid: true}})
+	}
+	onConflict := clause.OnConflict{
+		Columns:     []clause.Column{{Name: key.DBName}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_by IS NULL"}}},
+		DoUpdates:   assignments,
+	}
+
+	var created bool
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		value, _ := key.ValueOf(ctx, reflect.ValueOf(&entity).Elem())
+		before, err := r.snapshotWhere(tx, key.DBName+" = ? and deleted_by is null", value)
+		if err != nil {
+			return err
+		}
+		if before != nil {
+			if err := r.archive(tx, now, key.DBName+" = ? and deleted_by is null", value); err != nil {
+				return err
+			}
+		}
+		if err := tx.Clauses(onConflict).Create(&entity).Error; err != nil {
+			return err
+		}
+		id, err := primaryKey(ctx, tx, &entity)
+		if err != nil {
+			return err
+		}
+		after, err := r.snapshot(tx, id)
+		if err != nil {
+			return err
+		}
+		// there was no live row with the key before, so the statement inserted one
+		created = before == nil
+		if err := tx.Where("id = ?", id).First(&entity).Error; err != nil {
+			return err
+		}
+		if created {
+			return r.audit(tx, model_.AuditCreate, id, nil, after)
+		}
+		return r.audit(tx, model_.AuditUpdate, id, before, after)
+	})
+	if err != nil {
+		return entity, false, r.failed(logging.Insert, "Upsert", err)
+	}
+
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(entity).String(), "Upsert", "Success").Inc()
+	return entity, created, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/model"
+	database "golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/logging"
+
+	"gorm.io/gorm"
+)
+
+// UserRepository reads and writes users directly, without the audit log: a user
+// row carries the password hash
+type UserRepository struct {
+	BaseRepository[model.User]
+}
+
+func NewUserRepository(cfg *config.Config) *UserRepository {
+	return &UserRepository{BaseRepository: BaseRepository[model.User]{
+		database: database.GetDb(),
+		resolver: database.GetResolver(),
+		logger:   logging.NewLogger(cfg),
+	}}
+}
+
+// GetByUsername reads from the primary, a user signing in right after registering
+// must not miss on a lagging replica
+func (r UserRepository) GetByUsername(ctx context.Context, username string) (model.User, error) {
+	var user model.User
+	err := database.Conn(ctx, r.database).
+		Where("username = ?", username).
+		First(&user).
+		Error
+	return user, r.translate(err)
+}
+
+func (r UserRepository) Create(ctx context.Context, user model.User) (model.User, error) {
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		return tx.Create(&user).Error
+	})
+	if err != nil {
+		return user, r.failed(logging.Insert, "Create", err)
+	}
+	return user, nil
+}
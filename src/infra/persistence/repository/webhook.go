@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"golang-clean-web-api/config"
+	filter "golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	database "golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/pkg/metrics"
+
+	"gorm.io/gorm/clause"
+)
+
+// WebhookSubscriptionRepository writes subscriptions like any entity, audited
+// and with domain events, and finds the ones events are fanned out to
+type WebhookSubscriptionRepository struct {
+	*BaseRepository[model.WebhookSubscription]
+}
+
+func NewWebhookSubscriptionRepository(cfg *config.Config) *WebhookSubscriptionRepository {
+	return &WebhookSubscriptionRepository{BaseRepository: NewBaseRepository[model.WebhookSubscription](cfg, nil)}
+}
+
+func (r WebhookSubscriptionRepository) GetActive(ctx context.Context) ([]model.WebhookSubscription, error) {
+	subscriptions := []model.WebhookSubscription{}
+	err := r.reader(ctx).
+		Where("active = ? and deleted_by is null", true).
+		Order("id").
+		Find(&subscriptions).
+		Error
+	if err != nil {
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(model.WebhookSubscription{}).String(), "GetActive", "Failed").Inc()
+		return nil, err
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(model.WebhookSubscription{}).String(), "GetActive", "Success").Inc()
+	return subscriptions, nil
+}
+
+// WebhookDeliveryRepository keeps the delivery log. Deliveries are bookkeeping
+// of the subscriptions, their writes are neither audited nor raise events.
+type WebhookDeliveryRepository struct {
+	base BaseRepository[model.WebhookDelivery]
+}
+
+func NewWebhookDeliveryRepository(cfg *config.Config) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{base: *NewBaseRepository[model.WebhookDelivery](cfg, nil)}
+}
+
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, delivery model.WebhookDelivery) (model.WebhookDelivery, error) {
+	if err := r.base.writer(ctx).Create(&delivery).Error; err != nil {
+		return delivery, r.base.failed(logging.Insert, "Create", err)
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(delivery).String(), "Create", "Success").Inc()
+	return delivery, nil
+}
+
+func (r *WebhookDeliveryRepository) Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	err := r.base.writer(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&deliveries, createBatchSize).
+		Error
+	if err != nil {
+		return r.base.failed(logging.Insert, "Enqueue", err)
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(model.WebhookDelivery{}).String(), "Enqueue", "Success").Inc()
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) GetById(ctx context.Context, id int) (model.WebhookDelivery, error) {
+	delivery := model.WebhookDelivery{}
+	if err := r.base.reader(ctx).Where("id = ?", id).First(&delivery).Error; err != nil {
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(delivery).String(), "GetById", "Failed").Inc()
+		return delivery, r.base.translate(err)
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(delivery).String(), "GetById", "Success").Inc()
+	return delivery, nil
+}
+
+func (r *WebhookDeliveryRepository) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]model.WebhookDelivery, error) {
+	return r.base.GetByFilter(ctx, req)
+}
+
+// Due reads the primary, a replica may still show deliveries as pending that were just sent
+func (r *WebhookDeliveryRepository) Due(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	deliveries := []model.WebhookDelivery{}
+	err := database.Conn(ctx, r.base.database).
+		Where("status = ? and next_attempt_at <= ?", model.WebhookPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&deliveries).
+		Error
+	if err != nil {
+		metrics.DbCall.WithLabelValues(reflect.TypeOf(model.WebhookDelivery{}).String(), "Due", "Failed").Inc()
+		return nil, err
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(model.WebhookDelivery{}).String(), "Due", "Success").Inc()
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveryRepository) Save(ctx context.Context, delivery model.WebhookDelivery) error {
+	delivery.LastError = truncate(delivery.LastError, maxErrorLength)
+	if err := r.base.writer(ctx).Save(&delivery).Error; err != nil {
+		return r.base.failed(logging.Update, "Save", err)
+	}
+	metrics.DbCall.WithLabelValues(reflect.TypeOf(delivery).String(), "Save", "Success").Inc()
+	return nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/identity"
+)
+
+func TestWebhookRepositories(t *testing.T) {
+	base, db := newTestRepository[model.WebhookSubscription](t)
+	if err := db.AutoMigrate(&model.WebhookDelivery{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	subscriptions := &WebhookSubscriptionRepository{BaseRepository: base}
+	deliveries := &WebhookDeliveryRepository{base: BaseRepository[model.WebhookDelivery]{database: db, logger: base.logger}}
+	ctx := identity.NewContext(context.Background(), &identity.Identity{UserId: 7, Username: "tester"})
+
+	active, err := subscriptions.Create(ctx, model.WebhookSubscription{Url: "http://a", Secret: "top-secret-value", Active: true})
+	if err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	if _, err := subscriptions.Create(ctx, model.WebhookSubscription{Url: "http://b", Secret: "top-secret-value"}); err != nil {
+		t.Fatalf("Failed to create: %v", err)
+	}
+	found, err := subscriptions.GetActive(ctx)
+	if err != nil || len(found) != 1 || found[0].Id != active.Id {
+		t.Fatalf("Expected the active subscription only, got %+v %v", found, err)
+	}
+	_, history, _ := subscriptions.GetHistory(ctx, active.Id, filter.PaginationInputWithFilter{})
+	if strings.Contains((*history)[0].Changes, "top-secret-value") {
+		t.Errorf("Expected the secret to be redacted, got %s", (*history)[0].Changes)
+	}
+
+	now := time.Now().UTC()
+	queued := func(eventId int64) model.WebhookDelivery {
+		return model.WebhookDelivery{SubscriptionId: active.Id, EventId: sql.NullInt64{Valid: true, Int64: eventId},
+			EventType: model.EntityCreated, Payload: "{}", Status: model.WebhookPending, NextAttemptAt: now, CreatedAt: now}
+	}
+	if err := deliveries.Enqueue(ctx, []model.WebhookDelivery{queued(1), queued(2)}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if err := deliveries.Enqueue(ctx, []model.WebhookDelivery{queued(2), queued(3)}); err != nil {
+		t.Fatalf("Failed to enqueue a repeated event: %v", err)
+	}
+	due, err := deliveries.Due(ctx, now.Add(time.Second), 10)
+	if err != nil || len(due) != 3 {
+		t.Fatalf("Expected three deliveries, the repeated one skipped, got %d %v", len(due), err)
+	}
+
+	due[0].Status = model.WebhookDead
+	due[0].Attempts = 8
+	due[0].ResponseCode = 500
+	due[0].LastError = strings.Repeat("x", 600)
+	if err := deliveries.Save(ctx, due[0]); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	due[1].NextAttemptAt = now.Add(time.Minute)
+	if err := deliveries.Save(ctx, due[1]); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	if still, _ := deliveries.Due(ctx, now.Add(time.Second), 10); len(still) != 1 || still[0].Id != due[2].Id {
+		t.Fatalf("Expected only the untouched delivery to be due, got %+v", still)
+	}
+	dead, err := deliveries.GetById(ctx, due[0].Id)
+	if err != nil || dead.Status != model.WebhookDead || dead.ResponseCode != 500 || len(dead.LastError) != maxErrorLength {
+		t.Fatalf("Expected the dead delivery with its response code, got %+v %v", dead, err)
+	}
+	if _, err := deliveries.GetById(ctx, 99); err == nil {
+		t.Fatal("Expected a missing delivery not to be found")
+	}
+}
@@ -0,0 +1,53 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/constant"
+	models "golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/logging"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// EnsureAdmin creates the configured admin with the configured password when
+// there is no such user, and fails when the user exists without the admin role.
+// Users are not audited, like the ones registering through the API.
+func EnsureAdmin(ctx context.Context, db *gorm.DB, cfg config.AdminConfig) error {
+	if cfg.Username == "" {
+		return nil
+	}
+
+	var user models.User
+	err := db.WithContext(ctx).Where("username = ?", cfg.Username).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if cfg.Password == "" {
+			return fmt.Errorf("admin %s does not exist and has no password to be created with", cfg.Username)
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(cfg.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		user = models.User{Username: cfg.Username, Password: string(hashed), Email: cfg.Email, IsActive: true, Roles: constant.AdminRoleName}
+		if err := db.WithContext(ctx).Create(&user).Error; err != nil {
+			return err
+		}
+		logger.Info(logging.Postgres, logging.Seed, fmt.Sprintf("admin %s created", cfg.Username), nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, role := range user.RoleList() {
+		if role == constant.AdminRoleName {
+			return nil
+		}
+	}
+	// anyone may have registered the name first, so an existing user is never promoted
+	return fmt.Errorf("user %s exists without the admin role and is not promoted, name another admin", cfg.Username)
+}
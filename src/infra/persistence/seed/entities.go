@@ -0,0 +1,80 @@
+package seed
+
+import (
+	"context"
+	"reflect"
+
+	"golang-clean-web-api/config"
+	models "golang-clean-web-api/domain/model"
+	"golang-clean-web-api/infra/persistence/repository"
+
+	"gorm.io/gorm"
+)
+
+// Entity describes how the fixtures of one table are matched and linked.
+// Fixture rows use the json names of the api (camelCase) as keys.
+type Entity struct {
+	// Name is the fixture file name without extension, e.g. countries.yml
+	Name  string
+	Model interface{}
+	// Key lists the fixture fields that identify a row, relation fields included
+	Key       []string
+	Relations []Relation
+	// Writer writes the rows through the repository of the model
+	Writer func(db *gorm.DB) Writer
+}
+
+// Writer creates and updates rows by column. Seeded rows go through
+// repository.BaseRepository, so they are audited, published to the outbox and
+// kept in history like any other write.
+type Writer interface {
+	Create(ctx context.Context, columns map[string]interface{}) error
+	Update(ctx context.Context, id int, columns map[string]interface{}) error
+}
+
+// Relation resolves a fixture field holding the natural key of another entity into a foreign key
+type Relation struct {
+	Field  string
+	Column string
+	Entity string
+}
+
+// Entities are seeded in this order, so an entity must come after the ones it references
+var Entities = []Entity{
+	{Name: "countries", Model: &models.Country{}, Key: []string{"name"}, Writer: writer[models.Country]},
+	{Name: "cities", Model: &models.City{}, Key: []string{"name", "country"}, Writer: writer[models.City],
+		Relations: []Relation{{Field: "country", Column: "country_id", Entity: "countries"}}},
+	{Name: "companies", Model: &models.Company{}, Key: []string{"name"}, Writer: writer[models.Company],
+		Relations: []Relation{{Field: "country", Column: "country_id", Entity: "countries"}}},
+	{Name: "colors", Model: &models.Color{}, Key: []string{"name"}, Writer: writer[models.Color]},
+}
+
+type repositoryWriter[T any] struct {
+	repository *repository.BaseRepository[T]
+	db         *gorm.DB
+}
+
+func writer[T any](db *gorm.DB) Writer {
+	return repositoryWriter[T]{repository: repository.NewBaseRepositoryOn[T](config.GetConfig(), db), db: db}
+}
+
+func (w repositoryWriter[T]) Create(ctx context.Context, columns map[string]interface{}) error {
+	stmt := &gorm.Statement{DB: w.db}
+	entity := new(T)
+	if err := stmt.Parse(entity); err != nil {
+		return err
+	}
+	row := reflect.ValueOf(entity).Elem()
+	for column, value := range columns {
+		if err := stmt.Schema.FieldsByDBName[column].Set(ctx, row, value); err != nil {
+			return err
+		}
+	}
+	_, err := w.repository.Create(ctx, *entity)
+	return err
+}
+
+func (w repositoryWriter[T]) Update(ctx context.Context, id int, columns map[string]interface{}) error {
+	_, err := w.repository.Update(ctx, id, columns)
+	return err
+}
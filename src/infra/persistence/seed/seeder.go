@@ -0,0 +1,256 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"golang-clean-web-api/common"
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/logging"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+const notDeletedExp = "deleted_by is null"
+
+var logger = logging.NewLogger(config.GetConfig())
+
+// Result reports what seeding changed for one entity
+type Result struct {
+	Entity    string `json:"entity"`
+	Created   int    `json:"created"`
+	Updated   int    `json:"updated"`
+	Unchanged int    `json:"unchanged"`
+}
+
+type Seeder struct {
+	database *gorm.DB
+	entities []Entity
+}
+
+func NewSeeder(db *gorm.DB) *Seeder {
+	return &Seeder{database: db, entities: Entities}
+}
+
+// Run upserts the fixtures found in directory by natural key. Entities
+// without a fixture file are skipped. Everything runs in one transaction, rows
+// are written through the repositories so they are audited and versioned.
+func (s *Seeder) Run(ctx context.Context, directory string) ([]Result, error) {
+	results := []Result{}
+	err := database.NewTransactionManager(s.database).Do(ctx, func(ctx context.Context) error {
+		for _, entity := range s.entities {
+			rows, err := loadFixture(directory, entity.Name)
+			if err != nil {
+				return err
+			}
+			if rows == nil {
+				continue
+			}
+			result, err := s.seed(ctx, entity, rows)
+			if err != nil {
+				return fmt.Errorf("seed %s: %w", entity.Name, err)
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(logging.Postgres, logging.Seed, err.Error(), nil)
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *Seeder) seed(ctx context.Context, entity Entity, rows []map[string]interface{}) (Result, error) {
+	result := Result{Entity: entity.Name}
+	tx := database.Conn(ctx, s.database)
+	writer := entity.Writer(s.database)
+	fields, err := s.fields(tx, entity)
+	if err != nil {
+		return result, err
+	}
+
+	for i, row := range rows {
+		columns := map[string]interface{}{}
+		for field, value := range row {
+			if relation, ok := entity.relation(field); ok {
+				id, err := s.lookup(tx, relation, value)
+				if err != nil {
+					return result, fmt.Errorf("row %d: %w", i+1, err)
+				}
+				columns[relation.Column] = id
+				continue
+			}
+			column := common.ToSnakeCase(field)
+			if _, ok := fields[column]; !ok {
+				return result, fmt.Errorf("row %d: unknown field %s", i+1, field)
+			}
+			columns[column] = value
+		}
+
+		keys := map[string]interface{}{}
+		for _, field := range entity.Key {
+			column := entity.column(field)
+			value, ok := columns[column]
+			if !ok {
+				return result, fmt.Errorf("row %d: missing key field %s", i+1, field)
+			}
+			keys[column] = value
+		}
+
+		selects := []string{"id"}
+		for column := range columns {
+			selects = append(selects, column)
+		}
+		existing := map[string]interface{}{}
+		find := tx.Model(entity.newModel()).
+			Select(selects).
+			Where(keys).
+			Where(notDeletedExp).
+			Limit(1).
+			Find(&existing)
+		if find.Error != nil {
+			return result, find.Error
+		}
+
+		if find.RowsAffected == 0 {
+			if err := writer.Create(ctx, columns); err != nil {
+				return result, fmt.Errorf("row %d: %w", i+1, err)
+			}
+			result.Created++
+			logger.Info(logging.Postgres, logging.Seed, fmt.Sprintf("%s %v created", entity.Name, keys), nil)
+			continue
+		}
+
+		changed := map[string]interface{}{}
+		for column, value := range columns {
+			if fmt.Sprint(existing[column]) != fmt.Sprint(value) {
+				changed[column] = value
+			}
+		}
+		if len(changed) == 0 {
+			result.Unchanged++
+			continue
+		}
+		id, err := toInt(existing["id"])
+		if err != nil {
+			return result, err
+		}
+		if err := writer.Update(ctx, id, changed); err != nil {
+			return result, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		result.Updated++
+		logger.Info(logging.Postgres, logging.Seed, fmt.Sprintf("%s %v updated", entity.Name, keys), nil)
+	}
+	return result, nil
+}
+
+// lookup returns the id of the related row whose natural key equals value
+func (s *Seeder) lookup(tx *gorm.DB, relation Relation, value interface{}) (int, error) {
+	related, ok := s.entity(relation.Entity)
+	if !ok || len(related.Key) != 1 {
+		return 0, fmt.Errorf("relation %s needs an entity with a single field key", relation.Field)
+	}
+
+	ids := []int{}
+	err := tx.Model(related.newModel()).
+		Where(map[string]interface{}{related.column(related.Key[0]): value}).
+		Where(notDeletedExp).
+		Pluck("id", &ids).
+		Error
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("%s %v not found", relation.Entity, value)
+	}
+	return ids[0], nil
+}
+
+func (s *Seeder) fields(tx *gorm.DB, entity Entity) (map[string]bool, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(entity.newModel()); err != nil {
+		return nil, err
+	}
+	fields := map[string]bool{}
+	for column := range stmt.Schema.FieldsByDBName {
+		fields[column] = true
+	}
+	return fields, nil
+}
+
+func (s *Seeder) entity(name string) (Entity, bool) {
+	for _, entity := range s.entities {
+		if entity.Name == name {
+			return entity, true
+		}
+	}
+	return Entity{}, false
+}
+
+func (e Entity) relation(field string) (Relation, bool) {
+	for _, relation := range e.Relations {
+		if relation.Field == field {
+			return relation, true
+		}
+	}
+	return Relation{}, false
+}
+
+func (e Entity) column(field string) string {
+	if relation, ok := e.relation(field); ok {
+		return relation.Column
+	}
+	return common.ToSnakeCase(field)
+}
+
+func (e Entity) newModel() interface{} {
+	return reflect.New(reflect.TypeOf(e.Model).Elem()).Interface()
+}
+
+// toInt reads an id scanned into a map, whose type depends on the driver
+func toInt(value interface{}) (int, error) {
+	switch id := value.(type) {
+	case int:
+		return id, nil
+	case int32:
+		return int(id), nil
+	case int64:
+		return int(id), nil
+	}
+	return 0, fmt.Errorf("unexpected id %v", value)
+}
+
+// loadFixture reads <name>.yml, <name>.yaml or <name>.json from directory.
+// It returns nil rows when there is no fixture for the entity.
+func loadFixture(directory string, name string) ([]map[string]interface{}, error) {
+	for _, ext := range []string{".yml", ".yaml", ".json"} {
+		path := filepath.Join(directory, name+ext)
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rows := []map[string]interface{}{}
+		if ext == ".json" {
+			err = json.Unmarshal(content, &rows)
+		} else {
+			err = yaml.Unmarshal(content, &rows)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return rows, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,168 @@
+package seed
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang-clean-web-api/config"
+	models "golang-clean-web-api/domain/model"
+	"golang-clean-web-api/infra/persistence/database"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestSeeder(t *testing.T) *Seeder {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	entities := []interface{}{&models.Country{}, &models.City{}, &models.Company{}, &models.Color{}}
+	if err := db.AutoMigrate(append(entities, &models.User{}, &models.AuditLog{}, &models.OutboxEvent{})...); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	for _, entity := range entities {
+		if err := database.CreateHistoryTable(db, entity); err != nil {
+			t.Fatalf("Failed to create the history table: %v", err)
+		}
+	}
+	return NewSeeder(db)
+}
+
+func resultOf(results []Result, entity string) Result {
+	for _, result := range results {
+		if result.Entity == entity {
+			return result
+		}
+	}
+	return Result{}
+}
+
+func TestSeeder_Idempotent(t *testing.T) {
+	s := newTestSeeder(t)
+	ctx := context.Background()
+
+	results, err := s.Run(ctx, "testdata")
+	if err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected results for countries and cities only, got %+v", results)
+	}
+	if got := resultOf(results, "cities"); got.Created != 2 {
+		t.Errorf("Expected 2 cities created, got %+v", got)
+	}
+
+	var countryName string
+	s.database.Table("cities").
+		Select("countries.name").
+		Joins("join countries on countries.id = cities.country_id").
+		Where("cities.name = ?", "New York").
+		Scan(&countryName)
+	if countryName != "USA" {
+		t.Errorf("Expected New York to reference USA, got %q", countryName)
+	}
+
+	results, err = s.Run(ctx, "testdata")
+	if err != nil {
+		t.Fatalf("Second seed failed: %v", err)
+	}
+	for _, result := range results {
+		if result.Created != 0 || result.Updated != 0 {
+			t.Errorf("Expected second run to change nothing, got %+v", result)
+		}
+	}
+}
+
+func TestSeeder_UpdatesChangedRows(t *testing.T) {
+	s := newTestSeeder(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "colors.yml"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("- { name: Black, hexCode: \"#000000\" }\n")
+	if _, err := s.Run(ctx, dir); err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+
+	write("- { name: Black, hexCode: \"#111111\" }\n- { name: White, hexCode: \"#ffffff\" }\n")
+	results, err := s.Run(ctx, dir)
+	if err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+	if got := resultOf(results, "colors"); got.Created != 1 || got.Updated != 1 {
+		t.Errorf("Expected 1 created and 1 updated, got %+v", got)
+	}
+
+	var version int
+	s.database.Table("colors").Select("version").Where("name = ?", "Black").Scan(&version)
+	if version != 2 {
+		t.Errorf("Expected the updated color at version 2, got %d", version)
+	}
+	var audits, events, versions int64
+	s.database.Model(&models.AuditLog{}).Where("entity_type = ?", "colors").Count(&audits)
+	s.database.Model(&models.OutboxEvent{}).Count(&events)
+	s.database.Table(models.Color{}.HistoryTable()).Count(&versions)
+	if audits != 3 || events != 3 {
+		t.Errorf("Expected 3 audit entries and 3 events for 2 creates and 1 update, got %d and %d", audits, events)
+	}
+	if versions != 1 {
+		t.Errorf("Expected the replaced version of Black in the history, got %d rows", versions)
+	}
+}
+
+func TestSeeder_UnknownRelation(t *testing.T) {
+	s := newTestSeeder(t)
+	dir := t.TempDir()
+	content := "- { name: Paris, country: France }\n"
+	if err := os.WriteFile(filepath.Join(dir, "cities.yml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Run(context.Background(), dir); err == nil {
+		t.Error("Expected error for a city referencing a missing country")
+	}
+}
+
+func TestEnsureAdmin(t *testing.T) {
+	s := newTestSeeder(t)
+	ctx := context.Background()
+	roles := func(username string) string {
+		var user models.User
+		if err := s.database.Where("username = ?", username).First(&user).Error; err != nil {
+			t.Fatalf("Failed to read %s: %v", username, err)
+		}
+		return user.Roles
+	}
+
+	if err := EnsureAdmin(ctx, s.database, config.AdminConfig{Username: "root"}); err == nil {
+		t.Error("Expected error for a missing admin without a password")
+	}
+	if err := EnsureAdmin(ctx, s.database, config.AdminConfig{Username: "root", Password: "secret"}); err != nil {
+		t.Fatalf("Bootstrap failed: %v", err)
+	}
+	if got := roles("root"); got != "admin" {
+		t.Errorf("Expected the created user to be admin, got %q", got)
+	}
+
+	if err := EnsureAdmin(ctx, s.database, config.AdminConfig{Username: "root"}); err != nil {
+		t.Errorf("Expected an existing admin to be kept, got %v", err)
+	}
+
+	// alice registered the name before the admin was configured
+	s.database.Create(&models.User{Username: "alice", Password: "hash", Email: "alice@example.com", Roles: "default"})
+	if err := EnsureAdmin(ctx, s.database, config.AdminConfig{Username: "alice", Password: "secret"}); err == nil {
+		t.Error("Expected error for an existing user without the admin role")
+	}
+	if got := roles("alice"); got != "default" {
+		t.Errorf("Expected alice not to be promoted, got %q", got)
+	}
+}
@@ -0,0 +1,18 @@
+package clock
+
+import "time"
+
+// Clock tells the current time. Code that has to be tested at a fixed moment
+// takes a Clock instead of calling time.Now.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// System is the wall clock
+var System Clock = systemClock{}
@@ -0,0 +1,17 @@
+package concurrency
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the row version the caller last read.
+// Repositories only write the row while it still has that version.
+func NewContext(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, contextKey{}, version)
+}
+
+// FromContext returns the expected row version, if the caller set one
+func FromContext(ctx context.Context) (int, bool) {
+	version, ok := ctx.Value(contextKey{}).(int)
+	return version, ok
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, headers []string) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(headers); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer, record: make([]string, len(headers))}, nil
+}
+
+func (w *csvWriter) Write(values []any) error {
+	for i, value := range values {
+		w.record[i] = cell(value)
+	}
+	return w.writer.Write(w.record[:len(values)])
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+// cell keeps spreadsheets from running text that starts like a formula
+func cell(value any) string {
+	s := text(value)
+	if _, ok := value.(string); ok && s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
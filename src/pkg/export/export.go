@@ -0,0 +1,73 @@
+// Package export writes rows of values to csv, ndjson and xlsx documents as they
+// come, so a dump of any size is never held in memory.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	CSV    = "csv"
+	NDJSON = "ndjson"
+	XLSX   = "xlsx"
+)
+
+var contentTypes = map[string]string{
+	CSV:    "text/csv; charset=utf-8",
+	NDJSON: "application/x-ndjson",
+	XLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Writer writes a document row by row. Values are in the order of the columns the
+// writer was made with: strings, json.Number or numbers, bools, nil, or nested
+// maps and slices as decoded from json.
+type Writer interface {
+	Write(values []any) error
+	// Flush hands the rows written so far to the underlying writer
+	Flush() error
+	// Close finishes the document, it does not close the underlying writer
+	Close() error
+}
+
+// Supported tells whether there is a writer for the format
+func Supported(format string) bool {
+	_, ok := contentTypes[format]
+	return ok
+}
+
+// ContentType is the media type of documents in the format
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// NewWriter returns a writer of the format. Columns are json paths such as
+// country.name, headers label them in the first row of csv and xlsx documents.
+func NewWriter(format string, w io.Writer, columns []string, headers []string) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w, headers)
+	case NDJSON:
+		return newNDJSONWriter(w, columns), nil
+	case XLSX:
+		return newXLSXWriter(w, headers)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// text renders a value for a single cell, nested values as compact json
+func text(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case map[string]any, []any:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
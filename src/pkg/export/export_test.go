@@ -0,0 +1,107 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+var (
+	columns = []string{"id", "name", "country.id", "country.name"}
+	headers = []string{"Id", "Name", "Country Id", "Country Name"}
+	rows    = [][]any{
+		{json.Number("1"), "Tehran", json.Number("1"), "Iran"},
+		{json.Number("2"), "=cmd|' /C calc'!A0", nil, nil},
+	}
+)
+
+func write(t *testing.T, format string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer, err := NewWriter(format, &buffer, columns, headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestCSVWriter(t *testing.T) {
+	expected := "Id,Name,Country Id,Country Name\n" +
+		"1,Tehran,1,Iran\n" +
+		"2,'=cmd|' /C calc'!A0,,\n"
+	if got := string(write(t, CSV)); got != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	expected := `{"country":{"id":1,"name":"Iran"},"id":1,"name":"Tehran"}` + "\n" +
+		`{"id":2,"name":"=cmd|' /C calc'!A0"}` + "\n"
+	if got := string(write(t, NDJSON)); got != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	document := write(t, XLSX)
+	archive, err := zip.NewReader(bytes.NewReader(document), int64(len(document)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+		parts[file.Name] = string(content)
+	}
+	for _, part := range xlsxParts {
+		if parts[part.name] != part.content {
+			t.Fatalf("part %s is missing", part.name)
+		}
+	}
+	sheet := parts[xlsxSheet]
+	for _, expected := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">Id</t></is></c>`,
+		`<c r="A2"><v>1</v></c><c r="B2" t="inlineStr"><is><t xml:space="preserve">Tehran</t></is></c>`,
+		`<c r="B3" t="inlineStr"><is><t xml:space="preserve">=cmd|&#39; /C calc&#39;!A0</t></is></c></row>`,
+	} {
+		if !strings.Contains(sheet, expected) {
+			t.Fatalf("expected the sheet to contain %s, got %s", expected, sheet)
+		}
+	}
+	if !strings.HasSuffix(sheet, `</sheetData></worksheet>`) {
+		t.Fatalf("sheet is not closed: %s", sheet)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != expected {
+			t.Fatalf("column %d: expected %s, got %s", i, expected, got)
+		}
+	}
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	if Supported("pdf") {
+		t.Fatal("pdf is not supported")
+	}
+	if _, err := NewWriter("pdf", io.Discard, columns, headers); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// ndjsonWriter writes a json object per line, shaped by the column paths: the
+// column country.name becomes {"country":{"name":...}}. Nil values are left out.
+type ndjsonWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+	paths   [][]string
+}
+
+func newNDJSONWriter(w io.Writer, columns []string) *ndjsonWriter {
+	buffer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	paths := make([][]string, len(columns))
+	for i, column := range columns {
+		paths[i] = strings.Split(column, ".")
+	}
+	return &ndjsonWriter{buffer: buffer, encoder: encoder, paths: paths}
+}
+
+func (w *ndjsonWriter) Write(values []any) error {
+	object := map[string]any{}
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		path := w.paths[i]
+		parent := object
+		for _, name := range path[:len(path)-1] {
+			child, ok := parent[name].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				parent[name] = child
+			}
+			parent = child
+		}
+		parent[path[len(path)-1]] = value
+	}
+	return w.encoder.Encode(object)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return w.buffer.Flush()
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.Flush()
+}
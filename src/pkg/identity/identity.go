@@ -0,0 +1,58 @@
+package identity
+
+import (
+	"context"
+	"slices"
+)
+
+// Identity describes who issued the current request. It travels with the
+// request context.Context from the http middlewares down to the repositories.
+type Identity struct {
+	UserId    int
+	Username  string
+	Roles     []string
+	Tenant    string // empty unless the token names one
+	RequestId string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries the identity
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the identity stored in ctx, if any
+func FromContext(ctx context.Context) (*Identity, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	identity, ok := ctx.Value(contextKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// UserId returns the authenticated user id stored in ctx
+func UserId(ctx context.Context) (int, bool) {
+	identity, ok := FromContext(ctx)
+	if !ok || !identity.IsAuthenticated() {
+		return 0, false
+	}
+	return identity.UserId, true
+}
+
+// RequestId returns the request id stored in ctx or an empty string
+func RequestId(ctx context.Context) string {
+	identity, ok := FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return identity.RequestId
+}
+
+func (i *Identity) IsAuthenticated() bool {
+	return i.UserId > 0
+}
+
+func (i *Identity) HasRole(role string) bool {
+	return slices.Contains(i.Roles, role)
+}
@@ -5,33 +5,45 @@ import (
 	"time"
 
 	"golang-clean-web-api/config"
+	"golang-clean-web-api/pkg/clock"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID   uint     `json:"user_id"`
+	Username string   `json:"username"`
+	Roles    []string `json:"roles,omitempty"`
+	Tenant   string   `json:"tenant,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type TokenService struct {
 	config *config.Config
+	clock  clock.Clock
 }
 
 func NewTokenService(cfg *config.Config) *TokenService {
-	return &TokenService{config: cfg}
+	return NewTokenServiceWithClock(cfg, clock.System)
+}
+
+// NewTokenServiceWithClock issues and checks tokens at the time of c, tokens
+// issued at the same time for the same user are identical
+func NewTokenServiceWithClock(cfg *config.Config, c clock.Clock) *TokenService {
+	return &TokenService{config: cfg, clock: c}
 }
 
 // GenerateAccessToken generates a new access token
-func (s *TokenService) GenerateAccessToken(userID uint, username string) (string, error) {
-	expirationTime := time.Now().Add(s.config.Jwt.AccessExpireTime * time.Minute)
+func (s *TokenService) GenerateAccessToken(userID uint, username string, roles ...string) (string, error) {
+	now := s.clock.Now()
+	expirationTime := now.Add(s.config.Jwt.AccessExpireTime * time.Minute)
 	claims := &Claims{
 		UserID:   userID,
 		Username: username,
+		Roles:    roles,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -40,14 +52,16 @@ func (s *TokenService) GenerateAccessToken(userID uint, username string) (string
 }
 
 // GenerateRefreshToken generates a new refresh token
-func (s *TokenService) GenerateRefreshToken(userID uint, username string) (string, error) {
-	expirationTime := time.Now().Add(s.config.Jwt.RefreshExpireTime * time.Minute)
+func (s *TokenService) GenerateRefreshToken(userID uint, username string, roles ...string) (string, error) {
+	now := s.clock.Now()
+	expirationTime := now.Add(s.config.Jwt.RefreshExpireTime * time.Minute)
 	claims := &Claims{
 		UserID:   userID,
 		Username: username,
+		Roles:    roles,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -63,7 +77,7 @@ func (s *TokenService) ValidateToken(tokenString string) (*Claims, error) {
 			return nil, errors.New("unexpected signing method")
 		}
 		return []byte(s.config.Jwt.Secret), nil
-	})
+	}, jwt.WithTimeFunc(s.clock.Now))
 
 	if err != nil {
 		return nil, err
@@ -22,17 +22,21 @@ const (
 
 	// Postgres
 	Migration SubCategory = "Migration"
+	Seed      SubCategory = "Seed"
 	Select    SubCategory = "Select"
 	Rollback  SubCategory = "Rollback"
 	Update    SubCategory = "Update"
 	Delete    SubCategory = "Delete"
 	Insert    SubCategory = "Insert"
+	Purge     SubCategory = "Purge"
 
 	// Internal
 	Api                 SubCategory = "Api"
 	HashPassword        SubCategory = "HashPassword"
 	DefaultRoleNotFound SubCategory = "DefaultRoleNotFound"
 	FailedToCreateUser  SubCategory = "FailedToCreateUser"
+	Outbox              SubCategory = "Outbox"
+	Webhook             SubCategory = "Webhook"
 
 	// Validation
 	MobileValidation   SubCategory = "MobileValidation"
@@ -55,4 +59,8 @@ const (
 	RequestBody  ExtraKey = "RequestBody"
 	ResponseBody ExtraKey = "ResponseBody"
 	ErrorMessage ExtraKey = "ErrorMessage"
+	EventId      ExtraKey = "EventId"
+	EventType    ExtraKey = "EventType"
+	Aggregate    ExtraKey = "Aggregate"
+	DeliveryId   ExtraKey = "DeliveryId"
 )
@@ -22,5 +22,28 @@ const (
 	InvalidRolesFormat        = "invalid roles format"
 
 	// DB
-	RecordNotFound = "record not found"
+	RecordNotFound      = "record not found"
+	ConcurrencyConflict = "record was modified by someone else"
+	DuplicateRecord     = "record already exists"
+	RecordInUse         = "record is still referenced"
+	InvalidReference    = "referenced record does not exist"
+	RequiredValue       = "value is required"
+	InvalidValue        = "value is not allowed"
+	ValueTooLong        = "value is too long"
+
+	// Bulk
+	BulkTooLarge         = "too many operations in one bulk request"
+	InvalidBulkOperation = "invalid bulk operation"
+	BulkRolledBack       = "not applied because another operation failed"
+
+	// Import
+	ImportTooLarge    = "too many rows in one import"
+	InvalidImportFile = "import file is malformed"
+	InvalidImportRow  = "invalid import row"
+
+	// Filter
+	InvalidFilter = "invalid filter"
+
+	// Webhook
+	AlreadyDelivered = "delivery already succeeded"
 )
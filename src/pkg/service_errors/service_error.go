@@ -3,7 +3,9 @@ package service_errors
 type ServiceError struct {
 	EndUserMessage   string `json:"endUserMessage"`
 	TechnicalMessage string `json:"technicalMessage"`
-	Err              error
+	// Field is the field the error is about, if any
+	Field string `json:"field,omitempty"`
+	Err   error
 }
 
 func (s *ServiceError) Error() string {
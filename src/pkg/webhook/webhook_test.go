@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_SendSigned(t *testing.T) {
+	secret := "receiver-secret"
+	sentAt := time.Unix(1700000000, 0)
+	var verified error
+	var event string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified = Verify(secret, r.Header, body, sentAt.Add(time.Minute), 5*time.Minute)
+		event = r.Header.Get(EventHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	status, err := NewClient(time.Second).Send(context.Background(), receiver.URL, secret, 7, "EntityCreated", []byte(`{"id":7}`), sentAt)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("Expected the receiver to accept, got %d %v", status, err)
+	}
+	if verified != nil || event != "EntityCreated" {
+		t.Fatalf("Expected a verified EntityCreated, got %q %v", event, verified)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":7}`)
+	at := time.Unix(1700000000, 0)
+	header := http.Header{}
+	header.Set(TimestampHeader, "1700000000")
+	header.Set(SignatureHeader, Sign("secret", at.Unix(), body))
+
+	if err := Verify("secret", header, body, at, time.Minute); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+	if err := Verify("other", header, body, at, time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected another secret to fail, got %v", err)
+	}
+	if err := Verify("secret", header, []byte(`{"id":8}`), at, time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected a changed body to fail, got %v", err)
+	}
+	if err := Verify("secret", header, body, at.Add(time.Hour), time.Minute); !errors.Is(err, ErrExpiredSignature) {
+		t.Errorf("Expected an old timestamp to fail, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package testkit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/infra/persistence/database"
+)
+
+// group is the rows that share the values of the group-by fields
+type group struct {
+	values []interface{}
+	rows   []reflect.Value
+}
+
+// Aggregate groups the matching rows like the sql of database.GenerateAggregate:
+// without group-by fields there is one group even when no row matches, counts
+// skip nulls and the other functions are null over a group without values.
+func (r *Repository[T]) Aggregate(ctx context.Context, input filter.AggregateInput) ([]filter.AggregateRow, error) {
+	if _, err := database.GenerateAggregate[T](r.store.dialect, &input); err != nil {
+		return nil, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// the sort names groups and aggregates, not the fields of the rows
+	unsorted := input.DynamicFilter
+	unsorted.Sort = nil
+	rows, err := r.query(ctx, &unsorted, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &matcher{ctx: ctx, store: r.store, schema: r.schema}
+	paths := make([]fieldPath, 0, len(input.GroupBy))
+	for _, name := range input.GroupBy {
+		path, _ := m.resolve(name)
+		paths = append(paths, path)
+	}
+	groups := []*group{}
+	keys := map[string]*group{}
+	if len(paths) == 0 {
+		groups = append(groups, &group{})
+		keys[""] = groups[0]
+	}
+	for _, row := range rows {
+		values := make([]interface{}, 0, len(paths))
+		for _, path := range paths {
+			value, _ := m.sortValue(path, row)
+			values = append(values, value)
+		}
+		key := ""
+		if len(paths) > 0 {
+			encoded, _ := json.Marshal(values)
+			key = string(encoded)
+		}
+		g, ok := keys[key]
+		if !ok {
+			g = &group{values: values}
+			keys[key] = g
+			groups = append(groups, g)
+		}
+		g.rows = append(g.rows, row)
+	}
+
+	results := make([]filter.AggregateRow, 0, len(groups))
+	for _, g := range groups {
+		result := filter.AggregateRow{Group: map[string]interface{}{}, Values: map[string]interface{}{}}
+		for i, name := range input.GroupBy {
+			result.Group[name] = g.values[i]
+		}
+		for _, aggregate := range input.Aggregates {
+			result.Values[aggregate.Name()] = aggregateOf(m, aggregate, g.rows)
+		}
+		results = append(results, result)
+	}
+
+	order := []filter.Sort{}
+	if input.Sort != nil {
+		order = *input.Sort
+	}
+	if len(order) == 0 {
+		for _, name := range input.GroupBy {
+			order = append(order, filter.Sort{ColId: name, Sort: "asc"})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		for _, s := range order {
+			a, b := aggregateValue(results[i], s.ColId), aggregateValue(results[j], s.ColId)
+			c, comparable := compare(a, b)
+			switch {
+			case a == nil && b == nil:
+				c = 0
+			case a == nil:
+				c = 1
+			case b == nil:
+				c = -1
+			case !comparable:
+				c = 0
+			}
+			if s.Sort == "desc" {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	if limit := input.GetLimit(); len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func aggregateOf(m *matcher, aggregate filter.Aggregate, rows []reflect.Value) interface{} {
+	if aggregate.Field == "" {
+		return int64(len(rows))
+	}
+	path, _ := m.resolve(aggregate.Field)
+	values := []interface{}{}
+	for _, row := range rows {
+		if value, _ := m.sortValue(path, row); value != nil {
+			values = append(values, value)
+		}
+	}
+	if aggregate.Function == filter.AggregateCount {
+		return int64(len(values))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	switch aggregate.Function {
+	case filter.AggregateSum, filter.AggregateAvg:
+		sum := 0.0
+		for _, value := range values {
+			switch v := value.(type) {
+			case int64:
+				sum += float64(v)
+			case float64:
+				sum += v
+			}
+		}
+		if aggregate.Function == filter.AggregateAvg {
+			return sum / float64(len(values))
+		}
+		return sum
+	}
+	extreme := values[0]
+	for _, value := range values[1:] {
+		c, _ := compare(value, extreme)
+		if aggregate.Function == filter.AggregateMin && c < 0 || aggregate.Function == filter.AggregateMax && c > 0 {
+			extreme = value
+		}
+	}
+	return extreme
+}
+
+// aggregateValue reads a group-by field or an aggregate of a result row by name
+func aggregateValue(row filter.AggregateRow, name string) interface{} {
+	for _, values := range []map[string]interface{}{row.Group, row.Values} {
+		for key, value := range values {
+			if strings.EqualFold(key, name) {
+				return value
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package testkit
+
+import (
+	"sync"
+	"time"
+
+	"golang-clean-web-api/infra/cache"
+	"golang-clean-web-api/pkg/clock"
+)
+
+// Cache is an in-memory cache.Cache whose entries expire by its clock
+type Cache struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value []byte
+	// expires is zero for entries that never expire
+	expires time.Time
+}
+
+func NewCache(c clock.Clock) *Cache {
+	return &Cache{clock: c, entries: map[string]cacheEntry{}}
+}
+
+func (c *Cache) Set(key string, value []byte, duration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := cacheEntry{value: append([]byte(nil), value...)}
+	if duration > 0 {
+		entry.expires = c.clock.Now().Add(duration)
+	}
+	c.entries[key] = entry
+	return nil
+}
+
+func (c *Cache) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || (!entry.expires.IsZero() && !c.clock.Now().Before(entry.expires)) {
+		delete(c.entries, key)
+		return nil, cache.ErrNotFound
+	}
+	return append([]byte(nil), entry.value...), nil
+}
+
+func (c *Cache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
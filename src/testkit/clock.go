@@ -0,0 +1,39 @@
+package testkit
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a clock.Clock that stands still until a test moves it
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// DefaultTime is where the clock of a new Kit stands
+var DefaultTime = time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to now
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
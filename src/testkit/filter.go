@@ -0,0 +1,380 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-clean-web-api/domain/filter"
+
+	"gorm.io/gorm/schema"
+)
+
+// predicate tells whether a row of the schema a matcher was built for matches
+type predicate func(row reflect.Value) bool
+
+// matcher evaluates a DynamicFilter in memory with the semantics of the sql the
+// database query builder generates. It expects a filter the builder accepted.
+type matcher struct {
+	ctx    context.Context
+	store  *Store
+	schema *schema.Schema
+}
+
+// fieldPath is a column reached from the entity through zero or more relations
+type fieldPath struct {
+	relations []*schema.Relationship
+	field     *schema.Field
+}
+
+// compile turns the filter into a predicate over rows that are not soft deleted,
+// or over the soft deleted ones
+func (m *matcher) compile(f *filter.DynamicFilter, deleted bool) (predicate, error) {
+	predicates := []predicate{func(row reflect.Value) bool {
+		return isDeleted(m.ctx, m.schema, row) == deleted
+	}}
+	for name, condition := range f.Filter {
+		path, ok := m.resolve(name)
+		if !ok {
+			continue
+		}
+		p, err := m.condition(path, condition)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, p)
+	}
+	if f.Where != nil {
+		p, err := m.expression(f.Where)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, p)
+	}
+	return allOf(predicates), nil
+}
+
+func (m *matcher) expression(e *filter.Expression) (predicate, error) {
+	if !e.IsGroup() {
+		path, ok := m.resolve(e.Field)
+		if !ok {
+			return nil, invalidFilter("unknown field %s", e.Field)
+		}
+		return m.condition(path, e.Filter)
+	}
+	if e.Not != nil {
+		p, err := m.expression(e.Not)
+		if err != nil {
+			return nil, err
+		}
+		return func(row reflect.Value) bool { return !p(row) }, nil
+	}
+
+	children, or := e.And, false
+	if e.Or != nil {
+		children, or = e.Or, true
+	}
+	predicates := make([]predicate, 0, len(children))
+	for i := range children {
+		p, err := m.expression(&children[i])
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, p)
+	}
+	if or {
+		return anyOf(predicates), nil
+	}
+	return allOf(predicates), nil
+}
+
+// condition matches when the column, or the column of any live related row, passes
+func (m *matcher) condition(path fieldPath, f filter.Filter) (predicate, error) {
+	test, err := compileTest(path.field, f)
+	if err != nil {
+		return nil, err
+	}
+	var nest func(relations []*schema.Relationship, row reflect.Value) bool
+	nest = func(relations []*schema.Relationship, row reflect.Value) bool {
+		if len(relations) == 0 {
+			return test(valueOf(m.ctx, path.field, row))
+		}
+		for _, related := range m.store.related(m.ctx, relations[0], row) {
+			if nest(relations[1:], related) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(row reflect.Value) bool { return nest(path.relations, row) }, nil
+}
+
+// resolve finds Name or Country.Name like the query builder, which already
+// rejected the relations that are not allowed
+func (m *matcher) resolve(name string) (fieldPath, bool) {
+	segments := strings.Split(name, ".")
+	path := fieldPath{}
+	current := m.schema
+	for _, segment := range segments[:len(segments)-1] {
+		relation := findRelation(current, segment)
+		if relation == nil {
+			return path, false
+		}
+		path.relations = append(path.relations, relation)
+		current = relation.FieldSchema
+	}
+	path.field = findColumn(current, segments[len(segments)-1])
+	return path, path.field != nil
+}
+
+// sortValue reads the sort key of a row, through belongs-to and has-one relations
+func (m *matcher) sortValue(path fieldPath, row reflect.Value) (interface{}, bool) {
+	for _, relation := range path.relations {
+		if relation.Type != schema.BelongsTo && relation.Type != schema.HasOne {
+			return nil, false
+		}
+		related := m.store.related(m.ctx, relation, row)
+		if len(related) == 0 {
+			return nil, true
+		}
+		row = related[0]
+	}
+	return valueOf(m.ctx, path.field, row), true
+}
+
+// related returns the live rows a relation of row points to
+func (s *Store) related(ctx context.Context, relation *schema.Relationship, row reflect.Value) []reflect.Value {
+	matches := []reflect.Value{}
+	for _, candidate := range s.rows(relation.FieldSchema.Table) {
+		if isDeleted(ctx, relation.FieldSchema, candidate) {
+			continue
+		}
+		correlated := true
+		for _, reference := range relation.References {
+			if reference.PrimaryKey == nil {
+				continue
+			}
+			var own, other interface{}
+			if reference.OwnPrimaryKey {
+				own, other = valueOf(ctx, reference.PrimaryKey, row), valueOf(ctx, reference.ForeignKey, candidate)
+			} else {
+				own, other = valueOf(ctx, reference.ForeignKey, row), valueOf(ctx, reference.PrimaryKey, candidate)
+			}
+			if own == nil || own != other {
+				correlated = false
+				break
+			}
+		}
+		if correlated {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
+
+func isDeleted(ctx context.Context, sch *schema.Schema, row reflect.Value) bool {
+	field := sch.LookUpField("deleted_by")
+	return field != nil && valueOf(ctx, field, row) != nil
+}
+
+// compileTest builds the test of one column value. Like sql, null passes
+// nothing but isNull.
+func compileTest(field *schema.Field, f filter.Filter) (func(value interface{}) bool, error) {
+	switch f.Type {
+	case "isNull":
+		return func(value interface{}) bool { return value == nil }, nil
+	case "isNotNull":
+		return func(value interface{}) bool { return value != nil }, nil
+	case "contains", "notContains", "startsWith", "endsWith":
+		pattern := strings.ToLower(f.From)
+		match := map[string]func(string, string) bool{
+			"contains":    strings.Contains,
+			"notContains": func(s string, p string) bool { return !strings.Contains(s, p) },
+			"startsWith":  strings.HasPrefix,
+			"endsWith":    strings.HasSuffix,
+		}[f.Type]
+		return func(value interface{}) bool {
+			return value != nil && match(strings.ToLower(fmt.Sprint(value)), pattern)
+		}, nil
+	case "in", "notIn":
+		values := make([]interface{}, 0, len(f.Values))
+		for _, raw := range f.Values {
+			v, err := convertValue(field, raw)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		in := f.Type == "in"
+		return func(value interface{}) bool {
+			if value == nil {
+				return false
+			}
+			for _, v := range values {
+				if c, ok := compare(value, v); ok && c == 0 {
+					return in
+				}
+			}
+			return !in
+		}, nil
+	case "inRange":
+		from, err := convertValue(field, f.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := convertValue(field, f.To)
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) bool {
+			low, okLow := compare(value, from)
+			high, okHigh := compare(value, to)
+			return okLow && okHigh && low >= 0 && high <= 0
+		}, nil
+	}
+
+	accept := map[string]func(int) bool{
+		"equals":             func(c int) bool { return c == 0 },
+		"notEqual":           func(c int) bool { return c != 0 },
+		"lessThan":           func(c int) bool { return c < 0 },
+		"lessThanOrEqual":    func(c int) bool { return c <= 0 },
+		"greaterThan":        func(c int) bool { return c > 0 },
+		"greaterThanOrEqual": func(c int) bool { return c >= 0 },
+	}[f.Type]
+	if accept == nil {
+		return nil, invalidFilter("unknown filter type %q on %s", f.Type, field.Name)
+	}
+	v, err := convertValue(field, f.From)
+	if err != nil {
+		return nil, err
+	}
+	return func(value interface{}) bool {
+		c, ok := compare(value, v)
+		return ok && accept(c)
+	}, nil
+}
+
+// convertValue parses a raw filter value into the type the column holds
+func convertValue(field *schema.Field, raw string) (interface{}, error) {
+	switch field.DataType {
+	case schema.Int, schema.Uint:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, invalidFilter("%s expects a number, got %q", field.Name, raw)
+		}
+		return v, nil
+	case schema.Float:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, invalidFilter("%s expects a number, got %q", field.Name, raw)
+		}
+		return v, nil
+	case schema.Bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, invalidFilter("%s expects a boolean, got %q", field.Name, raw)
+		}
+		return v, nil
+	case schema.Time:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+			if v, err := time.Parse(layout, raw); err == nil {
+				return v, nil
+			}
+		}
+		return nil, invalidFilter("%s expects a time, got %q", field.Name, raw)
+	}
+	return raw, nil
+}
+
+// compare orders two values of a column, false when either is null or they do not compare
+func compare(a interface{}, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return order(x < y, x > y), true
+		case float64:
+			return order(float64(x) < y, float64(x) > y), true
+		}
+	case float64:
+		switch y := b.(type) {
+		case float64:
+			return order(x < y, x > y), true
+		case int64:
+			return order(x < float64(y), x > float64(y)), true
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			return order(!x && y, x && !y), true
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y), true
+		}
+	}
+	return 0, false
+}
+
+func order(less bool, greater bool) int {
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
+func allOf(predicates []predicate) predicate {
+	return func(row reflect.Value) bool {
+		for _, p := range predicates {
+			if !p(row) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func anyOf(predicates []predicate) predicate {
+	return func(row reflect.Value) bool {
+		for _, p := range predicates {
+			if p(row) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func findColumn(s *schema.Schema, name string) *schema.Field {
+	if field := s.LookUpField(name); field != nil && field.DBName != "" {
+		return field
+	}
+	for _, field := range s.Fields {
+		if field.DBName != "" && (strings.EqualFold(field.Name, name) || strings.EqualFold(field.DBName, name)) {
+			return field
+		}
+	}
+	return nil
+}
+
+func findRelation(s *schema.Schema, name string) *schema.Relationship {
+	for relationName, relation := range s.Relationships.Relations {
+		if strings.EqualFold(relationName, name) {
+			return relation
+		}
+	}
+	return nil
+}
+
+func invalidFilter(format string, args ...interface{}) error {
+	return filter.Invalid("filter", format, args...)
+}
@@ -0,0 +1,73 @@
+package testkit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"golang-clean-web-api/domain/model"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultPassword is the password of every user the fixtures create
+const DefaultPassword = "password"
+
+// User creates an active user with DefaultPassword and the roles
+func (k *Kit) User(t testing.TB, username string, roles ...string) model.User {
+	t.Helper()
+	hashed, err := bcrypt.GenerateFromPassword([]byte(DefaultPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := k.Users.Create(context.Background(), model.User{
+		Username: username,
+		Password: string(hashed),
+		Email:    username + "@example.com",
+		IsActive: true,
+		Roles:    strings.Join(roles, ","),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// Token issues an access token of the user at the time of the clock
+func (k *Kit) Token(t testing.TB, user model.User) string {
+	t.Helper()
+	token, err := k.Tokens.GenerateAccessToken(uint(user.Id), user.Username, user.RoleList()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func (k *Kit) Country(t testing.TB, name string) model.Country {
+	t.Helper()
+	return create(t, k.Countries, model.Country{Name: name})
+}
+
+func (k *Kit) City(t testing.TB, name string, country model.Country) model.City {
+	t.Helper()
+	return create(t, k.Cities, model.City{Name: name, CountryId: country.Id})
+}
+
+func (k *Kit) Company(t testing.TB, name string, country model.Country) model.Company {
+	t.Helper()
+	return create(t, k.Companies, model.Company{Name: name, CountryId: country.Id})
+}
+
+func (k *Kit) Color(t testing.TB, name string, hexCode string) model.Color {
+	t.Helper()
+	return create(t, k.Colors, model.Color{Name: name, HexCode: hexCode})
+}
+
+func create[T any](t testing.TB, r *Repository[T], entity T) T {
+	t.Helper()
+	created, err := r.Create(context.Background(), entity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
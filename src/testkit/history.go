@@ -0,0 +1,75 @@
+package testkit
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"golang-clean-web-api/infra/persistence/database"
+)
+
+// version is a past version of a row and the period it was current, from
+// inclusive to exclusive, like a row of a history table
+type version struct {
+	row      interface{}
+	from, to time.Time
+}
+
+type historyTable interface {
+	HistoryTable() string
+}
+
+// archive keeps the stored row in the history of the model, if it has one,
+// before a write replaces it now. Callers write the row right after.
+func (r *Repository[T]) archive(ctx context.Context, id int) {
+	tabled, ok := any(new(T)).(historyTable)
+	if !ok {
+		return
+	}
+	row, ok := r.store.row(r.schema.Table, id)
+	if !ok {
+		return
+	}
+	// the history is a table of the store, so transactions undo it with the rest
+	t := r.store.table(tabled.HistoryTable())
+	t.nextId++
+	t.rows[t.nextId] = version{row: row.Interface(), from: r.since(ctx, row), to: r.now()}
+}
+
+// since is when the version of a row began, its last write
+func (r *Repository[T]) since(ctx context.Context, row reflect.Value) time.Time {
+	for _, column := range []string{"deleted_at", "modified_at", "created_at"} {
+		if field := r.schema.LookUpField(column); field != nil {
+			if at, ok := valueOf(ctx, field, row).(time.Time); ok {
+				return at
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// rowsAsOf returns the rows as they were at the time in id order, deleted ones
+// included, like database.GenerateAsOf. A nil time returns the current rows.
+func (r *Repository[T]) rowsAsOf(ctx context.Context, at *time.Time) ([]reflect.Value, error) {
+	if at == nil {
+		return r.store.rows(r.schema.Table), nil
+	}
+	if _, err := database.GenerateAsOf[T](r.store.dialect, *at); err != nil {
+		return nil, err
+	}
+	rows := []reflect.Value{}
+	for _, row := range r.store.rows(r.schema.Table) {
+		if !r.since(ctx, row).After(*at) {
+			rows = append(rows, row)
+		}
+	}
+	for _, stored := range r.store.table(any(new(T)).(historyTable).HistoryTable()).rows {
+		v := stored.(version)
+		if !v.from.After(*at) && v.to.After(*at) {
+			rows = append(rows, copyRow(v.row))
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return r.id(ctx, rows[i]) < r.id(ctx, rows[j]) })
+	return rows, nil
+}
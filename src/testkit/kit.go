@@ -0,0 +1,98 @@
+package testkit
+
+import (
+	"testing"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/domain/repository"
+	"golang-clean-web-api/infra/cache"
+	"golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/jwt"
+)
+
+// Kit holds the fakes of one test. It is a dependency.Container, so handlers
+// registered while it is in use run against it.
+type Kit struct {
+	Config *config.Config
+	Clock  *Clock
+	Store  *Store
+	// Memory is the cache, named apart from the Cache method of the container
+	Memory *Cache
+	Tokens *jwt.TokenService
+
+	Countries  *Repository[model.Country]
+	Cities     *Repository[model.City]
+	Colors     *Repository[model.Color]
+	Companies  *Repository[model.Company]
+	Audit      *Repository[model.AuditLog]
+	Users      *UserRepository
+	Search     *SearchRepository
+	Outbox     *OutboxRepository
+	Webhooks   *WebhookSubscriptionRepository
+	Deliveries *WebhookDeliveryRepository
+}
+
+// New returns a kit with an empty store and the clock at DefaultTime. The config
+// is a copy of the one of the environment, tests may change it.
+func New(t testing.TB) *Kit {
+	t.Helper()
+	cfg := *config.GetConfig()
+	c := NewClock(DefaultTime)
+	store := NewStore(c)
+	k := &Kit{
+		Config: &cfg,
+		Clock:  c,
+		Store:  store,
+		Memory: NewCache(c),
+		Tokens: jwt.NewTokenServiceWithClock(&cfg, c),
+
+		Countries: NewRepository[model.Country](store,
+			database.PreloadEntity{Entity: "Cities", OnDemand: true}, database.PreloadEntity{Entity: "Companies", OnDemand: true}),
+		Cities:     NewRepository[model.City](store, database.PreloadEntity{Entity: "Country"}),
+		Colors:     NewRepository[model.Color](store),
+		Companies:  NewRepository[model.Company](store, database.PreloadEntity{Entity: "Country"}),
+		Audit:      NewRepository[model.AuditLog](store),
+		Users:      NewUserRepository(store),
+		Outbox:     NewOutboxRepository(store),
+		Webhooks:   NewWebhookSubscriptionRepository(store),
+		Deliveries: NewWebhookDeliveryRepository(store),
+	}
+	k.Search = NewSearchRepository(
+		SearchType{Name: "country", Repository: k.Countries},
+		SearchType{Name: "city", Repository: k.Cities},
+		SearchType{Name: "company", Repository: k.Companies},
+		SearchType{Name: "color", Repository: k.Colors},
+	)
+	return k
+}
+
+func (k *Kit) CountryRepository(*config.Config) repository.CountryRepository { return k.Countries }
+
+func (k *Kit) CityRepository(*config.Config) repository.CityRepository { return k.Cities }
+
+func (k *Kit) ColorRepository(*config.Config) repository.ColorRepository { return k.Colors }
+
+func (k *Kit) CompanyRepository(*config.Config) repository.CompanyRepository { return k.Companies }
+
+func (k *Kit) AuditRepository(*config.Config) repository.AuditRepository { return k.Audit }
+
+func (k *Kit) UserRepository(*config.Config) repository.UserRepository { return k.Users }
+
+func (k *Kit) SearchRepository(*config.Config) repository.SearchRepository { return k.Search }
+
+func (k *Kit) OutboxRepository(*config.Config) repository.OutboxRepository { return k.Outbox }
+
+func (k *Kit) WebhookSubscriptionRepository(*config.Config) repository.WebhookSubscriptionRepository {
+	return k.Webhooks
+}
+
+func (k *Kit) WebhookDeliveryRepository(*config.Config) repository.WebhookDeliveryRepository {
+	return k.Deliveries
+}
+
+func (k *Kit) TransactionManager() repository.TransactionManager { return k.Store }
+
+func (k *Kit) TokenService(*config.Config) *jwt.TokenService { return k.Tokens }
+
+func (k *Kit) Cache() cache.Cache { return k.Memory }
@@ -0,0 +1,167 @@
+package testkit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang-clean-web-api/domain/event"
+	"golang-clean-web-api/domain/model"
+)
+
+// record writes the domain event of an audited write like the database repository
+func (r *Repository[T]) record(ctx context.Context, entry *model.AuditLog, before map[string]interface{}, after map[string]interface{}) {
+	eventType, ok := model.AuditEvents[entry.Action]
+	if !ok {
+		return
+	}
+	row := after
+	if row == nil {
+		row = before
+	}
+	fields := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		name := column
+		if field := r.schema.LookUpField(column); field != nil {
+			name = field.Name
+		}
+		fields[name] = value
+	}
+	payload, _ := json.Marshal(fields)
+
+	e := model.OutboxEvent{
+		EventType:     eventType,
+		AggregateType: entry.EntityType,
+		AggregateId:   entry.EntityId,
+		Payload:       string(payload),
+		UserId:        entry.UserId,
+		RequestId:     entry.RequestId,
+		CreatedAt:     entry.CreatedAt,
+		NextAttemptAt: entry.CreatedAt,
+	}
+	sch, err := r.store.parse(&e)
+	if err != nil {
+		panic(err)
+	}
+	r.store.put(ctx, sch, reflect.ValueOf(&e).Elem())
+}
+
+// OutboxRepository is an in-memory repository.OutboxRepository over the events
+// the fake repositories record
+type OutboxRepository struct {
+	store *Store
+	// locked is set while a relay holds the lock
+	locked atomic.Bool
+}
+
+func NewOutboxRepository(store *Store) *OutboxRepository {
+	return &OutboxRepository{store: store}
+}
+
+// Events returns every recorded event, published or not, oldest first
+func (r *OutboxRepository) Events() []model.OutboxEvent {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	events := []model.OutboxEvent{}
+	for _, row := range r.store.rows(model.OutboxEvent{}.TableName()) {
+		events = append(events, row.Interface().(model.OutboxEvent))
+	}
+	return events
+}
+
+// Lock lets one relay in at a time, like the advisory lock of the database one
+func (r *OutboxRepository) Lock(ctx context.Context) (func(), bool, error) {
+	if !r.locked.CompareAndSwap(false, true) {
+		return nil, false, nil
+	}
+	return func() { r.locked.Store(false) }, true, nil
+}
+
+func (r *OutboxRepository) Pending(ctx context.Context, now time.Time, limit int) ([]model.OutboxEvent, error) {
+	type aggregate struct {
+		table string
+		id    int
+	}
+	blocked := map[aggregate]bool{}
+	pending := []model.OutboxEvent{}
+	for _, e := range r.Events() {
+		if e.PublishedAt.Valid {
+			continue
+		}
+		key := aggregate{e.AggregateType, e.AggregateId}
+		if !blocked[key] && !e.NextAttemptAt.After(now) && len(pending) < limit {
+			pending = append(pending, e)
+		}
+		blocked[key] = true
+	}
+	return pending, nil
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id int, at time.Time) error {
+	return r.update(ctx, id, func(e *model.OutboxEvent) {
+		e.PublishedAt = sql.NullTime{Valid: true, Time: at}
+		e.Attempts++
+		e.LastError = ""
+	})
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int, next time.Time, reason string) error {
+	return r.update(ctx, id, func(e *model.OutboxEvent) {
+		e.NextAttemptAt = next
+		e.Attempts++
+		e.LastError = reason
+	})
+}
+
+func (r *OutboxRepository) update(ctx context.Context, id int, change func(e *model.OutboxEvent)) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.row(model.OutboxEvent{}.TableName(), id)
+	if !ok {
+		return nil
+	}
+	e := row.Interface().(model.OutboxEvent)
+	change(&e)
+	sch, err := r.store.parse(&e)
+	if err != nil {
+		return err
+	}
+	r.store.put(ctx, sch, reflect.ValueOf(&e).Elem())
+	return nil
+}
+
+// ErrSinkDown is what a failing Sink answers
+var ErrSinkDown = errors.New("testkit: sink is down")
+
+// Sink is an event.Sink that keeps what it is given. While Down it fails every delivery.
+type Sink struct {
+	mu        sync.Mutex
+	Down      bool
+	published []event.Event
+}
+
+func (s *Sink) Name() string {
+	return "testkit"
+}
+
+func (s *Sink) Publish(ctx context.Context, e event.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Down {
+		return ErrSinkDown
+	}
+	s.published = append(s.published, e)
+	return nil
+}
+
+// Published returns the delivered events in delivery order
+func (s *Sink) Published() []event.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]event.Event{}, s.published...)
+}
@@ -0,0 +1,797 @@
+package testkit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-clean-web-api/common"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/infra/persistence/database"
+	"golang-clean-web-api/pkg/concurrency"
+	"golang-clean-web-api/pkg/identity"
+	"golang-clean-web-api/pkg/service_errors"
+
+	"gorm.io/gorm/schema"
+)
+
+// cursorSecret signs the cursors of every fake repository
+var cursorSecret = []byte("testkit")
+
+// Repository is an in-memory repository.BaseRepository. It behaves like the
+// database one as far as usecases and handlers can tell: soft deletes, row
+// versions checked against concurrency.FromContext, audit records, natural keys
+// that stay unique among live rows, projections, preloads, the dynamic filter,
+// offset and cursor pagination.
+type Repository[T any] struct {
+	store    *Store
+	schema   *schema.Schema
+	preloads []database.PreloadEntity
+	// unaudited repositories write no audit records, like the database user repository
+	unaudited bool
+}
+
+// NewRepository returns the repository of T in the store, preloads are the
+// relations it loads like the database repository configured in dependency
+func NewRepository[T any](store *Store, preloads ...database.PreloadEntity) *Repository[T] {
+	sch, err := store.parse(new(T))
+	if err != nil {
+		panic(err)
+	}
+	return &Repository[T]{store: store, schema: sch, preloads: preloads}
+}
+
+// auditIgnored are bookkeeping columns, the audit record carries actor and time itself
+var auditIgnored = map[string]bool{
+	"created_at":  true,
+	"created_by":  true,
+	"modified_at": true,
+	"modified_by": true,
+	"version":     true,
+}
+
+// upsertIgnored are the columns an upsert leaves alone on an existing row
+var upsertIgnored = map[string]bool{
+	"created_at":  true,
+	"created_by":  true,
+	"modified_at": true,
+	"modified_by": true,
+	"deleted_at":  true,
+	"deleted_by":  true,
+	"version":     true,
+}
+
+type naturalKeyed interface {
+	NaturalKey() string
+}
+
+func (r *Repository[T]) Create(ctx context.Context, entity T) (T, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.insert(ctx, entity)
+}
+
+// CreateMany inserts every entity or, when one fails, none
+func (r *Repository[T]) CreateMany(ctx context.Context, entities []T) ([]T, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	created := make([]T, 0, len(entities))
+	err := r.store.atomic(func() error {
+		for _, entity := range entities {
+			entity, err := r.insert(ctx, entity)
+			if err != nil {
+				return err
+			}
+			created = append(created, entity)
+		}
+		return nil
+	})
+	if err != nil {
+		return entities, err
+	}
+	return created, nil
+}
+
+func (r *Repository[T]) insert(ctx context.Context, entity T) (T, error) {
+	row := reflect.ValueOf(&entity).Elem()
+	if id, _ := toInt(valueOf(ctx, r.schema.PrioritizedPrimaryField, row)); id != 0 {
+		if _, exists := r.store.row(r.schema.Table, id); exists {
+			return entity, &service_errors.ServiceError{EndUserMessage: service_errors.DuplicateRecord,
+				TechnicalMessage: fmt.Sprintf("%s %d already exists", r.schema.Table, id)}
+		}
+	}
+	userId := -1
+	if value, ok := identity.UserId(ctx); ok {
+		userId = value
+	}
+	r.set(ctx, row, "created_at", r.now())
+	r.set(ctx, row, "created_by", userId)
+	if version := r.schema.LookUpField("version"); version != nil {
+		if current, _ := toInt(valueOf(ctx, version, row)); current == 0 {
+			r.set(ctx, row, "version", 1)
+		}
+	}
+	if err := r.unique(ctx, row, 0); err != nil {
+		return entity, err
+	}
+
+	id := r.store.put(ctx, r.schema, r.stripped(row))
+	_ = r.schema.PrioritizedPrimaryField.Set(ctx, row, id)
+	r.audit(ctx, model.AuditCreate, id, nil, r.snapshot(ctx, row))
+	return entity, nil
+}
+
+// Upsert inserts the entity, or overwrites the live row with the same natural key
+func (r *Repository[T]) Upsert(ctx context.Context, entity T) (T, bool, error) {
+	keyed, ok := any(entity).(naturalKeyed)
+	if !ok {
+		return entity, false, fmt.Errorf("%T has no natural key", entity)
+	}
+	key := r.schema.LookUpField(keyed.NaturalKey())
+	if key == nil {
+		return entity, false, fmt.Errorf("natural key %s is not a field of %s", keyed.NaturalKey(), r.schema.Table)
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	incoming := reflect.ValueOf(&entity).Elem()
+	existing, found := r.find(ctx, func(row reflect.Value) bool {
+		return !isDeleted(ctx, r.schema, row) && valueOf(ctx, key, row) == valueOf(ctx, key, incoming)
+	})
+	if !found {
+		created, err := r.insert(ctx, entity)
+		return created, err == nil, err
+	}
+
+	id := r.id(ctx, existing)
+	before := r.snapshot(ctx, existing)
+	for _, field := range r.schema.Fields {
+		if field.DBName != "" && !field.PrimaryKey && field != key && !upsertIgnored[field.DBName] {
+			value, _ := field.ValueOf(ctx, incoming)
+			_ = field.Set(ctx, existing, value)
+		}
+	}
+	r.modified(ctx, existing)
+	r.archive(ctx, id)
+	r.store.put(ctx, r.schema, existing)
+	r.audit(ctx, model.AuditUpdate, id, before, r.snapshot(ctx, existing))
+	return existing.Interface().(T), false, nil
+}
+
+// Update writes the changes, named by field or column, and bumps the row version
+func (r *Repository[T]) Update(ctx context.Context, id int, entity map[string]interface{}) (T, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, err := r.writable(ctx, id)
+	if err != nil {
+		return *new(T), err
+	}
+	before := r.snapshot(ctx, row)
+	for name, value := range entity {
+		field := findColumn(r.schema, common.ToSnakeCase(name))
+		if field == nil {
+			field = findColumn(r.schema, name)
+		}
+		if field == nil {
+			return *new(T), fmt.Errorf("%s has no column %s", r.schema.Table, name)
+		}
+		if err := field.Set(ctx, row, value); err != nil {
+			return *new(T), err
+		}
+	}
+	r.modified(ctx, row)
+	if err := r.unique(ctx, row, id); err != nil {
+		return *new(T), err
+	}
+	r.archive(ctx, id)
+	r.store.put(ctx, r.schema, row)
+	r.audit(ctx, model.AuditUpdate, id, before, r.snapshot(ctx, row))
+	return row.Interface().(T), nil
+}
+
+func (r *Repository[T]) Delete(ctx context.Context, id int) error {
+	userId, ok := identity.UserId(ctx)
+	if !ok {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, err := r.writable(ctx, id)
+	if err != nil {
+		return err
+	}
+	before := r.snapshot(ctx, row)
+	r.set(ctx, row, "deleted_at", sql.NullTime{Valid: true, Time: r.now()})
+	r.set(ctx, row, "deleted_by", &sql.NullInt64{Int64: int64(userId), Valid: true})
+	r.bump(ctx, row)
+	r.archive(ctx, id)
+	r.store.put(ctx, r.schema, row)
+	r.audit(ctx, model.AuditDelete, id, before, r.snapshot(ctx, row))
+	return nil
+}
+
+// Restore brings a soft deleted row back, recording who restored it as the modifier
+func (r *Repository[T]) Restore(ctx context.Context, id int) error {
+	if _, ok := identity.UserId(ctx); !ok {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.PermissionDenied}
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.row(r.schema.Table, id)
+	if !ok || !isDeleted(ctx, r.schema, row) {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+	before := r.snapshot(ctx, row)
+	r.set(ctx, row, "deleted_at", sql.NullTime{})
+	r.set(ctx, row, "deleted_by", nil)
+	r.modified(ctx, row)
+	if err := r.unique(ctx, row, id); err != nil {
+		return err
+	}
+	r.archive(ctx, id)
+	r.store.put(ctx, r.schema, row)
+	r.audit(ctx, model.AuditRestore, id, before, r.snapshot(ctx, row))
+	return nil
+}
+
+// Purge permanently removes a soft deleted row
+func (r *Repository[T]) Purge(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.row(r.schema.Table, id)
+	if !ok || !isDeleted(ctx, r.schema, row) {
+		return &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+	r.archive(ctx, id)
+	r.store.remove(r.schema.Table, id)
+	r.audit(ctx, model.AuditPurge, id, r.snapshot(ctx, row), nil)
+	return nil
+}
+
+// PurgeDeletedBefore permanently removes the rows soft deleted before the given time
+func (r *Repository[T]) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	deletedAt := r.schema.LookUpField("deleted_at")
+	if deletedAt == nil {
+		return 0, nil
+	}
+	var purged int64
+	for _, row := range r.store.rows(r.schema.Table) {
+		at, ok := valueOf(ctx, deletedAt, row).(time.Time)
+		if !isDeleted(ctx, r.schema, row) || !ok || !at.Before(before) {
+			continue
+		}
+		id := r.id(ctx, row)
+		r.archive(ctx, id)
+		r.store.remove(r.schema.Table, id)
+		r.audit(ctx, model.AuditPurge, id, r.snapshot(ctx, row), nil)
+		purged++
+	}
+	return purged, nil
+}
+
+func (r *Repository[T]) GetById(ctx context.Context, id int) (T, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	projection, err := filter.AsOfProjection(filter.AsOfFromContext(ctx), filter.ProjectionFromContext(ctx), nil)
+	if err != nil {
+		return *new(T), err
+	}
+	columns, preloads, err := database.GenerateProjection[T](r.store.dialect, projection, r.preloads)
+	if err != nil {
+		return *new(T), err
+	}
+	row, err := r.liveAsOf(ctx, id, filter.AsOfFromContext(ctx))
+	if err != nil {
+		return *new(T), err
+	}
+	return r.project(ctx, row, columns, preloads), nil
+}
+
+func (r *Repository[T]) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]T, error) {
+	return r.page(ctx, req, false)
+}
+
+// GetDeleted pages through the soft deleted rows with the same filter
+func (r *Repository[T]) GetDeleted(ctx context.Context, req filter.PaginationInputWithFilter) (int64, *[]T, error) {
+	return r.page(ctx, req, true)
+}
+
+// GetHistory pages the audit records of one entity, oldest first unless sorted otherwise
+func (r *Repository[T]) GetHistory(ctx context.Context, id int, req filter.PaginationInputWithFilter) (int64, *[]model.AuditLog, error) {
+	req.AndWhere(
+		filter.Expression{Field: "EntityType", Filter: filter.Filter{Type: "equals", From: r.schema.Table}},
+		filter.Expression{Field: "EntityId", Filter: filter.Filter{Type: "equals", From: strconv.Itoa(id)}},
+	)
+	if req.Sort == nil || len(*req.Sort) == 0 {
+		req.Sort = &[]filter.Sort{{ColId: "Id", Sort: "asc"}}
+	}
+	return NewRepository[model.AuditLog](r.store).GetByFilter(ctx, req)
+}
+
+func (r *Repository[T]) page(ctx context.Context, req filter.PaginationInputWithFilter, deleted bool) (int64, *[]T, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	projection, err := filter.AsOfProjection(req.AsOf, &req.Projection, &req.DynamicFilter)
+	if err != nil {
+		return 0, &[]T{}, err
+	}
+	columns, preloads, err := database.GenerateProjection[T](r.store.dialect, projection, r.preloads)
+	if err != nil {
+		return 0, &[]T{}, err
+	}
+	rows, err := r.query(ctx, &req.DynamicFilter, req.AsOf, deleted)
+	if err != nil {
+		return 0, &[]T{}, err
+	}
+
+	items := []T{}
+	for i := req.GetOffset(); i < len(rows) && len(items) < req.GetPageSize(); i++ {
+		items = append(items, r.project(ctx, rows[i], columns, preloads))
+	}
+	return int64(len(rows)), &items, nil
+}
+
+// GetByCursor reads a page after (or before) the row a cursor points at in sort order
+func (r *Repository[T]) GetByCursor(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.Cursors, *[]T, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	cursors := &filter.Cursors{}
+	if _, err := database.NewKeyset[T](r.store.dialect, &req.DynamicFilter); err != nil {
+		return cursors, &[]T{}, err
+	}
+	projection, err := filter.AsOfProjection(req.AsOf, &req.Projection, &req.DynamicFilter)
+	if err != nil {
+		return cursors, &[]T{}, err
+	}
+	columns, preloads, err := database.GenerateProjection[T](r.store.dialect, projection, r.preloads)
+	if err != nil {
+		return cursors, &[]T{}, err
+	}
+	rows, err := r.query(ctx, &req.DynamicFilter, req.AsOf, false)
+	if err != nil {
+		return cursors, &[]T{}, err
+	}
+	if req.WithTotal {
+		total := int64(len(rows))
+		cursors.TotalRows = &total
+	}
+
+	fingerprint := r.fingerprint(&req.DynamicFilter)
+	start, end, backward := 0, len(rows), false
+	if req.Cursor != "" {
+		cursor, err := filter.DecodeCursor(cursorSecret, req.Cursor, fingerprint)
+		if err != nil {
+			return cursors, &[]T{}, err
+		}
+		var id int
+		if len(cursor.Values) != 1 || json.Unmarshal(cursor.Values[0], &id) != nil {
+			return cursors, &[]T{}, filter.Invalid("cursor", "cursor is malformed")
+		}
+		position := len(rows)
+		for i, row := range rows {
+			if r.id(ctx, row) == id {
+				position = i
+				break
+			}
+		}
+		backward = cursor.Backward
+		if backward {
+			end = position
+		} else {
+			start = min(position+1, len(rows))
+		}
+	}
+
+	size := req.GetPageSize()
+	more := end-start > size
+	if backward {
+		start = max(start, end-size)
+	} else {
+		end = min(end, start+size)
+	}
+	items := make([]T, 0, end-start)
+	for _, row := range rows[start:end] {
+		items = append(items, r.project(ctx, row, columns, preloads))
+	}
+	hasNext, hasPrevious := more, req.Cursor != ""
+	if backward {
+		hasNext, hasPrevious = true, more
+	}
+	if len(items) == 0 {
+		return cursors, &items, nil
+	}
+	if hasNext {
+		if cursors.Next, err = r.cursor(ctx, rows[end-1], fingerprint, false); err != nil {
+			return cursors, &[]T{}, err
+		}
+	}
+	if hasPrevious {
+		if cursors.Previous, err = r.cursor(ctx, rows[start], fingerprint, true); err != nil {
+			return cursors, &[]T{}, err
+		}
+	}
+	return cursors, &items, nil
+}
+
+func (r *Repository[T]) cursor(ctx context.Context, row reflect.Value, fingerprint string, backward bool) (string, error) {
+	id, err := json.Marshal(r.id(ctx, row))
+	if err != nil {
+		return "", err
+	}
+	return filter.EncodeCursor(cursorSecret, filter.Cursor{Sort: fingerprint, Values: []json.RawMessage{id}, Backward: backward})
+}
+
+func (r *Repository[T]) fingerprint(f *filter.DynamicFilter) string {
+	if f.Sort == nil {
+		return r.schema.Table
+	}
+	encoded, _ := json.Marshal(f.Sort)
+	return r.schema.Table + string(encoded)
+}
+
+// query returns the matching rows as of the time, if any, in sort order, the id breaking ties
+func (r *Repository[T]) query(ctx context.Context, f *filter.DynamicFilter, at *time.Time, deleted bool) ([]reflect.Value, error) {
+	generate := database.GenerateDynamicQuery[T]
+	if deleted {
+		generate = database.GenerateDeletedQuery[T]
+	}
+	if _, _, err := generate(r.store.dialect, f); err != nil {
+		return nil, err
+	}
+	if _, err := database.GenerateDynamicSort[T](r.store.dialect, f); err != nil {
+		return nil, err
+	}
+
+	m := &matcher{ctx: ctx, store: r.store, schema: r.schema}
+	match, err := m.compile(f, deleted)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := r.rowsAsOf(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	rows := []reflect.Value{}
+	ranks := map[int]float64{}
+	for _, row := range candidates {
+		if !match(row) {
+			continue
+		}
+		if f.Search != "" {
+			rank, ok := r.searchRank(ctx, row, f.Search, false)
+			if !ok {
+				continue
+			}
+			ranks[r.id(ctx, row)] = rank
+		}
+		rows = append(rows, row)
+	}
+
+	type key struct {
+		path fieldPath
+		desc bool
+	}
+	keys := []key{}
+	if f.Sort != nil {
+		for _, s := range *f.Sort {
+			if s.Sort != "asc" && s.Sort != "desc" {
+				continue
+			}
+			if path, ok := m.resolve(s.ColId); ok {
+				keys = append(keys, key{path: path, desc: s.Sort == "desc"})
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if f.Search != "" && len(keys) == 0 {
+			// by relevance unless sorted, like database.GenerateSearchOrder
+			if a, b := ranks[r.id(ctx, rows[i])], ranks[r.id(ctx, rows[j])]; a != b {
+				return a > b
+			}
+		}
+		for _, k := range keys {
+			a, okA := m.sortValue(k.path, rows[i])
+			b, okB := m.sortValue(k.path, rows[j])
+			if !okA || !okB {
+				continue
+			}
+			// nulls come last ascending and first descending, as in postgres
+			c, comparable := compare(a, b)
+			switch {
+			case a == nil && b == nil:
+				c = 0
+			case a == nil:
+				c = 1
+			case b == nil:
+				c = -1
+			case !comparable:
+				c = 0
+			}
+			if k.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return r.id(ctx, rows[i]) < r.id(ctx, rows[j])
+	})
+	return rows, nil
+}
+
+// project copies the selected columns, all for nil, and loads the relations
+func (r *Repository[T]) project(ctx context.Context, row reflect.Value, columns []string, preloads []database.PreloadEntity) T {
+	projected := reflect.New(row.Type()).Elem()
+	if columns == nil {
+		projected.Set(row)
+	} else {
+		for _, column := range columns {
+			field := r.schema.LookUpField(strings.TrimPrefix(column, r.schema.Table+"."))
+			if field == nil {
+				continue
+			}
+			value, _ := field.ValueOf(ctx, row)
+			_ = field.Set(ctx, projected, value)
+		}
+	}
+	for _, preload := range preloads {
+		r.load(ctx, projected, r.schema, strings.Split(preload.Entity, "."))
+	}
+	return projected.Interface().(T)
+}
+
+// load sets a relation of row, and the relations below it, from the store
+func (r *Repository[T]) load(ctx context.Context, row reflect.Value, sch *schema.Schema, path []string) {
+	relation := findRelation(sch, path[0])
+	if relation == nil {
+		return
+	}
+	related := r.store.related(ctx, relation, row)
+	for _, child := range related {
+		if len(path) > 1 {
+			r.load(ctx, child, relation.FieldSchema, path[1:])
+		}
+	}
+
+	target := row.FieldByIndex(relation.Field.StructField.Index)
+	switch target.Kind() {
+	case reflect.Slice:
+		items := reflect.MakeSlice(target.Type(), 0, len(related))
+		for _, child := range related {
+			items = reflect.Append(items, assignable(child, target.Type().Elem()))
+		}
+		target.Set(items)
+	default:
+		if len(related) > 0 {
+			target.Set(assignable(related[0], target.Type()))
+		}
+	}
+}
+
+// assignable returns value, or a pointer to it, as the type of the relation field
+func assignable(value reflect.Value, to reflect.Type) reflect.Value {
+	if to.Kind() == reflect.Pointer {
+		pointer := reflect.New(value.Type())
+		pointer.Elem().Set(value)
+		return pointer
+	}
+	return value
+}
+
+// stripped clears the relations of a row, they are stored in their own tables
+func (r *Repository[T]) stripped(row reflect.Value) reflect.Value {
+	stored := reflect.New(row.Type()).Elem()
+	stored.Set(row)
+	for _, relation := range r.schema.Relationships.Relations {
+		if relation.Schema != r.schema {
+			// gorm also lists relations of other models pointing back to this one
+			continue
+		}
+		target := stored.FieldByIndex(relation.Field.StructField.Index)
+		target.Set(reflect.Zero(target.Type()))
+	}
+	return stored
+}
+
+// liveAsOf returns the row as it was at the time, when it was not deleted then
+func (r *Repository[T]) liveAsOf(ctx context.Context, id int, at *time.Time) (reflect.Value, error) {
+	if at == nil {
+		return r.live(ctx, id)
+	}
+	rows, err := r.rowsAsOf(ctx, at)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	for _, row := range rows {
+		if r.id(ctx, row) == id && !isDeleted(ctx, r.schema, row) {
+			return row, nil
+		}
+	}
+	return reflect.Value{}, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+}
+
+func (r *Repository[T]) live(ctx context.Context, id int) (reflect.Value, error) {
+	row, ok := r.store.row(r.schema.Table, id)
+	if !ok || isDeleted(ctx, r.schema, row) {
+		return row, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+	}
+	return row, nil
+}
+
+// writable returns the live row when it still has the version ctx expects, if any
+func (r *Repository[T]) writable(ctx context.Context, id int) (reflect.Value, error) {
+	row, err := r.live(ctx, id)
+	if err != nil {
+		return row, err
+	}
+	if version, ok := concurrency.FromContext(ctx); ok {
+		if current, _ := toInt(valueOf(ctx, r.schema.LookUpField("version"), row)); current != version {
+			return row, &service_errors.ServiceError{EndUserMessage: service_errors.ConcurrencyConflict}
+		}
+	}
+	return row, nil
+}
+
+func (r *Repository[T]) find(ctx context.Context, match func(row reflect.Value) bool) (reflect.Value, bool) {
+	for _, row := range r.store.rows(r.schema.Table) {
+		if match(row) {
+			return row, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// unique enforces the natural key over the live rows other than id
+func (r *Repository[T]) unique(ctx context.Context, row reflect.Value, id int) error {
+	keyed, ok := any(*new(T)).(naturalKeyed)
+	if !ok || isDeleted(ctx, r.schema, row) {
+		return nil
+	}
+	key := r.schema.LookUpField(keyed.NaturalKey())
+	value := valueOf(ctx, key, row)
+	_, taken := r.find(ctx, func(other reflect.Value) bool {
+		return r.id(ctx, other) != id && !isDeleted(ctx, r.schema, other) && valueOf(ctx, key, other) == value
+	})
+	if taken {
+		return &service_errors.ServiceError{
+			EndUserMessage:   service_errors.DuplicateRecord,
+			TechnicalMessage: fmt.Sprintf("%s %s %v already exists", r.schema.Table, key.DBName, value),
+			Field:            key.Name,
+		}
+	}
+	return nil
+}
+
+// modified records who changed the row and when, and bumps its version
+func (r *Repository[T]) modified(ctx context.Context, row reflect.Value) {
+	r.set(ctx, row, "modified_at", sql.NullTime{Valid: true, Time: r.now()})
+	if userId, ok := identity.UserId(ctx); ok {
+		r.set(ctx, row, "modified_by", &sql.NullInt64{Int64: int64(userId), Valid: true})
+	}
+	r.bump(ctx, row)
+}
+
+func (r *Repository[T]) bump(ctx context.Context, row reflect.Value) {
+	if version := r.schema.LookUpField("version"); version != nil {
+		current, _ := toInt(valueOf(ctx, version, row))
+		_ = version.Set(ctx, row, current+1)
+	}
+}
+
+func (r *Repository[T]) set(ctx context.Context, row reflect.Value, column string, value interface{}) {
+	field := r.schema.LookUpField(column)
+	if field == nil {
+		return
+	}
+	if value == nil {
+		field.ReflectValueOf(ctx, row).Set(reflect.Zero(field.FieldType))
+		return
+	}
+	_ = field.Set(ctx, row, value)
+}
+
+func (r *Repository[T]) id(ctx context.Context, row reflect.Value) int {
+	id, _ := toInt(valueOf(ctx, r.schema.PrioritizedPrimaryField, row))
+	return id
+}
+
+func (r *Repository[T]) now() time.Time {
+	return r.store.clock.Now().UTC()
+}
+
+// snapshot reads the columns of a row like the database returns them
+func (r *Repository[T]) snapshot(ctx context.Context, row reflect.Value) map[string]interface{} {
+	columns := map[string]interface{}{}
+	for _, field := range r.schema.Fields {
+		if field.DBName != "" {
+			columns[field.DBName] = valueOf(ctx, field, row)
+		}
+	}
+	return columns
+}
+
+// redact masks the secret columns of a snapshot like the database repository
+func (r *Repository[T]) redact(row map[string]interface{}) map[string]interface{} {
+	secrets, ok := any(new(T)).(interface{ SecretFields() []string })
+	if !ok || row == nil {
+		return row
+	}
+	redacted := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		redacted[column] = value
+	}
+	for _, name := range secrets.SecretFields() {
+		if field := r.schema.LookUpField(name); field != nil {
+			if _, ok := redacted[field.DBName]; ok {
+				redacted[field.DBName] = "[redacted]"
+			}
+		}
+	}
+	return redacted
+}
+
+// audit records a write the way the database repository does
+func (r *Repository[T]) audit(ctx context.Context, action string, id int, before map[string]interface{}, after map[string]interface{}) {
+	if r.unaudited {
+		return
+	}
+	before, after = r.redact(before), r.redact(after)
+	columns := []string{}
+	for column := range before {
+		columns = append(columns, column)
+	}
+	for column := range after {
+		if _, ok := before[column]; !ok {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+
+	changes := []model.FieldChange{}
+	for _, column := range columns {
+		if auditIgnored[column] {
+			continue
+		}
+		oldValue, newValue := before[column], after[column]
+		encodedOld, _ := json.Marshal(oldValue)
+		encodedNew, _ := json.Marshal(newValue)
+		if string(encodedOld) == string(encodedNew) {
+			continue
+		}
+		name := column
+		if field := r.schema.LookUpField(column); field != nil {
+			name = field.Name
+		}
+		changes = append(changes, model.FieldChange{Field: name, Old: oldValue, New: newValue})
+	}
+	encoded, _ := json.Marshal(changes)
+
+	entry := model.AuditLog{
+		EntityType: r.schema.Table,
+		EntityId:   id,
+		Action:     action,
+		UserId:     -1,
+		RequestId:  identity.RequestId(ctx),
+		CreatedAt:  r.now(),
+		Changes:    string(encoded),
+	}
+	if user, ok := identity.FromContext(ctx); ok && user.IsAuthenticated() {
+		entry.UserId = user.UserId
+		entry.Username = user.Username
+	}
+	sch, err := r.store.parse(&entry)
+	if err != nil {
+		panic(err)
+	}
+	r.store.put(ctx, sch, reflect.ValueOf(&entry).Elem())
+	r.record(ctx, &entry, before, after)
+}
@@ -0,0 +1,210 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/infra/persistence/database"
+
+	"gorm.io/gorm/schema"
+)
+
+// similarityThreshold is the default pg_trgm threshold of the % operator
+const similarityThreshold = 0.3
+
+// searcher is a repository global search looks in
+type searcher interface {
+	hits(ctx context.Context, text string, suggest bool) ([]filter.SearchHit, error)
+}
+
+// SearchType is an entity type of global search and the repository of its rows
+type SearchType struct {
+	Name       string
+	Repository searcher
+}
+
+// SearchRepository is an in-memory repository.SearchRepository. It approximates
+// postgres: a row matches when its search fields hold every word of the text or
+// one of them is as similar to the text as pg_trgm requires.
+type SearchRepository struct {
+	types []SearchType
+}
+
+func NewSearchRepository(types ...SearchType) *SearchRepository {
+	return &SearchRepository{types: types}
+}
+
+func (r *SearchRepository) Search(ctx context.Context, input filter.SearchInput) ([]filter.SearchHit, error) {
+	return r.find(ctx, input, false)
+}
+
+func (r *SearchRepository) Suggest(ctx context.Context, input filter.SearchInput) ([]filter.SearchHit, error) {
+	return r.find(ctx, input, true)
+}
+
+func (r *SearchRepository) find(ctx context.Context, input filter.SearchInput, suggest bool) ([]filter.SearchHit, error) {
+	types := r.types
+	if len(input.Types) > 0 {
+		types = []SearchType{}
+		for _, name := range input.Types {
+			found := false
+			for _, t := range r.types {
+				if strings.EqualFold(t.Name, name) {
+					types = append(types, t)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, filter.Invalid("types", "unknown search type %s", name)
+			}
+		}
+	}
+
+	limit := input.GetLimit()
+	hits := []filter.SearchHit{}
+	for _, t := range types {
+		found, err := t.Repository.hits(ctx, input.Query, suggest)
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			found[i].Type = t.Name
+		}
+		hits = append(hits, found[:min(len(found), limit)]...)
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+	return hits[:min(len(hits), limit)], nil
+}
+
+// hits ranks the live rows of the repository against the text, best first
+func (r *Repository[T]) hits(ctx context.Context, text string, suggest bool) ([]filter.SearchHit, error) {
+	generate := database.GenerateSearch[T]
+	if suggest {
+		generate = database.GenerateSuggest[T]
+	}
+	if _, err := generate(r.store.dialect, text); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	title := r.searchColumns()[0]
+	hits := []filter.SearchHit{}
+	for _, row := range r.store.rows(r.schema.Table) {
+		if isDeleted(ctx, r.schema, row) {
+			continue
+		}
+		rank, ok := r.searchRank(ctx, row, text, suggest)
+		if !ok {
+			continue
+		}
+		name := fmt.Sprint(valueOf(ctx, title, row))
+		hit := filter.SearchHit{Id: r.id(ctx, row), Name: name, Rank: rank}
+		if !suggest {
+			hit.Highlight = database.HighlightText(name, text)
+		}
+		hits = append(hits, hit)
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		return hits[i].Name < hits[j].Name
+	})
+	return hits, nil
+}
+
+// searchRank tells whether the row matches the text and how well
+func (r *Repository[T]) searchRank(ctx context.Context, row reflect.Value, text string, suggest bool) (float64, bool) {
+	words := database.SearchWords(text)
+	values := []string{}
+	for _, field := range r.searchColumns() {
+		if value := valueOf(ctx, field, row); value != nil {
+			values = append(values, strings.ToLower(fmt.Sprint(value)))
+		}
+	}
+	if len(values) == 0 {
+		return 0, false
+	}
+	document := database.SearchWords(strings.Join(values, " "))
+
+	if suggest {
+		phrase := strings.Join(words, " ")
+		matches := strings.HasPrefix(values[0], phrase)
+		if !matches {
+			matches = allWords(words, document, strings.HasPrefix)
+		}
+		return similarity(values[0], phrase), matches
+	}
+
+	rank := 0.0
+	matches := allWords(words, document, func(token string, word string) bool { return token == word })
+	if matches {
+		rank = 0.1
+	}
+	best := 0.0
+	for _, value := range values {
+		best = max(best, similarity(value, text))
+	}
+	return rank + best, matches || best >= similarityThreshold
+}
+
+// searchColumns are the fields of SearchFields, the title first
+func (r *Repository[T]) searchColumns() []*schema.Field {
+	fields := []*schema.Field{}
+	if searchable, ok := any(*new(T)).(interface{ SearchFields() []string }); ok {
+		for _, name := range searchable.SearchFields() {
+			fields = append(fields, findColumn(r.schema, name))
+		}
+	}
+	return fields
+}
+
+// allWords tells whether every word matches a token of the document
+func allWords(words []string, document []string, match func(token string, word string) bool) bool {
+	for _, word := range words {
+		found := false
+		for _, token := range document {
+			if match(token, word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// similarity is the pg_trgm similarity: shared trigrams over all trigrams of the words
+func similarity(a string, b string) float64 {
+	x, y := trigrams(a), trigrams(b)
+	if len(x) == 0 || len(y) == 0 {
+		return 0
+	}
+	shared := 0
+	for trigram := range x {
+		if y[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(x)+len(y)-shared)
+}
+
+// trigrams pads every word with two spaces before and one after, like pg_trgm
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range database.SearchWords(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,92 @@
+package testkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang-clean-web-api/api"
+	"golang-clean-web-api/api/helper"
+	"golang-clean-web-api/api/middleware"
+	"golang-clean-web-api/constant"
+	"golang-clean-web-api/dependency"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server is the router of the api, with the handlers built from a kit
+type Server struct {
+	Engine *gin.Engine
+}
+
+// Server registers the routes against the fakes of the kit. The middleware that
+// needs redis, rate limiting, is left out.
+func (k *Kit) Server() *Server {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.RequestId())
+	r.Use(middleware.DbSession())
+
+	restore := dependency.Use(k)
+	defer restore()
+	api.RegisterRoutes(r, k.Config)
+	return &Server{Engine: r}
+}
+
+// Do sends the request, body encoded as json unless it is nil, with the token as
+// bearer unless it is empty
+func (s *Server) Do(t testing.TB, method string, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set(constant.AuthorizationHeaderKey, "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.Engine.ServeHTTP(w, req)
+	return w
+}
+
+// Decode reads the response envelope, decoding its result into result when it is
+// not nil
+func Decode(t testing.TB, w *httptest.ResponseRecorder, result interface{}) helper.BaseHttpResponse {
+	t.Helper()
+	var response helper.BaseHttpResponse
+	var raw struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode %d response %q: %v", w.Code, w.Body.String(), err)
+	}
+	if result != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(raw.Result, result); err != nil {
+			t.Fatalf("decode result %s: %v", raw.Result, err)
+		}
+	}
+	return response
+}
+
+// StatusOf fails the test unless the response has the status
+func StatusOf(t testing.TB, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("expected status %d (%s), got %d: %s", status, http.StatusText(status), w.Code, w.Body.String())
+	}
+}
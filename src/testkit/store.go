@@ -0,0 +1,213 @@
+// Package testkit runs usecases and handlers without a database or redis: an
+// in-memory store behind fakes of the repositories, a cache, a clock that only
+// moves when told to, tokens issued at that time, fixtures and an http server
+// over the real routes.
+package testkit
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"sort"
+	"sync"
+
+	"golang-clean-web-api/pkg/clock"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Store is the in-memory database the fake repositories of a test share. It keeps
+// a copy of every row by table, relations are resolved across tables on read.
+// It is also the transaction manager: Do undoes every write of a failed function.
+type Store struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	tables map[string]*table
+	// dialect parses models and builds queries the way the database repositories
+	// do, so the fakes answer invalid filters and projections with the same errors.
+	// It never connects.
+	dialect *gorm.DB
+}
+
+type table struct {
+	rows   map[int]interface{}
+	nextId int
+}
+
+func NewStore(c clock.Clock) *Store {
+	dialect, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}),
+		&gorm.Config{DisableAutomaticPing: true, DryRun: true})
+	if err != nil {
+		panic(err)
+	}
+	return &Store{clock: c, tables: map[string]*table{}, dialect: dialect}
+}
+
+func (s *Store) parse(model interface{}) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: s.dialect}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+func (s *Store) table(name string) *table {
+	t, ok := s.tables[name]
+	if !ok {
+		t = &table{rows: map[int]interface{}{}}
+		s.tables[name] = t
+	}
+	return t
+}
+
+// rows returns addressable copies of the rows of a table in id order
+func (s *Store) rows(name string) []reflect.Value {
+	t := s.table(name)
+	ids := make([]int, 0, len(t.rows))
+	for id := range t.rows {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	rows := make([]reflect.Value, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, copyRow(t.rows[id]))
+	}
+	return rows
+}
+
+func (s *Store) row(name string, id int) (reflect.Value, bool) {
+	row, ok := s.table(name).rows[id]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	return copyRow(row), true
+}
+
+// put stores a copy of row, giving it the next id when it has none
+func (s *Store) put(ctx context.Context, sch *schema.Schema, row reflect.Value) int {
+	t := s.table(sch.Table)
+	primary := sch.PrioritizedPrimaryField
+	id, _ := toInt(valueOf(ctx, primary, row))
+	if id == 0 {
+		t.nextId++
+		id = t.nextId
+		_ = primary.Set(ctx, row, id)
+	} else if id > t.nextId {
+		t.nextId = id
+	}
+	t.rows[id] = copyRow(row.Interface()).Interface()
+	return id
+}
+
+func (s *Store) remove(name string, id int) {
+	delete(s.table(name).rows, id)
+}
+
+// Do runs fn as a transaction: when it returns an error or panics the store goes
+// back to how it was before. Writes made meanwhile by other goroutines are undone too.
+func (s *Store) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	s.mu.Lock()
+	saved := s.save()
+	s.mu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.mu.Lock()
+			s.load(saved)
+			s.mu.Unlock()
+			panic(r)
+		}
+		if err != nil {
+			s.mu.Lock()
+			s.load(saved)
+			s.mu.Unlock()
+		}
+	}()
+	return fn(ctx)
+}
+
+// atomic is Do for callers that hold the lock
+func (s *Store) atomic(fn func() error) error {
+	saved := s.save()
+	if err := fn(); err != nil {
+		s.load(saved)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) save() map[string]table {
+	saved := make(map[string]table, len(s.tables))
+	for name, t := range s.tables {
+		rows := make(map[int]interface{}, len(t.rows))
+		for id, row := range t.rows {
+			rows[id] = row
+		}
+		saved[name] = table{rows: rows, nextId: t.nextId}
+	}
+	return saved
+}
+
+func (s *Store) load(saved map[string]table) {
+	s.tables = make(map[string]*table, len(saved))
+	for name, t := range saved {
+		t := t
+		s.tables[name] = &t
+	}
+}
+
+// copyRow returns an addressable copy of a stored struct
+func copyRow(row interface{}) reflect.Value {
+	value := reflect.ValueOf(row)
+	copied := reflect.New(value.Type()).Elem()
+	copied.Set(value)
+	return copied
+}
+
+// valueOf reads a field the way the database would return it: nil for null and
+// int64, float64, bool, string or time.Time otherwise
+func valueOf(ctx context.Context, field *schema.Field, row reflect.Value) interface{} {
+	value, _ := field.ValueOf(ctx, row)
+	return normalize(value)
+}
+
+func normalize(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		value = v.Elem().Interface()
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		converted, err := valuer.Value()
+		if err != nil {
+			return nil
+		}
+		value = converted
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	}
+	return value
+}
+
+func toInt(value interface{}) (int, bool) {
+	v, ok := normalize(value).(int64)
+	return int(v), ok
+}
@@ -0,0 +1,740 @@
+package testkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-clean-web-api/api/dto"
+	"golang-clean-web-api/constant"
+	"golang-clean-web-api/domain/event"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/infra/cache"
+	"golang-clean-web-api/pkg/concurrency"
+	"golang-clean-web-api/pkg/identity"
+	"golang-clean-web-api/pkg/service_errors"
+	"golang-clean-web-api/pkg/webhook"
+	"golang-clean-web-api/usecase"
+	usecaseDto "golang-clean-web-api/usecase/dto"
+)
+
+func userContext() context.Context {
+	return identity.NewContext(context.Background(), &identity.Identity{UserId: 1, Username: "admin"})
+}
+
+func isServiceError(err error, message string) bool {
+	var serviceError *service_errors.ServiceError
+	return errors.As(err, &serviceError) && serviceError.EndUserMessage == message
+}
+
+func TestRepository_SoftDeleteAndRestore(t *testing.T) {
+	k := New(t)
+	ctx := userContext()
+	country := k.Country(t, "Iran")
+
+	if err := k.Countries.Delete(ctx, country.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Countries.GetById(ctx, country.Id); !isServiceError(err, service_errors.RecordNotFound) {
+		t.Fatalf("expected not found after delete, got %v", err)
+	}
+	total, deleted, err := k.Countries.GetDeleted(ctx, filter.PaginationInputWithFilter{})
+	if err != nil || total != 1 || (*deleted)[0].Id != country.Id {
+		t.Fatalf("expected the country in the trash, got %d %v", total, err)
+	}
+
+	if err := k.Countries.Restore(ctx, country.Id); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := k.Countries.GetById(ctx, country.Id)
+	if err != nil || restored.Version != 3 {
+		t.Fatalf("expected the restored country at version 3, got %+v %v", restored, err)
+	}
+	total, history, err := k.Countries.GetHistory(ctx, country.Id, filter.PaginationInputWithFilter{})
+	if err != nil || total != 3 {
+		t.Fatalf("expected create, delete and restore in the history, got %d %v", total, err)
+	}
+	if (*history)[2].Action != model.AuditRestore {
+		t.Fatalf("expected the restore last, got %s", (*history)[2].Action)
+	}
+}
+
+func TestRepository_Concurrency(t *testing.T) {
+	k := New(t)
+	color := k.Color(t, "Red", "#ff0000")
+
+	stale := concurrency.NewContext(userContext(), color.Version+1)
+	if _, err := k.Colors.Update(stale, color.Id, map[string]interface{}{"Name": "Blue"}); !isServiceError(err, service_errors.ConcurrencyConflict) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	current := concurrency.NewContext(userContext(), color.Version)
+	updated, err := k.Colors.Update(current, color.Id, map[string]interface{}{"Name": "Blue"})
+	if err != nil || updated.Name != "Blue" || updated.Version != color.Version+1 {
+		t.Fatalf("expected the update to bump the version, got %+v %v", updated, err)
+	}
+}
+
+func TestRepository_NaturalKey(t *testing.T) {
+	k := New(t)
+	ctx := userContext()
+	country := k.Country(t, "Iran")
+
+	if _, err := k.Countries.Create(ctx, model.Country{Name: "Iran"}); !isServiceError(err, service_errors.DuplicateRecord) {
+		t.Fatalf("expected a duplicate, got %v", err)
+	}
+	if err := k.Countries.Delete(ctx, country.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Countries.Create(ctx, model.Country{Name: "Iran"}); err != nil {
+		t.Fatalf("expected the name of a deleted country to be free, got %v", err)
+	}
+}
+
+func TestRepository_Filter(t *testing.T) {
+	k := New(t)
+	ctx := userContext()
+	iran := k.Country(t, "Iran")
+	germany := k.Country(t, "Germany")
+	k.City(t, "Tehran", iran)
+	k.City(t, "Shiraz", iran)
+	k.City(t, "Berlin", germany)
+
+	req := filter.PaginationInputWithFilter{
+		PaginationInput: filter.PaginationInput{PageSize: 1, PageNumber: 2},
+		DynamicFilter: filter.DynamicFilter{
+			Filter: map[string]filter.Filter{"Country.Name": {Type: "equals", From: "Iran", FilterType: "text"}},
+			Sort:   &[]filter.Sort{{ColId: "Name", Sort: "asc"}},
+		},
+	}
+	total, cities, err := k.Cities.GetByFilter(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(*cities) != 1 || (*cities)[0].Name != "Tehran" {
+		t.Fatalf("expected Tehran on the second page of two, got %d %+v", total, *cities)
+	}
+	if (*cities)[0].Country.Name != "Iran" {
+		t.Fatalf("expected the country preloaded, got %+v", (*cities)[0].Country)
+	}
+
+	req.Where = &filter.Expression{Field: "Population", Filter: filter.Filter{Type: "equals", From: "1"}}
+	if _, _, err := k.Cities.GetByFilter(ctx, req); !isServiceError(err, service_errors.InvalidFilter) {
+		t.Fatalf("expected an invalid filter, got %v", err)
+	}
+}
+
+func TestStore_RollsBackFailedTransaction(t *testing.T) {
+	k := New(t)
+	ctx := userContext()
+	failed := errors.New("failed")
+
+	err := k.Store.Do(ctx, func(ctx context.Context) error {
+		if _, err := k.Colors.Create(ctx, model.Color{Name: "Red", HexCode: "#ff0000"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of the function, got %v", err)
+	}
+	total, _, err := k.Colors.GetByFilter(ctx, filter.PaginationInputWithFilter{})
+	if err != nil || total != 0 {
+		t.Fatalf("expected no color after the rollback, got %d %v", total, err)
+	}
+}
+
+func TestCache_ExpiresByClock(t *testing.T) {
+	k := New(t)
+	if err := cache.Set(k.Memory, "key", "value", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := cache.Get[string](k.Memory, "key"); err != nil || value != "value" {
+		t.Fatalf("expected the value, got %q %v", value, err)
+	}
+	k.Clock.Advance(time.Minute)
+	if _, err := cache.Get[string](k.Memory, "key"); err == nil {
+		t.Fatal("expected the value to expire")
+	}
+}
+
+func TestServer_AuthAndCrud(t *testing.T) {
+	k := New(t)
+	server := k.Server()
+
+	w := server.Do(t, http.MethodPost, "/api/v1/auth/register",
+		dto.RegisterRequest{Username: "alice", Password: "secret1", Email: "alice@example.com"}, "")
+	StatusOf(t, w, http.StatusCreated)
+	w = server.Do(t, http.MethodPost, "/api/v1/auth/login", dto.LoginRequest{Username: "alice", Password: "secret1"}, "")
+	StatusOf(t, w, http.StatusOK)
+	var tokens dto.TokenResponse
+	Decode(t, w, &tokens)
+
+	w = server.Do(t, http.MethodPost, "/api/v1/countries/", dto.CreateUpdateCountryRequest{Name: "Iran"}, "")
+	StatusOf(t, w, http.StatusUnauthorized)
+	w = server.Do(t, http.MethodPost, "/api/v1/countries/", dto.CreateUpdateCountryRequest{Name: "Iran"}, tokens.AccessToken)
+	StatusOf(t, w, http.StatusCreated)
+	var created dto.CountryResponse
+	Decode(t, w, &created)
+
+	w = server.Do(t, http.MethodGet, "/api/v1/countries/"+strconv.Itoa(created.Id), nil, k.Token(t, k.User(t, "bob")))
+	StatusOf(t, w, http.StatusOK)
+
+	k.Clock.Advance(24 * time.Hour)
+	w = server.Do(t, http.MethodGet, "/api/v1/countries/"+strconv.Itoa(created.Id), nil, tokens.AccessToken)
+	StatusOf(t, w, http.StatusUnauthorized)
+}
+
+func TestServer_RefreshReloadsUser(t *testing.T) {
+	k := New(t)
+	server := k.Server()
+	ctx := context.Background()
+	alice := k.User(t, "alice", "admin")
+	refresh, err := k.Tokens.GenerateRefreshToken(uint(alice.Id), alice.Username, alice.RoleList()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := k.Users.Update(ctx, alice.Id, map[string]interface{}{"roles": "default"}); err != nil {
+		t.Fatal(err)
+	}
+	w := server.Do(t, http.MethodPost, "/api/v1/auth/refresh", dto.RefreshTokenRequest{RefreshToken: refresh}, "")
+	StatusOf(t, w, http.StatusOK)
+	var tokens dto.TokenResponse
+	Decode(t, w, &tokens)
+	claims, err := k.Tokens.ValidateToken(tokens.AccessToken)
+	if err != nil || len(claims.Roles) != 1 || claims.Roles[0] != "default" {
+		t.Fatalf("expected the refreshed token to carry the current roles, got %+v %v", claims, err)
+	}
+
+	if _, err := k.Users.Update(ctx, alice.Id, map[string]interface{}{"is_active": false}); err != nil {
+		t.Fatal(err)
+	}
+	w = server.Do(t, http.MethodPost, "/api/v1/auth/refresh", dto.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, "")
+	StatusOf(t, w, http.StatusUnauthorized)
+}
+
+func TestRepository_Cursor(t *testing.T) {
+	k := New(t)
+	ctx := userContext()
+	for _, name := range []string{"Red", "Green", "Blue"} {
+		k.Color(t, name, "#000000")
+	}
+
+	req := filter.PaginationInputWithFilter{
+		PaginationInput: filter.PaginationInput{PageSize: 2, CursorMode: true},
+		DynamicFilter:   filter.DynamicFilter{Sort: &[]filter.Sort{{ColId: "Name", Sort: "asc"}}},
+	}
+	cursors, colors, err := k.Colors.GetByCursor(ctx, req)
+	if err != nil || len(*colors) != 2 || (*colors)[0].Name != "Blue" || cursors.Next == "" {
+		t.Fatalf("expected Blue and Green with a next cursor, got %+v %v", colors, err)
+	}
+	req.Cursor = cursors.Next
+	cursors, colors, err = k.Colors.GetByCursor(ctx, req)
+	if err != nil || len(*colors) != 1 || (*colors)[0].Name != "Red" || cursors.Next != "" || cursors.Previous == "" {
+		t.Fatalf("expected Red on the last page, got %+v %v", colors, err)
+	}
+}
+
+func TestServer_Search(t *testing.T) {
+	k := New(t)
+	iran := k.Country(t, "Iran")
+	k.City(t, "Tehran", iran)
+	k.City(t, "Shiraz", iran)
+	token := k.Token(t, k.User(t, "alice"))
+	server := k.Server()
+
+	w := server.Do(t, http.MethodGet, "/api/v1/search?q=tehrn", nil, token)
+	StatusOf(t, w, http.StatusOK)
+	var hits []dto.SearchHitResponse
+	Decode(t, w, &hits)
+	if len(hits) != 1 || hits[0].Type != "city" || hits[0].Name != "Tehran" {
+		t.Fatalf("expected the misspelled city, got %+v", hits)
+	}
+
+	w = server.Do(t, http.MethodGet, "/api/v1/search/suggest?q=sh&type=city", nil, token)
+	StatusOf(t, w, http.StatusOK)
+	var suggestions []dto.SuggestionResponse
+	Decode(t, w, &suggestions)
+	if len(suggestions) != 1 || suggestions[0].Name != "Shiraz" {
+		t.Fatalf("expected Shiraz, got %+v", suggestions)
+	}
+
+	w = server.Do(t, http.MethodGet, "/api/v1/cities?search=shiraz", nil, token)
+	StatusOf(t, w, http.StatusOK)
+	w = server.Do(t, http.MethodGet, "/api/v1/search/suggest?q=sh", nil, token)
+	StatusOf(t, w, http.StatusBadRequest)
+}
+
+func TestServer_Aggregate(t *testing.T) {
+	k := New(t)
+	iran, germany := k.Country(t, "Iran"), k.Country(t, "Germany")
+	k.City(t, "Tehran", iran)
+	k.City(t, "Shiraz", iran)
+	k.City(t, "Berlin", germany)
+	token := k.Token(t, k.User(t, "alice"))
+	server := k.Server()
+
+	body := filter.AggregateInput{
+		GroupBy:       []string{"Country.Name"},
+		Aggregates:    []filter.Aggregate{{Function: "count"}, {Function: "min", Field: "Id"}},
+		DynamicFilter: filter.DynamicFilter{Sort: &[]filter.Sort{{ColId: "count", Sort: "desc"}}},
+	}
+	w := server.Do(t, http.MethodPost, "/api/v1/cities/aggregate", body, token)
+	StatusOf(t, w, http.StatusOK)
+	var rows []dto.AggregateRowResponse
+	Decode(t, w, &rows)
+	if len(rows) != 2 || rows[0].Group["Country.Name"] != "Iran" || rows[0].Values["count"] != 2.0 || rows[1].Values["minId"] != 3.0 {
+		t.Fatalf("expected two cities in Iran and one in Germany, got %+v", rows)
+	}
+
+	body.Aggregates = []filter.Aggregate{{Function: "avg", Field: "Name"}}
+	w = server.Do(t, http.MethodPost, "/api/v1/cities/aggregate", body, token)
+	StatusOf(t, w, http.StatusBadRequest)
+}
+
+func TestServer_Export(t *testing.T) {
+	k := New(t)
+	k.Config.Export.BatchSize = 2
+	iran, germany := k.Country(t, "Iran"), k.Country(t, "Germany")
+	k.City(t, "Tehran", iran)
+	k.City(t, "Shiraz", iran)
+	k.City(t, "Berlin", germany)
+	token := k.Token(t, k.User(t, "alice"))
+	server := k.Server()
+
+	w := server.Do(t, http.MethodGet, "/api/v1/cities/export?sort=name&columns=id,name,country.name&lang=fa", nil, token)
+	StatusOf(t, w, http.StatusOK)
+	expected := "شناسه,نام,نام کشور\n3,Berlin,Germany\n2,Shiraz,Iran\n1,Tehran,Iran\n"
+	if w.Body.String() != expected || w.Header().Get("Content-Disposition") != `attachment; filename="cities.csv"` {
+		t.Fatalf("expected the cities in two batches by name, got %q %v", w.Body.String(), w.Header())
+	}
+
+	w = server.Do(t, http.MethodGet, "/api/v1/cities/export?format=ndjson&filter=name:equals:Tehran", nil, token)
+	StatusOf(t, w, http.StatusOK)
+	if w.Body.String() != `{"country":{"id":1,"name":"Iran"},"id":1,"name":"Tehran"}`+"\n" {
+		t.Fatalf("expected Tehran in the shape of the json response, got %q", w.Body.String())
+	}
+
+	w = server.Do(t, http.MethodGet, "/api/v1/colors/export?format=xlsx", nil, token)
+	StatusOf(t, w, http.StatusOK)
+	if !strings.HasPrefix(w.Body.String(), "PK") || !strings.Contains(w.Header().Get("Content-Type"), "spreadsheetml") {
+		t.Fatalf("expected an xlsx document, got %v", w.Header())
+	}
+
+	for _, query := range []string{"format=pdf", "columns=population", "sort=country.name"} {
+		w = server.Do(t, http.MethodGet, "/api/v1/cities/export?"+query, nil, token)
+		StatusOf(t, w, http.StatusBadRequest)
+		if w.Header().Get("Content-Disposition") != "" {
+			t.Fatalf("expected no download for %s, got %v", query, w.Header())
+		}
+	}
+}
+
+func TestServer_AsOf(t *testing.T) {
+	k := New(t)
+	token := k.Token(t, k.User(t, "alice"))
+	server := k.Server()
+	ctx := userContext()
+	// moments between the writes
+	moment := func() string {
+		k.Clock.Advance(time.Minute)
+		at := k.Clock.Now().UTC().Format(time.RFC3339)
+		k.Clock.Advance(time.Minute)
+		return at
+	}
+
+	beforeCreate := moment()
+	country := k.Country(t, "Iran")
+	created := moment()
+	if _, err := k.Countries.Update(ctx, country.Id, map[string]interface{}{"name": "Persia"}); err != nil {
+		t.Fatal(err)
+	}
+	updated := moment()
+	if err := k.Countries.Delete(ctx, country.Id); err != nil {
+		t.Fatal(err)
+	}
+	deleted := moment()
+
+	path := "/api/v1/countries/" + strconv.Itoa(country.Id)
+	for at, name := range map[string]string{created: "Iran", updated: "Persia"} {
+		w := server.Do(t, http.MethodGet, path+"?asOf="+at, nil, token)
+		StatusOf(t, w, http.StatusOK)
+		var found dto.CountryResponse
+		Decode(t, w, &found)
+		if found.Name != name {
+			t.Fatalf("expected %s as of %s, got %+v", name, at, found)
+		}
+
+		w = server.Do(t, http.MethodGet, "/api/v1/countries?asOf="+at, nil, token)
+		StatusOf(t, w, http.StatusOK)
+		var page filter.PagedList[dto.CountryResponse]
+		Decode(t, w, &page)
+		if page.TotalRows != 1 || (*page.Items)[0].Name != name {
+			t.Fatalf("expected only %s in the list as of %s, got %+v", name, at, page)
+		}
+	}
+	for _, at := range []string{beforeCreate, deleted} {
+		w := server.Do(t, http.MethodGet, path+"?asOf="+at, nil, token)
+		StatusOf(t, w, http.StatusNotFound)
+	}
+
+	w := server.Do(t, http.MethodPost, "/api/v1/countries/get-by-filter", map[string]interface{}{"asOf": updated}, token)
+	StatusOf(t, w, http.StatusOK)
+	var page filter.PagedList[dto.CountryResponse]
+	Decode(t, w, &page)
+	if page.TotalRows != 1 || (*page.Items)[0].Name != "Persia" {
+		t.Fatalf("expected Persia in the filtered list, got %+v", page)
+	}
+
+	w = server.Do(t, http.MethodGet, path+"?asOf=yesterday", nil, token)
+	StatusOf(t, w, http.StatusBadRequest)
+}
+
+func TestServer_AsOfRelations(t *testing.T) {
+	k := New(t)
+	token := k.Token(t, k.User(t, "alice"))
+	server := k.Server()
+	tehran := k.City(t, "Tehran", k.Country(t, "Iran"))
+	k.Clock.Advance(time.Minute)
+	at := k.Clock.Now().UTC().Format(time.RFC3339)
+	path := "/api/v1/cities/" + strconv.Itoa(tehran.Id)
+
+	// the country would be read as it is now, so it is left out
+	w := server.Do(t, http.MethodGet, path+"?asOf="+at, nil, token)
+	StatusOf(t, w, http.StatusOK)
+	var found dto.CityResponse
+	Decode(t, w, &found)
+	if found.Name != "Tehran" || found.Country != nil {
+		t.Fatalf("expected Tehran without its country, got %+v", found)
+	}
+
+	for _, query := range []string{
+		path + "?include=country&asOf=" + at,
+		"/api/v1/cities?include=country&asOf=" + at,
+		"/api/v1/cities?filter=country.name:equals:Iran&asOf=" + at,
+		"/api/v1/cities?sort=country.name&asOf=" + at,
+	} {
+		w := server.Do(t, http.MethodGet, query, nil, token)
+		StatusOf(t, w, http.StatusBadRequest)
+	}
+	w = server.Do(t, http.MethodPost, "/api/v1/cities/get-by-filter", map[string]interface{}{"asOf": at,
+		"where": map[string]interface{}{"not": map[string]interface{}{"field": "Country.Name", "type": "equals", "from": "Iran"}}}, token)
+	StatusOf(t, w, http.StatusBadRequest)
+}
+
+func TestServer_HistoryIsAdminOnly(t *testing.T) {
+	k := New(t)
+	iran := k.Country(t, "Iran")
+	user := k.Token(t, k.User(t, "alice"))
+	admin := k.Token(t, k.User(t, "root", constant.AdminRoleName))
+	server := k.Server()
+	path := "/api/v1/countries/" + strconv.Itoa(iran.Id) + "/history"
+
+	w := server.Do(t, http.MethodGet, path, nil, user)
+	StatusOf(t, w, http.StatusForbidden)
+	w = server.Do(t, http.MethodGet, path, nil, admin)
+	StatusOf(t, w, http.StatusOK)
+}
+
+func TestServer_InvalidFilterReason(t *testing.T) {
+	k := New(t)
+	token := k.Token(t, k.User(t, "alice"))
+	server := k.Server()
+
+	for query, property := range map[string]string{
+		"/api/v1/cities?filter=population:equals:1": "filter",
+		"/api/v1/cities?include=mayor":              "include",
+	} {
+		w := server.Do(t, http.MethodGet, query, nil, token)
+		StatusOf(t, w, http.StatusBadRequest)
+		response := Decode(t, w, nil)
+		if response.ValidationErrors == nil || (*response.ValidationErrors)[0].Property != property ||
+			(*response.ValidationErrors)[0].Message == "" {
+			t.Errorf("expected %s to say why %s was rejected, got %+v", query, property, response.ValidationErrors)
+		}
+	}
+}
+
+func TestServer_Import(t *testing.T) {
+	k := New(t)
+	iran := k.Country(t, "Iran")
+	token := k.Token(t, k.User(t, "alice"))
+	server := k.Server()
+	upload := func(path string, contentType string, body string) (*httptest.ResponseRecorder, dto.ImportResponse[dto.ColorResponse]) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set(constant.AuthorizationHeaderKey, "Bearer "+token)
+		w := httptest.NewRecorder()
+		server.Engine.ServeHTTP(w, req)
+		var report dto.ImportResponse[dto.ColorResponse]
+		if w.Code == http.StatusOK || w.Code == http.StatusMultiStatus {
+			Decode(t, w, &report)
+		}
+		return w, report
+	}
+	colors := func() int64 {
+		count, _, err := k.Colors.GetByFilter(userContext(), filter.PaginationInputWithFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	csv := "name,hexCode\nRed,#ff0000\nBlue,#0000ff\nGreen,#00ff001\n"
+	w, report := upload("/api/v1/colors/import?dryRun=true", "text/csv", csv)
+	StatusOf(t, w, http.StatusMultiStatus)
+	if !report.DryRun || report.Created != 2 || report.Failed != 1 || report.Rows[2].Outcome != usecaseDto.ImportFailed ||
+		(*report.Rows[2].ValidationErrors)[0].Property != "HexCode" || colors() != 0 {
+		t.Fatalf("expected a report of two creates and an invalid hex code and nothing kept, got %+v", report)
+	}
+
+	w, report = upload("/api/v1/colors/import", "text/csv", csv)
+	StatusOf(t, w, http.StatusMultiStatus)
+	if report.Created != 2 || colors() != 2 {
+		t.Fatalf("expected two colors created, got %+v", report)
+	}
+	w, report = upload("/api/v1/colors/import", "application/json", `[{"name": "Red", "hexCode": "#ee0000"}]`)
+	StatusOf(t, w, http.StatusOK)
+	if report.Updated != 1 || report.Rows[0].Result.HexCode != "#ee0000" {
+		t.Fatalf("expected Red updated by name, got %+v", report)
+	}
+
+	var form strings.Builder
+	writer := multipart.NewWriter(&form)
+	file, _ := writer.CreateFormFile("file", "cities.csv")
+	io.WriteString(file, "name,country\nTehran,Iran\nParis,France\n")
+	writer.Close()
+	w, _ = upload("/api/v1/cities/import", writer.FormDataContentType(), form.String())
+	StatusOf(t, w, http.StatusMultiStatus)
+	var cities dto.ImportResponse[dto.CityResponse]
+	Decode(t, w, &cities)
+	tehran, err := k.Cities.GetById(userContext(), cities.Rows[0].Result.Id)
+	if err != nil || tehran.CountryId != iran.Id || cities.Created != 1 ||
+		cities.Rows[1].Status != http.StatusUnprocessableEntity || (*cities.Rows[1].ValidationErrors)[0].Property != "country" {
+		t.Fatalf("expected Tehran in Iran and France unknown, got %+v", cities)
+	}
+
+	// more keys than an in filter takes, each repeated
+	rows := "name,country\n"
+	for i := 0; i < filter.MaxExpressionValues+20; i++ {
+		name := string(rune('A'+i/26)) + string(rune('a'+i%26))
+		k.Country(t, "Land"+name)
+		rows += fmt.Sprintf("City%[1]s,Land%[1]s\nTown%[1]s,Land%[1]s\n", name)
+	}
+	w, _ = upload("/api/v1/cities/import", "text/csv", rows)
+	StatusOf(t, w, http.StatusOK)
+	Decode(t, w, &cities)
+	if cities.Created != 2*(filter.MaxExpressionValues+20) {
+		t.Fatalf("expected every city created, got %d created and %d failed", cities.Created, cities.Failed)
+	}
+
+	w, report = upload("/api/v1/colors/import", "text/csv", "name,shade\nPurple,dark\n")
+	StatusOf(t, w, http.StatusMultiStatus)
+	if (*report.Rows[0].ValidationErrors)[0].Property != "shade" {
+		t.Fatalf("expected the unknown column reported, got %+v", report)
+	}
+	w, _ = upload("/api/v1/colors/import", "text/plain", "name\nRed\n")
+	StatusOf(t, w, http.StatusBadRequest)
+}
+
+func TestOutboxRelay_RetriesInOrder(t *testing.T) {
+	k := New(t)
+	ctx := userContext()
+	red := k.Color(t, "Red", "#ff0000")
+	if _, err := k.Colors.Update(ctx, red.Id, map[string]interface{}{"Name": "Crimson"}); err != nil {
+		t.Fatal(err)
+	}
+	sink := &Sink{Down: true}
+	relay := usecase.NewOutboxRelayUsecaseWithClock(k.Config, k.Outbox, []event.Sink{sink}, k.Clock)
+
+	if published, err := relay.Relay(ctx); err != nil || published != 0 {
+		t.Fatalf("expected nothing published while the sink is down, got %d %v", published, err)
+	}
+	sink.Down = false
+	if published, _ := relay.Relay(ctx); published != 0 {
+		t.Fatalf("expected the failed event to wait for its backoff, got %d", published)
+	}
+	k.Clock.Advance(time.Second)
+	if published, err := relay.Relay(ctx); err != nil || published != 2 {
+		t.Fatalf("expected both events after the backoff, got %d %v", published, err)
+	}
+	events := sink.Published()
+	if events[0].Type != model.EntityCreated || events[1].Type != model.EntityUpdated || events[1].AggregateId != red.Id {
+		t.Fatalf("expected create before update, got %+v", events)
+	}
+	if outbox := k.Outbox.Events(); outbox[0].Attempts != 2 || !outbox[1].PublishedAt.Valid {
+		t.Fatalf("expected a retried and a published event, got %+v", outbox)
+	}
+}
+
+func TestOutboxRelay_OneAtATime(t *testing.T) {
+	k := New(t)
+	k.Color(t, "Red", "#ff0000")
+	sink := &Sink{}
+	relay := usecase.NewOutboxRelayUsecaseWithClock(k.Config, k.Outbox, []event.Sink{sink}, k.Clock)
+
+	unlock, ok, err := k.Outbox.Lock(context.Background())
+	if err != nil || !ok {
+		t.Fatalf("expected the lock, got %v %v", ok, err)
+	}
+	if published, err := relay.Relay(userContext()); err != nil || published != 0 {
+		t.Fatalf("expected nothing published while another relay holds the lock, got %d %v", published, err)
+	}
+	unlock()
+	if published, err := relay.Relay(userContext()); err != nil || published != 1 {
+		t.Fatalf("expected the event published once the lock is free, got %d %v", published, err)
+	}
+}
+
+// receiver is a webhook endpoint that checks signatures and fails while down
+type receiver struct {
+	mu     sync.Mutex
+	secret string
+	down   bool
+	now    func() time.Time
+	events []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if err := webhook.Verify(rc.secret, r.Header, body, rc.now(), time.Minute); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if rc.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	rc.events = append(rc.events, r.Header.Get(webhook.EventHeader))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestWebhooks_RetryDeadAndReplay(t *testing.T) {
+	k := New(t)
+	k.Config.Webhook.MaxAttempts = 2
+	ctx := userContext()
+	rc := &receiver{down: true, now: k.Clock.Now}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	webhooks := usecase.NewWebhookUsecaseWithClock(k.Config, k.Webhooks, k.Deliveries, k.Store, k.Clock)
+	dispatcher := usecase.NewWebhookDispatcherUsecaseWithClock(k.Config, k.Webhooks, k.Deliveries, k.Clock)
+	relay := usecase.NewOutboxRelayUsecaseWithClock(k.Config, k.Outbox, []event.Sink{webhooks}, k.Clock)
+	subscription, err := webhooks.Create(ctx, usecaseDto.CreateWebhookSubscription{
+		Url: server.URL, EntityTypes: "colors", EventTypes: model.EntityCreated, Active: true})
+	if err != nil || len(subscription.Secret) != 48 {
+		t.Fatalf("expected a subscription with a generated secret, got %+v %v", subscription, err)
+	}
+	rc.secret = subscription.Secret
+	k.Color(t, "Red", "#ff0000")
+	k.Country(t, "Iran")
+
+	if _, err := relay.Relay(ctx); err != nil {
+		t.Fatal(err)
+	}
+	deliveries := k.Deliveries.Deliveries()
+	if len(deliveries) != 1 || deliveries[0].EventType != model.EntityCreated {
+		t.Fatalf("expected one delivery of the created color, got %+v", deliveries)
+	}
+	if strings.Contains(k.Outbox.Events()[0].Payload, rc.secret) {
+		t.Fatalf("expected the secret to be redacted from the subscription event")
+	}
+
+	if delivered, err := dispatcher.Dispatch(ctx); err != nil || delivered != 0 {
+		t.Fatalf("expected the receiver to refuse, got %d %v", delivered, err)
+	}
+	k.Clock.Advance(time.Second)
+	if _, err := dispatcher.Dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	dead := k.Deliveries.Deliveries()[0]
+	if dead.Status != model.WebhookDead || dead.Attempts != 2 || dead.ResponseCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected a dead delivery after two refused attempts, got %+v", dead)
+	}
+
+	rc.down = false
+	replayed, err := webhooks.Replay(ctx, dead.Id)
+	if err != nil || replayed.Status != model.WebhookDelivered || replayed.ResponseCode != http.StatusNoContent {
+		t.Fatalf("expected the replay to be delivered, got %+v %v", replayed, err)
+	}
+	if _, err := webhooks.Replay(ctx, dead.Id); !isServiceError(err, service_errors.AlreadyDelivered) {
+		t.Fatalf("expected a delivered delivery not to be replayed, got %v", err)
+	}
+	if len(rc.events) != 1 || rc.events[0] != model.EntityCreated {
+		t.Fatalf("expected the receiver to get the event once, got %v", rc.events)
+	}
+
+	// the relay publishes at least once, a repeated event is not delivered again
+	if err := webhooks.Publish(ctx, event.Event{Id: int(dead.EventId.Int64), Type: model.EntityCreated, AggregateType: "colors"}); err != nil {
+		t.Fatal(err)
+	}
+	if deliveries := k.Deliveries.Deliveries(); len(deliveries) != 1 {
+		t.Fatalf("expected the repeated event to be skipped, got %d deliveries", len(deliveries))
+	}
+}
+
+func TestServer_Webhooks(t *testing.T) {
+	k := New(t)
+	rc := &receiver{now: time.Now}
+	receiverServer := httptest.NewServer(rc)
+	defer receiverServer.Close()
+	admin := k.Token(t, k.User(t, "root", constant.AdminRoleName))
+	server := k.Server()
+
+	body := dto.CreateWebhookSubscriptionRequest{Url: receiverServer.URL, EventTypes: []string{"EntityUpdated"}}
+	w := server.Do(t, http.MethodPost, "/api/v1/webhooks/", body, k.Token(t, k.User(t, "alice")))
+	StatusOf(t, w, http.StatusForbidden)
+	repeated := body
+	repeated.EventTypes = []string{"EntityUpdated", "EntityUpdated"}
+	w = server.Do(t, http.MethodPost, "/api/v1/webhooks/", repeated, admin)
+	StatusOf(t, w, http.StatusBadRequest)
+	w = server.Do(t, http.MethodPost, "/api/v1/webhooks/", body, admin)
+	StatusOf(t, w, http.StatusCreated)
+	var created dto.WebhookSubscriptionResponse
+	Decode(t, w, &created)
+	if created.Secret == "" || !created.Active || len(created.EntityTypes) != 0 || created.EventTypes[0] != "EntityUpdated" {
+		t.Fatalf("expected an active subscription to every entity with its secret, got %+v", created)
+	}
+	rc.secret = created.Secret
+
+	id := strconv.Itoa(created.Id)
+	w = server.Do(t, http.MethodGet, "/api/v1/webhooks/"+id, nil, admin)
+	StatusOf(t, w, http.StatusOK)
+	var read dto.WebhookSubscriptionResponse
+	Decode(t, w, &read)
+	if read.Secret != "" {
+		t.Fatalf("expected the secret to be shown only on create")
+	}
+
+	w = server.Do(t, http.MethodPost, "/api/v1/webhooks/"+id+"/ping", nil, admin)
+	StatusOf(t, w, http.StatusOK)
+	var ping dto.WebhookDeliveryResponse
+	Decode(t, w, &ping)
+	if ping.Status != model.WebhookDelivered || ping.ResponseCode != http.StatusNoContent || rc.events[0] != model.WebhookPing {
+		t.Fatalf("expected a delivered ping, got %+v", ping)
+	}
+
+	w = server.Do(t, http.MethodGet, "/api/v1/webhooks/"+id+"/deliveries?filter=status:equals:delivered", nil, admin)
+	StatusOf(t, w, http.StatusOK)
+	var log filter.PagedList[dto.WebhookDeliveryResponse]
+	Decode(t, w, &log)
+	if log.TotalRows != 1 || (*log.Items)[0].Id != ping.Id {
+		t.Fatalf("expected the ping in the delivery log, got %+v", log)
+	}
+
+	body.EventTypes = []string{"EntityRenamed"}
+	w = server.Do(t, http.MethodPost, "/api/v1/webhooks/", body, admin)
+	StatusOf(t, w, http.StatusBadRequest)
+}
@@ -0,0 +1,33 @@
+package testkit
+
+import (
+	"context"
+
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/pkg/service_errors"
+)
+
+// UserRepository is an in-memory repository.UserRepository. Like the database
+// one it writes no audit records.
+type UserRepository struct {
+	*Repository[model.User]
+}
+
+func NewUserRepository(store *Store) *UserRepository {
+	r := NewRepository[model.User](store)
+	r.unaudited = true
+	return &UserRepository{Repository: r}
+}
+
+// GetByUsername finds the user by exact username, soft deleted users included
+// like the database query
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (model.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, row := range r.store.rows(r.schema.Table) {
+		if user := row.Interface().(model.User); user.Username == username {
+			return user, nil
+		}
+	}
+	return model.User{}, &service_errors.ServiceError{EndUserMessage: service_errors.RecordNotFound}
+}
@@ -0,0 +1,94 @@
+package testkit
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"golang-clean-web-api/domain/model"
+)
+
+// WebhookSubscriptionRepository is an in-memory repository.WebhookSubscriptionRepository
+type WebhookSubscriptionRepository struct {
+	*Repository[model.WebhookSubscription]
+}
+
+func NewWebhookSubscriptionRepository(store *Store) *WebhookSubscriptionRepository {
+	return &WebhookSubscriptionRepository{Repository: NewRepository[model.WebhookSubscription](store)}
+}
+
+func (r *WebhookSubscriptionRepository) GetActive(ctx context.Context) ([]model.WebhookSubscription, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	subscriptions := []model.WebhookSubscription{}
+	for _, row := range r.store.rows(r.schema.Table) {
+		if subscription := row.Interface().(model.WebhookSubscription); subscription.Active && !isDeleted(ctx, r.schema, row) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+// WebhookDeliveryRepository is an in-memory repository.WebhookDeliveryRepository.
+// Like the database one it writes no audit records.
+type WebhookDeliveryRepository struct {
+	*Repository[model.WebhookDelivery]
+}
+
+func NewWebhookDeliveryRepository(store *Store) *WebhookDeliveryRepository {
+	r := NewRepository[model.WebhookDelivery](store)
+	r.unaudited = true
+	return &WebhookDeliveryRepository{Repository: r}
+}
+
+// Deliveries returns every delivery in id order
+func (r *WebhookDeliveryRepository) Deliveries() []model.WebhookDelivery {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	deliveries := []model.WebhookDelivery{}
+	for _, row := range r.store.rows(r.schema.Table) {
+		deliveries = append(deliveries, row.Interface().(model.WebhookDelivery))
+	}
+	return deliveries
+}
+
+// Enqueue skips the deliveries of an event a subscription already has, like the
+// unique index of the database
+func (r *WebhookDeliveryRepository) Enqueue(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	existing := r.Deliveries()
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, delivery := range deliveries {
+		queued := false
+		for _, other := range existing {
+			if delivery.EventId.Valid && other.EventId == delivery.EventId && other.SubscriptionId == delivery.SubscriptionId {
+				queued = true
+			}
+		}
+		if queued {
+			continue
+		}
+		if _, err := r.insert(ctx, delivery); err != nil {
+			return err
+		}
+		existing = append(existing, delivery)
+	}
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) Due(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	due := []model.WebhookDelivery{}
+	for _, delivery := range r.Deliveries() {
+		if delivery.Status == model.WebhookPending && !delivery.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (r *WebhookDeliveryRepository) Save(ctx context.Context, delivery model.WebhookDelivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.put(ctx, r.schema, reflect.ValueOf(&delivery).Elem())
+	return nil
+}
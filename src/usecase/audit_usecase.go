@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/domain/repository"
+	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/usecase/dto"
+)
+
+type AuditUsecase struct {
+	logger     logging.Logger
+	repository repository.AuditRepository
+}
+
+func NewAuditUsecase(cfg *config.Config, repository repository.AuditRepository) *AuditUsecase {
+	return &AuditUsecase{
+		logger:     logging.NewLogger(cfg),
+		repository: repository,
+	}
+}
+
+// GetByFilter pages the audit records across all entities, newest first unless sorted otherwise
+func (u *AuditUsecase) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.AuditLog], error) {
+	var response *filter.PagedList[dto.AuditLog]
+	if req.Sort == nil || len(*req.Sort) == 0 {
+		req.Sort = &[]filter.Sort{{ColId: "Id", Sort: "desc"}}
+	}
+	count, entries, err := u.repository.GetByFilter(ctx, req)
+	if err != nil {
+		return response, err
+	}
+
+	return filter.Paginate[model.AuditLog, dto.AuditLog](count, entries, req.GetPageNumber(), int64(req.GetPageSize()))
+}
@@ -2,24 +2,42 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 
 	"golang-clean-web-api/common"
 	"golang-clean-web-api/config"
 	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
 	"golang-clean-web-api/domain/repository"
+	"golang-clean-web-api/pkg/concurrency"
 	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/pkg/service_errors"
+	"golang-clean-web-api/usecase/dto"
 )
 
+const defaultExportBatch = 500
+
 type BaseUsecase[TEntity any, TCreate any, TUpdate any, TResponse any] struct {
-	logger     logging.Logger
-	repository repository.BaseRepository[TEntity]
+	logger        logging.Logger
+	repository    repository.BaseRepository[TEntity]
+	transactions  repository.TransactionManager
+	maxOperations int
+	exportBatch   int
+	maxImportRows int
 }
 
-func NewBaseUsecase[TEntity any, TCreate any, TUpdate any, TResponse any](cfg *config.Config, repository repository.BaseRepository[TEntity]) *BaseUsecase[TEntity, TCreate, TUpdate, TResponse] {
+func NewBaseUsecase[TEntity any, TCreate any, TUpdate any, TResponse any](cfg *config.Config, repository repository.BaseRepository[TEntity],
+	transactions repository.TransactionManager) *BaseUsecase[TEntity, TCreate, TUpdate, TResponse] {
 	logger := logging.NewLogger(cfg)
 	return &BaseUsecase[TEntity, TCreate, TUpdate, TResponse]{
-		repository: repository,
-		logger:     logger,
+		repository:    repository,
+		transactions:  transactions,
+		maxOperations: cfg.Bulk.MaxOperations,
+		exportBatch:   cfg.Export.BatchSize,
+		maxImportRows: cfg.Import.MaxRows,
+		logger:        logger,
 	}
 }
 
@@ -36,6 +54,21 @@ func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Create(ctx context.C
 	return response, nil
 }
 
+// Upsert creates the entity or overwrites the one with the same natural key,
+// reporting whether it was created
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Upsert(ctx context.Context, req TCreate) (TResponse, bool, error) {
+	var response TResponse
+	entity, _ := common.TypeConverter[TEntity](req)
+
+	entity, created, err := u.repository.Upsert(ctx, entity)
+	if err != nil {
+		return response, false, err
+	}
+
+	response, _ = common.TypeConverter[TResponse](entity)
+	return response, created, nil
+}
+
 func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Update(ctx context.Context, id int, req TUpdate) (TResponse, error) {
 	var response TResponse
 	updateMap, _ := common.TypeConverter[map[string]interface{}](req)
@@ -65,10 +98,291 @@ func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) GetById(ctx context.
 
 func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[TResponse], error) {
 	var response *filter.PagedList[TResponse]
+	if req.IsCursorMode() {
+		cursors, entities, err := u.repository.GetByCursor(ctx, req)
+		if err != nil {
+			return response, err
+		}
+		return filter.PaginateCursor[TEntity, TResponse](cursors, entities, int64(req.GetPageSize()))
+	}
+
 	count, entities, err := u.repository.GetByFilter(ctx, req)
 	if err != nil {
 		return response, err
 	}
 
-	return filter.Paginate[TEntity, TResponse](count, entities, req.PageNumber, int64(req.PageSize))
+	return filter.Paginate[TEntity, TResponse](count, entities, req.GetPageNumber(), int64(req.GetPageSize()))
+}
+
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) GetDeleted(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[TResponse], error) {
+	var response *filter.PagedList[TResponse]
+	count, entities, err := u.repository.GetDeleted(ctx, req)
+	if err != nil {
+		return response, err
+	}
+
+	return filter.Paginate[TEntity, TResponse](count, entities, req.GetPageNumber(), int64(req.GetPageSize()))
+}
+
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Restore(ctx context.Context, id int) error {
+	return u.repository.Restore(ctx, id)
+}
+
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Purge(ctx context.Context, id int) error {
+	return u.repository.Purge(ctx, id)
+}
+
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) GetHistory(ctx context.Context, id int, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.AuditLog], error) {
+	var response *filter.PagedList[dto.AuditLog]
+	count, entries, err := u.repository.GetHistory(ctx, id, req)
+	if err != nil {
+		return response, err
+	}
+
+	return filter.Paginate[model.AuditLog, dto.AuditLog](count, entries, req.GetPageNumber(), int64(req.GetPageSize()))
+}
+
+// Aggregate computes the requested values for every group of the matching rows
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Aggregate(ctx context.Context, input filter.AggregateInput) ([]dto.AggregateRow, error) {
+	rows, err := u.repository.Aggregate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]dto.AggregateRow, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, dto.AggregateRow{Group: row.Group, Values: row.Values})
+	}
+	return response, nil
+}
+
+// Export hands the entities of the filter to write in keyset pages of the export
+// batch size, so no more than a page is held at a time however many rows match
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Export(ctx context.Context, req filter.PaginationInputWithFilter, write func(items []TResponse) error) error {
+	req.PageSize = u.exportBatch
+	if req.PageSize <= 0 {
+		req.PageSize = defaultExportBatch
+	}
+	req.CursorMode, req.Cursor, req.WithTotal = true, "", false
+	for {
+		page, err := u.GetByFilter(ctx, req)
+		if err != nil {
+			return err
+		}
+		if err := write(*page.Items); err != nil {
+			return err
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		req.Cursor = page.NextCursor
+	}
+}
+
+// IdsByKey returns the ids of the entities whose natural key is one of keys, for
+// clients that refer to reference data by name. Unknown keys are left out. The
+// keys are looked up filter.MaxExpressionValues at a time, the most an in filter takes.
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) IdsByKey(ctx context.Context, keys []string) (map[string]int, error) {
+	keyed, ok := any(new(TEntity)).(interface{ NaturalKey() string })
+	if !ok {
+		return nil, fmt.Errorf("%T has no natural key", *new(TEntity))
+	}
+	ids := map[string]int{}
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+
+	for start := 0; start < len(unique); start += filter.MaxExpressionValues {
+		chunk := unique[start:min(start+filter.MaxExpressionValues, len(unique))]
+		req := filter.PaginationInputWithFilter{}
+		req.PageSize = len(chunk)
+		req.AndWhere(filter.Expression{Field: keyed.NaturalKey(), Filter: filter.Filter{Type: "in", Values: chunk}})
+		_, entities, err := u.repository.GetByFilter(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, entity := range *entities {
+			fields, err := common.TypeConverter[map[string]interface{}](entity)
+			if err != nil {
+				return nil, err
+			}
+			id, _ := fields["Id"].(float64)
+			ids[fmt.Sprint(fields[keyed.NaturalKey()])] = int(id)
+		}
+	}
+	return ids, nil
+}
+
+// errDryRun rolls back an import that was only meant to report
+var errDryRun = errors.New("import dry run")
+
+// Import writes the rows with write, which reports whether a row was created or
+// updated. The rows share a transaction with a savepoint each, so a failed row
+// leaves the others alone. A dry run reports the same outcomes and keeps nothing.
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Import(ctx context.Context, dryRun bool, rows []dto.ImportRow[TCreate],
+	write func(ctx context.Context, req TCreate) (TResponse, bool, error)) ([]dto.ImportResult[TResponse], error) {
+	if u.maxImportRows > 0 && len(rows) > u.maxImportRows {
+		return nil, &service_errors.ServiceError{
+			EndUserMessage:   service_errors.ImportTooLarge,
+			TechnicalMessage: fmt.Sprintf("%d rows, at most %d are allowed", len(rows), u.maxImportRows),
+		}
+	}
+
+	results := make([]dto.ImportResult[TResponse], len(rows))
+	err := u.transactions.Do(ctx, func(ctx context.Context) error {
+		for i, row := range rows {
+			results[i] = dto.ImportResult[TResponse]{Row: row.Row, Outcome: dto.ImportFailed, Err: row.Err}
+			if row.Err != nil {
+				continue
+			}
+			results[i].Err = u.transactions.Do(ctx, func(ctx context.Context) error {
+				response, created, err := write(ctx, row.Create)
+				if err != nil {
+					return err
+				}
+				results[i].Result, results[i].Outcome = &response, dto.ImportUpdated
+				if created {
+					results[i].Outcome = dto.ImportCreated
+				}
+				return nil
+			})
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return results, nil
+}
+
+// errBulkFailed rolls back an all-or-nothing bulk after one of its operations failed
+var errBulkFailed = errors.New("bulk operation failed")
+
+// Bulk runs the operations in order. Atomic keeps all of them or none, otherwise
+// every operation stands on its own. Failures are reported per operation, the
+// error is only set when the request as a whole could not be handled.
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) Bulk(ctx context.Context, atomic bool, operations []dto.BulkOperation[TCreate, TUpdate]) ([]dto.BulkResult[TResponse], error) {
+	if u.maxOperations > 0 && len(operations) > u.maxOperations {
+		return nil, &service_errors.ServiceError{
+			EndUserMessage:   service_errors.BulkTooLarge,
+			TechnicalMessage: fmt.Sprintf("%d operations, at most %d are allowed", len(operations), u.maxOperations),
+		}
+	}
+
+	results := make([]dto.BulkResult[TResponse], len(operations))
+	for i, operation := range operations {
+		results[i] = dto.BulkResult[TResponse]{Op: operation.Op, Id: operation.Id, Err: operation.Err}
+	}
+	if !atomic {
+		u.bulk(ctx, operations, results)
+		return results, nil
+	}
+	if bulkFailed(results) {
+		rollBack(results)
+		return results, nil
+	}
+
+	err := u.transactions.Do(ctx, func(ctx context.Context) error {
+		u.bulk(ctx, operations, results)
+		if bulkFailed(results) {
+			return errBulkFailed
+		}
+		return nil
+	})
+	if errors.Is(err, errBulkFailed) {
+		rollBack(results)
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) bulk(ctx context.Context, operations []dto.BulkOperation[TCreate, TUpdate], results []dto.BulkResult[TResponse]) {
+	for i := 0; i < len(operations); {
+		operation := operations[i]
+		if results[i].Err != nil {
+			i++
+			continue
+		}
+
+		opCtx := ctx
+		if operation.Version > 0 {
+			opCtx = concurrency.NewContext(ctx, operation.Version)
+		}
+		switch operation.Op {
+		case dto.BulkCreate:
+			// consecutive creates go in one batch
+			end := i + 1
+			for end < len(operations) && operations[end].Op == dto.BulkCreate && results[end].Err == nil {
+				end++
+			}
+			u.createMany(ctx, operations[i:end], results[i:end])
+			i = end
+			continue
+		case dto.BulkUpdate:
+			response, err := u.Update(opCtx, operation.Id, operation.Update)
+			results[i].Result, results[i].Err = &response, err
+		case dto.BulkDelete:
+			results[i].Err = u.Delete(opCtx, operation.Id)
+		default:
+			results[i].Err = &service_errors.ServiceError{EndUserMessage: service_errors.InvalidBulkOperation,
+				TechnicalMessage: fmt.Sprintf("unknown operation %q", operation.Op)}
+		}
+		if results[i].Err != nil {
+			results[i].Result = nil
+		}
+		i++
+	}
+}
+
+// createMany inserts a run of creates in batches. When a batch fails, its rows are
+// created one by one to find out which of them are at fault.
+func (u *BaseUsecase[TEntity, TCreate, TUpdate, TResponse]) createMany(ctx context.Context, operations []dto.BulkOperation[TCreate, TUpdate], results []dto.BulkResult[TResponse]) {
+	entities := make([]TEntity, 0, len(operations))
+	for _, operation := range operations {
+		entity, _ := common.TypeConverter[TEntity](operation.Create)
+		entities = append(entities, entity)
+	}
+
+	// the batch gets a copy, the ids of a failed batch must not leak into the retries
+	created, err := u.repository.CreateMany(ctx, slices.Clone(entities))
+	for i := range entities {
+		entity := created[i]
+		if err != nil {
+			entity, results[i].Err = u.repository.Create(ctx, entities[i])
+			if results[i].Err != nil {
+				continue
+			}
+		}
+		response, _ := common.TypeConverter[TResponse](entity)
+		results[i].Result = &response
+	}
+}
+
+func bulkFailed[TResponse any](results []dto.BulkResult[TResponse]) bool {
+	for _, result := range results {
+		if result.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rollBack reports the operations that succeeded as undone
+func rollBack[TResponse any](results []dto.BulkResult[TResponse]) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Result = nil
+			results[i].Err = &service_errors.ServiceError{EndUserMessage: service_errors.BulkRolledBack}
+		}
+	}
 }
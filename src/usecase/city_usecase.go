@@ -14,9 +14,9 @@ type CityUsecase struct {
 	base *BaseUsecase[model.City, dto.CreateCity, dto.UpdateCity, dto.City]
 }
 
-func NewCityUsecase(cfg *config.Config, repository repository.CityRepository) *CityUsecase {
+func NewCityUsecase(cfg *config.Config, repository repository.CityRepository, transactions repository.TransactionManager) *CityUsecase {
 	return &CityUsecase{
-		base: NewBaseUsecase[model.City, dto.CreateCity, dto.UpdateCity, dto.City](cfg, repository),
+		base: NewBaseUsecase[model.City, dto.CreateCity, dto.UpdateCity, dto.City](cfg, repository, transactions),
 	}
 }
 
@@ -35,6 +35,11 @@ func (u *CityUsecase) Delete(ctx context.Context, id int) error {
 	return u.base.Delete(ctx, id)
 }
 
+// Bulk
+func (u *CityUsecase) Bulk(ctx context.Context, atomic bool, operations []dto.BulkOperation[dto.CreateCity, dto.UpdateCity]) ([]dto.BulkResult[dto.City], error) {
+	return u.base.Bulk(ctx, atomic, operations)
+}
+
 // Get By Id
 func (u *CityUsecase) GetById(ctx context.Context, id int) (dto.City, error) {
 	return u.base.GetById(ctx, id)
@@ -44,3 +49,41 @@ func (u *CityUsecase) GetById(ctx context.Context, id int) (dto.City, error) {
 func (u *CityUsecase) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.City], error) {
 	return u.base.GetByFilter(ctx, req)
 }
+
+// Get Deleted
+func (u *CityUsecase) GetDeleted(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.City], error) {
+	return u.base.GetDeleted(ctx, req)
+}
+
+// Restore
+func (u *CityUsecase) Restore(ctx context.Context, id int) error {
+	return u.base.Restore(ctx, id)
+}
+
+// Purge
+func (u *CityUsecase) Purge(ctx context.Context, id int) error {
+	return u.base.Purge(ctx, id)
+}
+
+// Get History
+func (u *CityUsecase) GetHistory(ctx context.Context, id int, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.AuditLog], error) {
+	return u.base.GetHistory(ctx, id, req)
+}
+
+// Aggregate
+func (u *CityUsecase) Aggregate(ctx context.Context, input filter.AggregateInput) ([]dto.AggregateRow, error) {
+	return u.base.Aggregate(ctx, input)
+}
+
+// Export
+func (u *CityUsecase) Export(ctx context.Context, req filter.PaginationInputWithFilter, write func(items []dto.City) error) error {
+	return u.base.Export(ctx, req, write)
+}
+
+// Import creates cities, they have no natural key to update them by
+func (u *CityUsecase) Import(ctx context.Context, dryRun bool, rows []dto.ImportRow[dto.CreateCity]) ([]dto.ImportResult[dto.City], error) {
+	return u.base.Import(ctx, dryRun, rows, func(ctx context.Context, req dto.CreateCity) (dto.City, bool, error) {
+		response, err := u.base.Create(ctx, req)
+		return response, true, err
+	})
+}
@@ -14,9 +14,9 @@ type ColorUsecase struct {
 	base *BaseUsecase[model.Color, dto.CreateColor, dto.UpdateColor, dto.Color]
 }
 
-func NewColorUsecase(cfg *config.Config, repository repository.ColorRepository) *ColorUsecase {
+func NewColorUsecase(cfg *config.Config, repository repository.ColorRepository, transactions repository.TransactionManager) *ColorUsecase {
 	return &ColorUsecase{
-		base: NewBaseUsecase[model.Color, dto.CreateColor, dto.UpdateColor, dto.Color](cfg, repository),
+		base: NewBaseUsecase[model.Color, dto.CreateColor, dto.UpdateColor, dto.Color](cfg, repository, transactions),
 	}
 }
 
@@ -25,6 +25,11 @@ func (u *ColorUsecase) Create(ctx context.Context, req dto.CreateColor) (dto.Col
 	return u.base.Create(ctx, req)
 }
 
+// Upsert
+func (u *ColorUsecase) Upsert(ctx context.Context, req dto.CreateColor) (dto.Color, bool, error) {
+	return u.base.Upsert(ctx, req)
+}
+
 // Update
 func (u *ColorUsecase) Update(ctx context.Context, id int, req dto.UpdateColor) (dto.Color, error) {
 	return u.base.Update(ctx, id, req)
@@ -35,6 +40,11 @@ func (u *ColorUsecase) Delete(ctx context.Context, id int) error {
 	return u.base.Delete(ctx, id)
 }
 
+// Bulk
+func (u *ColorUsecase) Bulk(ctx context.Context, atomic bool, operations []dto.BulkOperation[dto.CreateColor, dto.UpdateColor]) ([]dto.BulkResult[dto.Color], error) {
+	return u.base.Bulk(ctx, atomic, operations)
+}
+
 // Get By Id
 func (u *ColorUsecase) GetById(ctx context.Context, id int) (dto.Color, error) {
 	return u.base.GetById(ctx, id)
@@ -44,3 +54,38 @@ func (u *ColorUsecase) GetById(ctx context.Context, id int) (dto.Color, error) {
 func (u *ColorUsecase) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.Color], error) {
 	return u.base.GetByFilter(ctx, req)
 }
+
+// Get Deleted
+func (u *ColorUsecase) GetDeleted(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.Color], error) {
+	return u.base.GetDeleted(ctx, req)
+}
+
+// Restore
+func (u *ColorUsecase) Restore(ctx context.Context, id int) error {
+	return u.base.Restore(ctx, id)
+}
+
+// Purge
+func (u *ColorUsecase) Purge(ctx context.Context, id int) error {
+	return u.base.Purge(ctx, id)
+}
+
+// Get History
+func (u *ColorUsecase) GetHistory(ctx context.Context, id int, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.AuditLog], error) {
+	return u.base.GetHistory(ctx, id, req)
+}
+
+// Aggregate
+func (u *ColorUsecase) Aggregate(ctx context.Context, input filter.AggregateInput) ([]dto.AggregateRow, error) {
+	return u.base.Aggregate(ctx, input)
+}
+
+// Export
+func (u *ColorUsecase) Export(ctx context.Context, req filter.PaginationInputWithFilter, write func(items []dto.Color) error) error {
+	return u.base.Export(ctx, req, write)
+}
+
+// Import creates or updates colors by name
+func (u *ColorUsecase) Import(ctx context.Context, dryRun bool, rows []dto.ImportRow[dto.CreateColor]) ([]dto.ImportResult[dto.Color], error) {
+	return u.base.Import(ctx, dryRun, rows, u.base.Upsert)
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 
+	"golang-clean-web-api/common"
 	"golang-clean-web-api/config"
 	"golang-clean-web-api/domain/filter"
 	model "golang-clean-web-api/domain/model"
@@ -11,18 +12,51 @@ import (
 )
 
 type CountryUsecase struct {
-	base *BaseUsecase[model.Country, dto.Name, dto.Name, dto.Country]
+	base         *BaseUsecase[model.Country, dto.Name, dto.Name, dto.Country]
+	cities       repository.CityRepository
+	transactions repository.TransactionManager
 }
 
-func NewCountryUsecase(cfg *config.Config, repository repository.CountryRepository) *CountryUsecase {
+func NewCountryUsecase(cfg *config.Config, repository repository.CountryRepository,
+	cities repository.CityRepository, transactions repository.TransactionManager) *CountryUsecase {
 	return &CountryUsecase{
-		base: NewBaseUsecase[model.Country, dto.Name, dto.Name, dto.Country](cfg, repository),
+		base:         NewBaseUsecase[model.Country, dto.Name, dto.Name, dto.Country](cfg, repository, transactions),
+		cities:       cities,
+		transactions: transactions,
 	}
 }
 
-// Create
-func (u *CountryUsecase) Create(ctx context.Context, req dto.Name) (dto.Country, error) {
-	return u.base.Create(ctx, req)
+// Create adds the country and its cities, nothing is kept when one of them fails
+func (u *CountryUsecase) Create(ctx context.Context, req dto.CreateCountry) (dto.Country, error) {
+	var response dto.Country
+	err := u.transactions.Do(ctx, func(ctx context.Context) error {
+		var err error
+		response, err = u.base.Create(ctx, dto.Name{Name: req.Name})
+		if err != nil {
+			return err
+		}
+		for _, name := range req.Cities {
+			entity, err := u.cities.Create(ctx, model.City{Name: name, CountryId: response.Id})
+			if err != nil {
+				return err
+			}
+			city, err := common.TypeConverter[dto.City](entity)
+			if err != nil {
+				return err
+			}
+			response.Cities = append(response.Cities, city)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.Country{}, err
+	}
+	return response, nil
+}
+
+// Upsert
+func (u *CountryUsecase) Upsert(ctx context.Context, req dto.Name) (dto.Country, bool, error) {
+	return u.base.Upsert(ctx, req)
 }
 
 // Update
@@ -35,6 +69,11 @@ func (u *CountryUsecase) Delete(ctx context.Context, id int) error {
 	return u.base.Delete(ctx, id)
 }
 
+// Bulk
+func (u *CountryUsecase) Bulk(ctx context.Context, atomic bool, operations []dto.BulkOperation[dto.Name, dto.Name]) ([]dto.BulkResult[dto.Country], error) {
+	return u.base.Bulk(ctx, atomic, operations)
+}
+
 // Get By Id
 func (u *CountryUsecase) GetById(ctx context.Context, id int) (dto.Country, error) {
 	return u.base.GetById(ctx, id)
@@ -44,3 +83,43 @@ func (u *CountryUsecase) GetById(ctx context.Context, id int) (dto.Country, erro
 func (u *CountryUsecase) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.Country], error) {
 	return u.base.GetByFilter(ctx, req)
 }
+
+// Get Deleted
+func (u *CountryUsecase) GetDeleted(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.Country], error) {
+	return u.base.GetDeleted(ctx, req)
+}
+
+// Restore
+func (u *CountryUsecase) Restore(ctx context.Context, id int) error {
+	return u.base.Restore(ctx, id)
+}
+
+// Purge
+func (u *CountryUsecase) Purge(ctx context.Context, id int) error {
+	return u.base.Purge(ctx, id)
+}
+
+// Get History
+func (u *CountryUsecase) GetHistory(ctx context.Context, id int, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.AuditLog], error) {
+	return u.base.GetHistory(ctx, id, req)
+}
+
+// Aggregate
+func (u *CountryUsecase) Aggregate(ctx context.Context, input filter.AggregateInput) ([]dto.AggregateRow, error) {
+	return u.base.Aggregate(ctx, input)
+}
+
+// Export
+func (u *CountryUsecase) Export(ctx context.Context, req filter.PaginationInputWithFilter, write func(items []dto.Country) error) error {
+	return u.base.Export(ctx, req, write)
+}
+
+// Import creates or updates countries by name
+func (u *CountryUsecase) Import(ctx context.Context, dryRun bool, rows []dto.ImportRow[dto.Name]) ([]dto.ImportResult[dto.Country], error) {
+	return u.base.Import(ctx, dryRun, rows, u.base.Upsert)
+}
+
+// Ids By Name
+func (u *CountryUsecase) IdsByName(ctx context.Context, names []string) (map[string]int, error) {
+	return u.base.IdsByKey(ctx, names)
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"database/sql"
 	"time"
 )
 
@@ -12,8 +13,23 @@ type Name struct {
 	Name string
 }
 
+// Versioned carries the row version, http clients see it as the ETag
+type Versioned struct {
+	Version int
+}
+
+func (v Versioned) GetVersion() int {
+	return v.Version
+}
+
+type CreateCountry struct {
+	Name   string
+	Cities []string
+}
+
 type Country struct {
 	IdName
+	Versioned
 	Cities    []City
 	Companies []Company
 }
@@ -29,6 +45,7 @@ type UpdateCity struct {
 }
 type City struct {
 	IdName
+	Versioned
 	Country Country
 }
 
@@ -61,6 +78,7 @@ type UpdateCompany struct {
 }
 type Company struct {
 	IdName
+	Versioned
 	Country Country
 }
 
@@ -76,6 +94,7 @@ type UpdateColor struct {
 
 type Color struct {
 	IdName
+	Versioned
 	HexCode string
 }
 
@@ -106,3 +125,74 @@ type PersianYearWithoutDate struct {
 	PersianTitle string
 	Year         int
 }
+
+// AuditLog is one recorded write, Changes is the json encoded list of field changes
+type AuditLog struct {
+	Id         int
+	EntityType string
+	EntityId   int
+	Action     string
+	UserId     int
+	Username   string
+	RequestId  string
+	CreatedAt  time.Time
+	Changes    string
+}
+
+// SearchHit is an entity found by search, Highlight marks the matched words of its name
+type SearchHit struct {
+	Type      string
+	Id        int
+	Name      string
+	Rank      float64
+	Highlight string
+}
+
+// AggregateRow is one group of an aggregate request, see filter.AggregateRow
+type AggregateRow struct {
+	Group  map[string]interface{}
+	Values map[string]interface{}
+}
+
+// CreateWebhookSubscription subscribes a url to domain events, EntityTypes and
+// EventTypes are comma separated, empty for all. An empty Secret is generated.
+type CreateWebhookSubscription struct {
+	Url         string
+	EntityTypes string
+	EventTypes  string
+	Secret      string
+	Active      bool
+}
+
+type UpdateWebhookSubscription struct {
+	Url         string
+	EntityTypes string
+	EventTypes  string
+	Active      bool
+}
+
+type WebhookSubscription struct {
+	Id int
+	Versioned
+	Url         string
+	EntityTypes string
+	EventTypes  string
+	Secret      string
+	Active      bool
+}
+
+// WebhookDelivery is an event sent to a subscription and how its last attempt went
+type WebhookDelivery struct {
+	Id             int
+	SubscriptionId int
+	EventId        sql.NullInt64
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseCode   int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    sql.NullTime
+}
@@ -0,0 +1,27 @@
+package dto
+
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// BulkOperation is one write of a bulk request. Create or Update is set depending
+// on Op, Version is the expected row version of an update or delete, 0 for any.
+// Err marks an operation that was rejected before reaching the usecase.
+type BulkOperation[TCreate any, TUpdate any] struct {
+	Op      string
+	Id      int
+	Version int
+	Create  TCreate
+	Update  TUpdate
+	Err     error
+}
+
+// BulkResult is the outcome of the operation at the same index
+type BulkResult[TResponse any] struct {
+	Op     string
+	Id     int
+	Result *TResponse
+	Err    error
+}
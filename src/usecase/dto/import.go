@@ -0,0 +1,23 @@
+package dto
+
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+// ImportRow is a row of an upload, Row is its position counted from 1. Err marks
+// a row that was rejected before reaching the usecase.
+type ImportRow[TCreate any] struct {
+	Row    int
+	Create TCreate
+	Err    error
+}
+
+// ImportResult is the outcome of the row with the same Row
+type ImportResult[TResponse any] struct {
+	Row     int
+	Outcome string
+	Result  *TResponse
+	Err     error
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/event"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/domain/repository"
+	"golang-clean-web-api/pkg/clock"
+	"golang-clean-web-api/pkg/logging"
+)
+
+const (
+	defaultRelayInterval  = time.Second
+	defaultRelayBatchSize = 100
+	defaultMaxBackoff     = 10 * time.Minute
+	// firstBackoff is the wait after the first failure, it doubles with every other
+	firstBackoff = time.Second
+)
+
+// OutboxRelayUsecase publishes the events in the outbox to every sink. An event
+// is published once all sinks took it, otherwise all of them get it again after
+// a backoff, so delivery is at least once. The next event of the same aggregate
+// waits until then.
+type OutboxRelayUsecase struct {
+	cfg        *config.OutboxConfig
+	logger     logging.Logger
+	clock      clock.Clock
+	repository repository.OutboxRepository
+	sinks      []event.Sink
+}
+
+func NewOutboxRelayUsecase(cfg *config.Config, repository repository.OutboxRepository, sinks []event.Sink) *OutboxRelayUsecase {
+	return NewOutboxRelayUsecaseWithClock(cfg, repository, sinks, clock.System)
+}
+
+// NewOutboxRelayUsecaseWithClock decides which events are due by the time of c
+func NewOutboxRelayUsecaseWithClock(cfg *config.Config, repository repository.OutboxRepository, sinks []event.Sink,
+	c clock.Clock) *OutboxRelayUsecase {
+	return &OutboxRelayUsecase{
+		cfg:        &cfg.Outbox,
+		logger:     logging.NewLogger(cfg),
+		clock:      c,
+		repository: repository,
+		sinks:      sinks,
+	}
+}
+
+// Run relays on every interval until the context is done
+func (u *OutboxRelayUsecase) Run(ctx context.Context) {
+	interval := u.cfg.PollInterval
+	if interval <= 0 {
+		interval = defaultRelayInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := u.Relay(ctx); err != nil {
+			u.logger.Error(logging.Internal, logging.Outbox, err.Error(), nil)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay publishes the due events until none is left and returns how many were
+// published. A failed delivery is no error, the event is retried later. While
+// the relay of another instance runs it publishes nothing.
+func (u *OutboxRelayUsecase) Relay(ctx context.Context) (int, error) {
+	unlock, ok, err := u.repository.Lock(ctx)
+	if err != nil || !ok {
+		return 0, err
+	}
+	defer unlock()
+
+	batchSize := u.cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultRelayBatchSize
+	}
+
+	published := 0
+	for ctx.Err() == nil {
+		events, err := u.repository.Pending(ctx, u.clock.Now().UTC(), batchSize)
+		if err != nil {
+			return published, err
+		}
+		round := 0
+		for _, e := range events {
+			ok, err := u.publish(ctx, e)
+			if err != nil {
+				return published, err
+			}
+			if ok {
+				round++
+			}
+		}
+		published += round
+		// every event of this round failed, the next ones of their aggregates wait
+		if round == 0 {
+			break
+		}
+	}
+	return published, nil
+}
+
+// publish hands the event to every sink and records the outcome
+func (u *OutboxRelayUsecase) publish(ctx context.Context, e model.OutboxEvent) (bool, error) {
+	out := event.Event{
+		Id:            e.Id,
+		Type:          e.EventType,
+		AggregateType: e.AggregateType,
+		AggregateId:   e.AggregateId,
+		Payload:       json.RawMessage(e.Payload),
+		UserId:        e.UserId,
+		RequestId:     e.RequestId,
+		OccurredAt:    e.CreatedAt,
+	}
+	for _, sink := range u.sinks {
+		if err := sink.Publish(ctx, out); err != nil {
+			reason := fmt.Sprintf("%s: %s", sink.Name(), err.Error())
+			u.logger.Warn(logging.Internal, logging.Outbox, reason, map[logging.ExtraKey]interface{}{
+				logging.EventId:   e.Id,
+				logging.Aggregate: fmt.Sprintf("%s/%d", e.AggregateType, e.AggregateId),
+			})
+			return false, u.repository.MarkFailed(ctx, e.Id, u.clock.Now().UTC().Add(u.backoff(e.Attempts+1)), reason)
+		}
+	}
+	return true, u.repository.MarkPublished(ctx, e.Id, u.clock.Now().UTC())
+}
+
+// backoff is the wait after the given number of failed attempts
+func (u *OutboxRelayUsecase) backoff(attempts int) time.Duration {
+	limit := u.cfg.MaxBackoff
+	if limit <= 0 {
+		limit = defaultMaxBackoff
+	}
+	return exponentialBackoff(attempts, limit)
+}
+
+// exponentialBackoff doubles the wait from firstBackoff with every failed attempt, up to limit
+func exponentialBackoff(attempts int, limit time.Duration) time.Duration {
+	wait := firstBackoff
+	for i := 1; i < attempts && wait < limit; i++ {
+		wait *= 2
+	}
+	return min(wait, limit)
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+
+	"golang-clean-web-api/common"
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/repository"
+	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/usecase/dto"
+)
+
+type SearchUsecase struct {
+	logger     logging.Logger
+	repository repository.SearchRepository
+}
+
+func NewSearchUsecase(cfg *config.Config, repository repository.SearchRepository) *SearchUsecase {
+	return &SearchUsecase{
+		logger:     logging.NewLogger(cfg),
+		repository: repository,
+	}
+}
+
+// Search finds entities of every type, or of the requested ones, most relevant first
+func (u *SearchUsecase) Search(ctx context.Context, input filter.SearchInput) ([]dto.SearchHit, error) {
+	hits, err := u.repository.Search(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return common.TypeConverter[[]dto.SearchHit](hits)
+}
+
+// Suggest completes the name of an entity of one type
+func (u *SearchUsecase) Suggest(ctx context.Context, input filter.SearchInput) ([]dto.IdName, error) {
+	hits, err := u.repository.Suggest(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := make([]dto.IdName, 0, len(hits))
+	for _, hit := range hits {
+		suggestions = append(suggestions, dto.IdName{Id: hit.Id, Name: hit.Name})
+	}
+	return suggestions, nil
+}
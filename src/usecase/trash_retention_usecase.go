@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/repository"
+	"golang-clean-web-api/pkg/logging"
+)
+
+const defaultPurgeInterval = 24 * time.Hour
+
+// TrashRetentionUsecase purges soft deleted rows once they are older than the retention
+type TrashRetentionUsecase struct {
+	cfg          *config.TrashConfig
+	logger       logging.Logger
+	repositories []repository.TrashRepository
+}
+
+// NewTrashRetentionUsecase takes the repositories in purge order, rows that are
+// referenced by others come last
+func NewTrashRetentionUsecase(cfg *config.Config, repositories []repository.TrashRepository) *TrashRetentionUsecase {
+	return &TrashRetentionUsecase{
+		cfg:          &cfg.Trash,
+		logger:       logging.NewLogger(cfg),
+		repositories: repositories,
+	}
+}
+
+// Run purges on every interval until the context is done
+func (u *TrashRetentionUsecase) Run(ctx context.Context) {
+	interval := u.cfg.PurgeInterval
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := u.PurgeExpired(ctx); err != nil {
+			u.logger.Error(logging.Postgres, logging.Purge, err.Error(), nil)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired removes the rows deleted more than RetentionDays ago. A failing
+// repository does not stop the others.
+func (u *TrashRetentionUsecase) PurgeExpired(ctx context.Context) (int64, error) {
+	if u.cfg.RetentionDays <= 0 {
+		return 0, nil
+	}
+	before := time.Now().UTC().AddDate(0, 0, -u.cfg.RetentionDays)
+
+	var total int64
+	var errs []error
+	for _, repository := range u.repositories {
+		purged, err := repository.PurgeDeletedBefore(ctx, before)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		total += purged
+	}
+	if total > 0 {
+		u.logger.Info(logging.Postgres, logging.Purge, fmt.Sprintf("purged %d rows deleted before %s", total, before.Format(time.RFC3339)), nil)
+	}
+	return total, errors.Join(errs...)
+}
@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/domain/repository"
+	"golang-clean-web-api/pkg/clock"
+	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/pkg/webhook"
+)
+
+const (
+	defaultWebhookInterval    = time.Second
+	defaultWebhookBatchSize   = 100
+	defaultWebhookTimeout     = 10 * time.Second
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookMaxBackoff  = time.Hour
+)
+
+// WebhookDispatcherUsecase sends the pending webhook deliveries. A delivery that
+// fails is retried with a growing backoff until it runs out of attempts and is
+// dead. Pings are tried once.
+type WebhookDispatcherUsecase struct {
+	cfg           *config.WebhookConfig
+	logger        logging.Logger
+	clock         clock.Clock
+	client        *webhook.Client
+	subscriptions repository.WebhookSubscriptionRepository
+	deliveries    repository.WebhookDeliveryRepository
+}
+
+func NewWebhookDispatcherUsecase(cfg *config.Config, subscriptions repository.WebhookSubscriptionRepository,
+	deliveries repository.WebhookDeliveryRepository) *WebhookDispatcherUsecase {
+	return NewWebhookDispatcherUsecaseWithClock(cfg, subscriptions, deliveries, clock.System)
+}
+
+// NewWebhookDispatcherUsecaseWithClock decides which deliveries are due, and signs
+// them, by the time of c
+func NewWebhookDispatcherUsecaseWithClock(cfg *config.Config, subscriptions repository.WebhookSubscriptionRepository,
+	deliveries repository.WebhookDeliveryRepository, c clock.Clock) *WebhookDispatcherUsecase {
+	timeout := cfg.Webhook.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookDispatcherUsecase{
+		cfg:           &cfg.Webhook,
+		logger:        logging.NewLogger(cfg),
+		clock:         c,
+		client:        webhook.NewClient(timeout),
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+	}
+}
+
+// Run dispatches on every interval until the context is done
+func (u *WebhookDispatcherUsecase) Run(ctx context.Context) {
+	interval := u.cfg.PollInterval
+	if interval <= 0 {
+		interval = defaultWebhookInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := u.Dispatch(ctx); err != nil {
+			u.logger.Error(logging.Internal, logging.Webhook, err.Error(), nil)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch attempts the due deliveries until none is left and returns how many
+// were delivered. A failed attempt is no error, the delivery is retried later.
+func (u *WebhookDispatcherUsecase) Dispatch(ctx context.Context) (int, error) {
+	batchSize := u.cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultWebhookBatchSize
+	}
+
+	delivered := 0
+	for ctx.Err() == nil {
+		due, err := u.deliveries.Due(ctx, u.clock.Now().UTC(), batchSize)
+		if err != nil {
+			return delivered, err
+		}
+		if len(due) == 0 {
+			break
+		}
+		// every attempt moves a delivery out of the due ones: delivered, dead or
+		// retried after a backoff
+		subscriptions := map[int]*model.WebhookSubscription{}
+		for _, delivery := range due {
+			subscription, ok := subscriptions[delivery.SubscriptionId]
+			if !ok {
+				found, err := u.subscriptions.GetById(ctx, delivery.SubscriptionId)
+				if err == nil {
+					subscription = &found
+				} else if !isNotFound(err) {
+					return delivered, err
+				}
+				subscriptions[delivery.SubscriptionId] = subscription
+			}
+			if subscription == nil || !subscription.Active {
+				if err := u.abandon(ctx, delivery, subscription); err != nil {
+					return delivered, err
+				}
+				continue
+			}
+			delivery, err = u.Attempt(ctx, *subscription, delivery)
+			if err != nil {
+				return delivered, err
+			}
+			if delivery.Status == model.WebhookDelivered {
+				delivered++
+			}
+		}
+	}
+	return delivered, nil
+}
+
+// Attempt sends the delivery to the subscription once and records the outcome
+func (u *WebhookDispatcherUsecase) Attempt(ctx context.Context, subscription model.WebhookSubscription,
+	delivery model.WebhookDelivery) (model.WebhookDelivery, error) {
+	now := u.clock.Now().UTC()
+	status, err := u.client.Send(ctx, subscription.Url, subscription.Secret, delivery.Id, delivery.EventType, []byte(delivery.Payload), now)
+	delivery.Attempts++
+	delivery.ResponseCode = status
+	if err == nil {
+		delivery.Status = model.WebhookDelivered
+		delivery.DeliveredAt = sql.NullTime{Valid: true, Time: now}
+		delivery.LastError = ""
+		return delivery, u.deliveries.Save(ctx, delivery)
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.EventType == model.WebhookPing || delivery.Attempts >= u.maxAttempts() {
+		delivery.Status = model.WebhookDead
+	} else {
+		delivery.NextAttemptAt = now.Add(u.backoff(delivery.Attempts))
+	}
+	u.logger.Warn(logging.Internal, logging.Webhook, delivery.LastError, map[logging.ExtraKey]interface{}{
+		logging.DeliveryId: delivery.Id,
+		logging.EventType:  delivery.EventType,
+	})
+	return delivery, u.deliveries.Save(ctx, delivery)
+}
+
+// abandon kills the delivery of a subscription that was deleted or deactivated,
+// a replay sends it once the subscription is active again
+func (u *WebhookDispatcherUsecase) abandon(ctx context.Context, delivery model.WebhookDelivery, subscription *model.WebhookSubscription) error {
+	delivery.Status = model.WebhookDead
+	delivery.LastError = "subscription is inactive"
+	if subscription == nil {
+		delivery.LastError = "subscription was deleted"
+	}
+	return u.deliveries.Save(ctx, delivery)
+}
+
+func (u *WebhookDispatcherUsecase) maxAttempts() int {
+	if u.cfg.MaxAttempts <= 0 {
+		return defaultWebhookMaxAttempts
+	}
+	return u.cfg.MaxAttempts
+}
+
+// backoff is the wait after the given number of failed attempts
+func (u *WebhookDispatcherUsecase) backoff(attempts int) time.Duration {
+	limit := u.cfg.MaxBackoff
+	if limit <= 0 {
+		limit = defaultWebhookMaxBackoff
+	}
+	return exponentialBackoff(attempts, limit)
+}
@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"golang-clean-web-api/common"
+	"golang-clean-web-api/config"
+	"golang-clean-web-api/domain/event"
+	"golang-clean-web-api/domain/filter"
+	"golang-clean-web-api/domain/model"
+	"golang-clean-web-api/domain/repository"
+	"golang-clean-web-api/pkg/clock"
+	"golang-clean-web-api/pkg/logging"
+	"golang-clean-web-api/pkg/service_errors"
+	"golang-clean-web-api/usecase/dto"
+)
+
+// secretLength is the number of random bytes of a generated secret
+const secretLength = 24
+
+// WebhookUsecase manages the webhook subscriptions and is the event sink that
+// queues a delivery for every subscription that wants a published event
+type WebhookUsecase struct {
+	base          *BaseUsecase[model.WebhookSubscription, dto.CreateWebhookSubscription, dto.UpdateWebhookSubscription, dto.WebhookSubscription]
+	logger        logging.Logger
+	clock         clock.Clock
+	subscriptions repository.WebhookSubscriptionRepository
+	deliveries    repository.WebhookDeliveryRepository
+	dispatcher    *WebhookDispatcherUsecase
+}
+
+func NewWebhookUsecase(cfg *config.Config, subscriptions repository.WebhookSubscriptionRepository,
+	deliveries repository.WebhookDeliveryRepository, transactions repository.TransactionManager) *WebhookUsecase {
+	return NewWebhookUsecaseWithClock(cfg, subscriptions, deliveries, transactions, clock.System)
+}
+
+// NewWebhookUsecaseWithClock dates deliveries, and signs pings and replays, by the time of c
+func NewWebhookUsecaseWithClock(cfg *config.Config, subscriptions repository.WebhookSubscriptionRepository,
+	deliveries repository.WebhookDeliveryRepository, transactions repository.TransactionManager, c clock.Clock) *WebhookUsecase {
+	return &WebhookUsecase{
+		base: NewBaseUsecase[model.WebhookSubscription, dto.CreateWebhookSubscription, dto.UpdateWebhookSubscription, dto.WebhookSubscription](
+			cfg, subscriptions, transactions),
+		logger:        logging.NewLogger(cfg),
+		clock:         c,
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+		dispatcher:    NewWebhookDispatcherUsecaseWithClock(cfg, subscriptions, deliveries, c),
+	}
+}
+
+// Create subscribes with the secret of the request or a generated one
+func (u *WebhookUsecase) Create(ctx context.Context, req dto.CreateWebhookSubscription) (dto.WebhookSubscription, error) {
+	if req.Secret == "" {
+		secret := make([]byte, secretLength)
+		if _, err := rand.Read(secret); err != nil {
+			return dto.WebhookSubscription{}, err
+		}
+		req.Secret = hex.EncodeToString(secret)
+	}
+	return u.base.Create(ctx, req)
+}
+
+// Update
+func (u *WebhookUsecase) Update(ctx context.Context, id int, req dto.UpdateWebhookSubscription) (dto.WebhookSubscription, error) {
+	return u.base.Update(ctx, id, req)
+}
+
+// Delete
+func (u *WebhookUsecase) Delete(ctx context.Context, id int) error {
+	return u.base.Delete(ctx, id)
+}
+
+// Get By Id
+func (u *WebhookUsecase) GetById(ctx context.Context, id int) (dto.WebhookSubscription, error) {
+	return u.base.GetById(ctx, id)
+}
+
+// Get By Filter
+func (u *WebhookUsecase) GetByFilter(ctx context.Context, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.WebhookSubscription], error) {
+	return u.base.GetByFilter(ctx, req)
+}
+
+// GetDeliveries pages the delivery log of a subscription, newest first unless sorted otherwise
+func (u *WebhookUsecase) GetDeliveries(ctx context.Context, id int, req filter.PaginationInputWithFilter) (*filter.PagedList[dto.WebhookDelivery], error) {
+	var response *filter.PagedList[dto.WebhookDelivery]
+	if _, err := u.subscriptions.GetById(ctx, id); err != nil {
+		return response, err
+	}
+	req.AndWhere(filter.Expression{Field: "SubscriptionId", Filter: filter.Filter{Type: "equals", From: strconv.Itoa(id)}})
+	if req.Sort == nil || len(*req.Sort) == 0 {
+		req.Sort = &[]filter.Sort{{ColId: "Id", Sort: "desc"}}
+	}
+	count, deliveries, err := u.deliveries.GetByFilter(ctx, req)
+	if err != nil {
+		return response, err
+	}
+	return filter.Paginate[model.WebhookDelivery, dto.WebhookDelivery](count, deliveries, req.GetPageNumber(), int64(req.GetPageSize()))
+}
+
+// Ping sends a test event to the subscription right away, active or not, and
+// returns the logged delivery. Pings are not retried.
+func (u *WebhookUsecase) Ping(ctx context.Context, id int) (dto.WebhookDelivery, error) {
+	subscription, err := u.subscriptions.GetById(ctx, id)
+	if err != nil {
+		return dto.WebhookDelivery{}, err
+	}
+	now := u.clock.Now().UTC()
+	body, err := json.Marshal(event.Event{
+		Type:          model.WebhookPing,
+		AggregateType: subscription.TableName(),
+		AggregateId:   subscription.Id,
+		Payload:       json.RawMessage("{}"),
+		OccurredAt:    now,
+	})
+	if err != nil {
+		return dto.WebhookDelivery{}, err
+	}
+	delivery, err := u.deliveries.Create(ctx, model.WebhookDelivery{
+		SubscriptionId: subscription.Id,
+		EventType:      model.WebhookPing,
+		Payload:        string(body),
+		Status:         model.WebhookPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	})
+	if err != nil {
+		return dto.WebhookDelivery{}, err
+	}
+	return u.attempt(ctx, subscription, delivery)
+}
+
+// Replay sends a delivery that has not succeeded again right away. A dead one
+// gets a fresh set of attempts.
+func (u *WebhookUsecase) Replay(ctx context.Context, id int) (dto.WebhookDelivery, error) {
+	delivery, err := u.deliveries.GetById(ctx, id)
+	if err != nil {
+		return dto.WebhookDelivery{}, err
+	}
+	if delivery.Status == model.WebhookDelivered {
+		return dto.WebhookDelivery{}, &service_errors.ServiceError{EndUserMessage: service_errors.AlreadyDelivered}
+	}
+	subscription, err := u.subscriptions.GetById(ctx, delivery.SubscriptionId)
+	if err != nil {
+		return dto.WebhookDelivery{}, err
+	}
+	if delivery.Status == model.WebhookDead {
+		delivery.Status = model.WebhookPending
+		delivery.Attempts = 0
+	}
+	return u.attempt(ctx, subscription, delivery)
+}
+
+func (u *WebhookUsecase) attempt(ctx context.Context, subscription model.WebhookSubscription, delivery model.WebhookDelivery) (dto.WebhookDelivery, error) {
+	delivery, err := u.dispatcher.Attempt(ctx, subscription, delivery)
+	if err != nil {
+		return dto.WebhookDelivery{}, err
+	}
+	return common.TypeConverter[dto.WebhookDelivery](delivery)
+}
+
+func (u *WebhookUsecase) Name() string {
+	return "webhooks"
+}
+
+// Publish queues a delivery of the event for every active subscription that
+// wants it. Changes of the subscriptions themselves are not sent.
+func (u *WebhookUsecase) Publish(ctx context.Context, e event.Event) error {
+	if e.AggregateType == (model.WebhookSubscription{}).TableName() {
+		return nil
+	}
+	subscriptions, err := u.subscriptions.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	now := u.clock.Now().UTC()
+	deliveries := []model.WebhookDelivery{}
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(e.AggregateType, e.Type) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			SubscriptionId: subscription.Id,
+			EventId:        sql.NullInt64{Valid: true, Int64: int64(e.Id)},
+			EventType:      e.Type,
+			Payload:        string(body),
+			Status:         model.WebhookPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+	return u.deliveries.Enqueue(ctx, deliveries)
+}
+
+// isNotFound tells whether a repository error is a missing row
+func isNotFound(err error) bool {
+	var serviceError *service_errors.ServiceError
+	return errors.As(err, &serviceError) && serviceError.EndUserMessage == service_errors.RecordNotFound
+}